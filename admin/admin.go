@@ -0,0 +1,267 @@
+package admin
+
+import (
+	"encoding/json"
+	"errors"
+	"io"
+	"log/slog"
+	"net"
+	"net/http"
+	"sessionmgr"
+	"sessionmgr/conf"
+	"sessionmgr/logs"
+	"strconv"
+	"strings"
+)
+
+var ErrNotLoopback = errors.New("admin server must listen on a loopback address")
+var ErrOrigin = errors.New("requests from browsers are rejected")
+var ErrHost = errors.New("requests must be addressed to localhost")
+
+const maxBodySize = 1 << 16
+
+// redacted replaces secrets in the dumped config
+const redacted = "[redacted]"
+
+// Server inspect and control a manager over HTTP, it is meant for localhost only
+//
+//	GET    /sessions              every session with its states and LastUsed
+//	GET    /sessions/{id}         one session
+//	GET    /sessions/{id}/stats   pion statistics of one session
+//	DELETE /sessions/{id}         drop a session
+//	POST   /reload                ReloadConfig from the path given to New
+//	GET    /log                   current log levels
+//	POST   /log                   body is a level spec such as "info,ICE=debug,SESSION=off"
+//	GET    /config                current config, secrets redacted
+type Server struct {
+	mgr      *sessionmgr.SessionManagerImpl
+	confPath string
+	mux      *http.ServeMux
+}
+
+func New(mgr *sessionmgr.SessionManagerImpl, confPath string) *Server {
+	s := &Server{mgr: mgr, confPath: confPath, mux: http.NewServeMux()}
+	s.mux.HandleFunc("GET /sessions", s.handleSessions)
+	s.mux.HandleFunc("GET /sessions/{id}", s.handleSession)
+	s.mux.HandleFunc("GET /sessions/{id}/stats", s.handleStats)
+	s.mux.HandleFunc("DELETE /sessions/{id}", s.handleDrop)
+	s.mux.HandleFunc("POST /reload", s.handleReload)
+	s.mux.HandleFunc("GET /log", s.handleLevels)
+	s.mux.HandleFunc("POST /log", s.handleSetLevels)
+	s.mux.HandleFunc("GET /config", s.handleConfig)
+	return s
+}
+
+// Listen listen on addr, it must be a loopback address such as 127.0.0.1:8081
+func Listen(addr string) (net.Listener, error) {
+	host, _, err := net.SplitHostPort(addr)
+	if err != nil {
+		return nil, err
+	}
+	if ip := net.ParseIP(host); host != "localhost" && (ip == nil || !ip.IsLoopback()) {
+		return nil, ErrNotLoopback
+	}
+	return net.Listen("tcp", addr)
+}
+
+func (s *Server) ServeHTTP(w http.ResponseWriter, r *http.Request) {
+	// a page in a local browser could reach a loopback address, browsers always send Origin on
+	// cross-origin requests
+	if r.Header.Get("Origin") != "" {
+		http.Error(w, ErrOrigin.Error(), http.StatusForbidden)
+		return
+	}
+	// a name rebound to 127.0.0.1 reaches the server with that name as Host
+	if !loopbackHost(r.Host) {
+		http.Error(w, ErrHost.Error(), http.StatusForbidden)
+		return
+	}
+	s.mux.ServeHTTP(w, r)
+}
+
+// loopbackHost tell whether host, with or without a port, is localhost or a loopback IP literal
+func loopbackHost(host string) bool {
+	if name, _, err := net.SplitHostPort(host); err == nil {
+		host = name
+	}
+	host = strings.TrimSuffix(strings.TrimPrefix(host, "["), "]")
+	if strings.EqualFold(host, "localhost") {
+		return true
+	}
+	ip := net.ParseIP(host)
+	return ip != nil && ip.IsLoopback()
+}
+
+func (s *Server) handleSessions(w http.ResponseWriter, r *http.Request) {
+	infos, err := s.mgr.Sessions()
+	if err != nil {
+		writeError(w, err)
+		return
+	}
+	writeJSON(w, infos)
+}
+
+func (s *Server) handleSession(w http.ResponseWriter, r *http.Request) {
+	SessionID, ok := sessionID(w, r)
+	if !ok {
+		return
+	}
+	info, err := s.mgr.Inspect(SessionID)
+	if err != nil {
+		writeError(w, err)
+		return
+	}
+	writeJSON(w, info)
+}
+
+func (s *Server) handleStats(w http.ResponseWriter, r *http.Request) {
+	SessionID, ok := sessionID(w, r)
+	if !ok {
+		return
+	}
+	stats, err := s.mgr.SessionStats(SessionID)
+	if err != nil {
+		writeError(w, err)
+		return
+	}
+	writeJSON(w, stats)
+}
+
+func (s *Server) handleDrop(w http.ResponseWriter, r *http.Request) {
+	SessionID, ok := sessionID(w, r)
+	if !ok {
+		return
+	}
+	if _, err := s.mgr.Inspect(SessionID); err != nil {
+		writeError(w, err)
+		return
+	}
+	if err := s.mgr.DropSession(SessionID); err != nil {
+		writeError(w, err)
+		return
+	}
+	s.mgr.Logger().Warn(logs.MANAGER, "session dropped by admin", "session", SessionID)
+	w.WriteHeader(http.StatusNoContent)
+}
+
+func (s *Server) handleReload(w http.ResponseWriter, r *http.Request) {
+	if err := s.mgr.ReloadConfig(s.confPath); err != nil {
+		writeError(w, err)
+		return
+	}
+	w.WriteHeader(http.StatusNoContent)
+}
+
+// levels is the body of GET /log
+type levels struct {
+	Level  string            `json:"Level"`
+	Topics map[string]string `json:"Topics"`
+}
+
+func (s *Server) handleLevels(w http.ResponseWriter, r *http.Request) {
+	level, topics := s.mgr.Logger().Levels()
+	body := levels{Level: levelName(level), Topics: make(map[string]string, len(topics))}
+	for topic, level := range topics {
+		body.Topics[string(topic)] = levelName(level)
+	}
+	writeJSON(w, body)
+}
+
+func (s *Server) handleSetLevels(w http.ResponseWriter, r *http.Request) {
+	spec, err := io.ReadAll(http.MaxBytesReader(w, r.Body, maxBodySize))
+	if err != nil {
+		http.Error(w, err.Error(), http.StatusRequestEntityTooLarge)
+		return
+	}
+	logger := s.mgr.Logger()
+	if logger == nil {
+		http.Error(w, "logging is disabled", http.StatusConflict)
+		return
+	}
+	if err = logger.SetLevels(string(spec)); err != nil {
+		http.Error(w, err.Error(), http.StatusBadRequest)
+		return
+	}
+	logger.Info(logs.CONFIG, "log levels changed by admin", "spec", strings.TrimSpace(string(spec)))
+	s.handleLevels(w, r)
+}
+
+func (s *Server) handleConfig(w http.ResponseWriter, r *http.Request) {
+	writeJSON(w, redact(s.mgr.Config()))
+}
+
+// redact replace the credentials of config, the slices and maps it changes are copied first
+func redact(config *conf.Configuration) *conf.Configuration {
+	iceServers := append(config.WebrtcConf.ICEServers[:0:0], config.WebrtcConf.ICEServers...)
+	for i := range iceServers {
+		if iceServers[i].Credential != nil {
+			iceServers[i].Credential = redacted
+		}
+	}
+	config.WebrtcConf.ICEServers = iceServers
+	config.WebrtcConf.Certificates = nil
+
+	if config.TurnServer.Users != nil {
+		users := make(map[string]string, len(config.TurnServer.Users))
+		for user := range config.TurnServer.Users {
+			users[user] = redacted
+		}
+		config.TurnServer.Users = users
+	}
+	secrets := []*string{
+		&config.TurnServer.AuthSecret,
+		&config.Envelope.HMACKey,
+		&config.Envelope.Token,
+		&config.Envelope.Ed25519PrivateKey,
+		&config.Encryption.Key,
+		&config.Encryption.Passphrase,
+		&config.Auth.Token,
+		&config.Auth.HMACKey,
+	}
+	for _, secret := range secrets {
+		if *secret != "" {
+			*secret = redacted
+		}
+	}
+	return config
+}
+
+func sessionID(w http.ResponseWriter, r *http.Request) (int32, bool) {
+	id, err := strconv.ParseInt(r.PathValue("id"), 10, 32)
+	if err != nil {
+		http.Error(w, "session ID must be an int32", http.StatusBadRequest)
+		return 0, false
+	}
+	return int32(id), true
+}
+
+func levelName(level slog.Level) string {
+	switch level {
+	case logs.LevelOff:
+		return "off"
+	case logs.LevelTrace:
+		return "trace"
+	}
+	return strings.ToLower(level.String())
+}
+
+func writeJSON(w http.ResponseWriter, v any) {
+	w.Header().Set("Content-Type", "application/json")
+	enc := json.NewEncoder(w)
+	enc.SetIndent("", "  ")
+	_ = enc.Encode(v)
+}
+
+// writeError map the sentinel of a manager error to a status
+func writeError(w http.ResponseWriter, err error) {
+	status := http.StatusInternalServerError
+	switch {
+	case errors.Is(err, sessionmgr.ErrLost):
+		status = http.StatusNotFound
+	case errors.Is(err, sessionmgr.ErrCall):
+		status = http.StatusServiceUnavailable
+	case errors.Is(err, sessionmgr.ErrID), errors.Is(err, sessionmgr.ErrSdp):
+		status = http.StatusBadRequest
+	}
+	http.Error(w, err.Error(), status)
+}
@@ -0,0 +1,139 @@
+package admin
+
+import (
+	"encoding/json"
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"sessionmgr"
+	"sessionmgr/internal/testconf"
+	"sessionmgr/internal/testmgr"
+	"strings"
+	"testing"
+)
+
+// testConf is merged into testconf.Base, its WebRTC replaces the empty one
+const testConf = `,"WebRTC":{"iceServers":[{"urls":["turn:example.org:3478"],"username":"u","credential":"turn-password"}]},
+"Auth":{"Mode":"token","Token":"auth-token"},"Log":{"Level":"warn","Output":"stderr"}`
+
+func newServer(t *testing.T) (*sessionmgr.SessionManagerImpl, *Server) {
+	path := testconf.Write(t, testConf)
+	mgr := testmgr.Open(t, path)
+	return mgr, New(mgr, path)
+}
+
+func do(s *Server, method string, target string, body string) *httptest.ResponseRecorder {
+	rec := httptest.NewRecorder()
+	s.ServeHTTP(rec, httptest.NewRequest(method, "http://127.0.0.1:8081"+target, strings.NewReader(body)))
+	return rec
+}
+
+func TestSessions(t *testing.T) {
+	mgr, s := newServer(t)
+	if err := mgr.CreateSession(7); err != nil {
+		t.Fatal(err)
+	}
+
+	rec := do(s, http.MethodGet, "/sessions", "")
+	var infos []sessionmgr.SessionInfo
+	if err := json.Unmarshal(rec.Body.Bytes(), &infos); err != nil {
+		t.Fatal(err, rec.Body.String())
+	}
+	if len(infos) != 1 || infos[0].SessionID != 7 || infos[0].Role != "offerer" || infos[0].LastUsed.IsZero() {
+		t.Fatalf("unexpected sessions %+v", infos)
+	}
+	if rec = do(s, http.MethodGet, "/sessions/7/stats", ""); rec.Code != http.StatusOK || !strings.Contains(rec.Body.String(), "peer-connection") {
+		t.Errorf("unexpected stats %d %s", rec.Code, rec.Body.String())
+	}
+	if rec = do(s, http.MethodGet, "/sessions/x", ""); rec.Code != http.StatusBadRequest {
+		t.Errorf("expected a bad request, got %d", rec.Code)
+	}
+
+	if rec = do(s, http.MethodDelete, "/sessions/7", ""); rec.Code != http.StatusNoContent {
+		t.Fatalf("drop: %d %s", rec.Code, rec.Body.String())
+	}
+	if _, err := mgr.Inspect(7); !errors.Is(err, sessionmgr.ErrLost) {
+		t.Errorf("expected session to be dropped, got %v", err)
+	}
+	if rec = do(s, http.MethodDelete, "/sessions/7", ""); rec.Code != http.StatusNotFound {
+		t.Errorf("expected a dropped session not to be found, got %d", rec.Code)
+	}
+}
+
+func TestLevels(t *testing.T) {
+	mgr, s := newServer(t)
+	rec := do(s, http.MethodPost, "/log", "info,ICE=debug")
+	if rec.Code != http.StatusOK || !strings.Contains(rec.Body.String(), `"ICE": "debug"`) {
+		t.Fatalf("unexpected levels %d %s", rec.Code, rec.Body.String())
+	}
+	level, _ := mgr.Logger().Levels()
+	if levelName(level) != "info" {
+		t.Errorf("expected info, got %v", level)
+	}
+	if rec = do(s, http.MethodPost, "/log", "ICE=loud"); rec.Code != http.StatusBadRequest {
+		t.Errorf("expected an invalid level to be rejected, got %d", rec.Code)
+	}
+}
+
+func TestConfigRedacted(t *testing.T) {
+	mgr, s := newServer(t)
+	body := do(s, http.MethodGet, "/config", "").Body.String()
+	for _, secret := range []string{"turn-password", "auth-token"} {
+		if strings.Contains(body, secret) {
+			t.Errorf("%q leaked in %s", secret, body)
+		}
+	}
+	if !strings.Contains(body, redacted) {
+		t.Errorf("expected redacted secrets in %s", body)
+	}
+	// the manager keeps its secrets
+	if mgr.Config().Auth.Token != "auth-token" || mgr.Config().WebrtcConf.ICEServers[0].Credential != "turn-password" {
+		t.Error("redaction changed the manager config")
+	}
+
+	if rec := do(s, http.MethodPost, "/reload", ""); rec.Code != http.StatusNoContent {
+		t.Errorf("reload: %d %s", rec.Code, rec.Body.String())
+	}
+}
+
+func TestBrowserRejected(t *testing.T) {
+	_, s := newServer(t)
+	req := httptest.NewRequest(http.MethodDelete, "/sessions/1", nil)
+	req.Header.Set("Origin", "http://example.org")
+	rec := httptest.NewRecorder()
+	s.ServeHTTP(rec, req)
+	if rec.Code != http.StatusForbidden {
+		t.Errorf("expected a request with Origin to be rejected, got %d", rec.Code)
+	}
+}
+
+func TestRebindingRejected(t *testing.T) {
+	_, s := newServer(t)
+	for host, want := range map[string]int{
+		"attacker.example:8081": http.StatusForbidden,
+		"127.0.0.1.nip.io":      http.StatusForbidden,
+		"localhost:8081":        http.StatusOK,
+		"LOCALHOST":             http.StatusOK,
+		"127.0.0.1":             http.StatusOK,
+		"[::1]:8081":            http.StatusOK,
+	} {
+		req := httptest.NewRequest(http.MethodGet, "/sessions", nil)
+		req.Host = host
+		rec := httptest.NewRecorder()
+		s.ServeHTTP(rec, req)
+		if rec.Code != want {
+			t.Errorf("expected %d for Host %v, got %d", want, host, rec.Code)
+		}
+	}
+}
+
+func TestListen(t *testing.T) {
+	if _, err := Listen("0.0.0.0:0"); !errors.Is(err, ErrNotLoopback) {
+		t.Errorf("expected ErrNotLoopback, got %v", err)
+	}
+	l, err := Listen("127.0.0.1:0")
+	if err != nil {
+		t.Fatal(err)
+	}
+	_ = l.Close()
+}
@@ -0,0 +1,92 @@
+package audit
+
+import (
+	"encoding/json"
+	"io"
+	"os"
+	"path/filepath"
+	"sync"
+	"time"
+)
+
+// kinds of Event
+const (
+	EventCreate  = "create"
+	EventJoin    = "join"
+	EventOffer   = "offer"
+	EventAnswer  = "answer"
+	EventConfirm = "confirm"
+	EventConnect = "connect"
+	EventDrop    = "drop"
+)
+
+// Event is one line of the audit log, fields that do not apply to its kind are omitted
+type Event struct {
+	Time      time.Time `json:"Time"`
+	Event     string    `json:"Event"`
+	SessionID int32     `json:"SessionID"`
+	// Role is offerer or answerer
+	Role string `json:"Role,omitempty"`
+	// LocalCandidate and RemoteCandidate are the selected pair as "typ address:port"
+	LocalCandidate  string `json:"LocalCandidate,omitempty"`
+	RemoteCandidate string `json:"RemoteCandidate,omitempty"`
+	// Fingerprint is the remote DTLS certificate
+	Fingerprint string `json:"Fingerprint,omitempty"`
+	Reason      string `json:"Reason,omitempty"`
+	// BytesSent, BytesReceived and Duration (in seconds since creation) are totals of a drop
+	BytesSent     uint64  `json:"BytesSent,omitempty"`
+	BytesReceived uint64  `json:"BytesReceived,omitempty"`
+	Duration      float64 `json:"Duration,omitempty"`
+}
+
+// Log append events as JSON lines, a nil *Log records nothing
+type Log struct {
+	mu     sync.Mutex
+	w      io.Writer
+	closer io.Closer
+}
+
+// New create a Log writing to w
+func New(w io.Writer) *Log {
+	return &Log{w: w}
+}
+
+// Open append to the file at path, it is created readable by its owner only
+func Open(path string) (*Log, error) {
+	if err := os.MkdirAll(filepath.Dir(path), 0755); err != nil {
+		return nil, err
+	}
+	file, err := os.OpenFile(path, os.O_WRONLY|os.O_CREATE|os.O_APPEND, 0600)
+	if err != nil {
+		return nil, err
+	}
+	return &Log{w: file, closer: file}, nil
+}
+
+// Record write e as one line, Time is set when zero
+func (l *Log) Record(e Event) error {
+	if l == nil {
+		return nil
+	}
+	if e.Time.IsZero() {
+		e.Time = time.Now().UTC()
+	}
+	line, err := json.Marshal(e)
+	if err != nil {
+		return err
+	}
+	l.mu.Lock()
+	defer l.mu.Unlock()
+	// one write per line keeps lines whole in O_APPEND files shared by processes
+	_, err = l.w.Write(append(line, '\n'))
+	return err
+}
+
+func (l *Log) Close() error {
+	if l == nil || l.closer == nil {
+		return nil
+	}
+	l.mu.Lock()
+	defer l.mu.Unlock()
+	return l.closer.Close()
+}
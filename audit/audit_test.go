@@ -0,0 +1,54 @@
+package audit
+
+import (
+	"bufio"
+	"encoding/json"
+	"os"
+	"path/filepath"
+	"testing"
+)
+
+func TestRecord(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "audit", "sessions.jsonl")
+	l, err := Open(path)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if err = l.Record(Event{Event: EventCreate, SessionID: 1, Role: "offerer"}); err != nil {
+		t.Fatal(err)
+	}
+	if err = l.Record(Event{Event: EventDrop, SessionID: 1, Reason: "requested", BytesSent: 5, Duration: 1.5}); err != nil {
+		t.Fatal(err)
+	}
+	if err = l.Close(); err != nil {
+		t.Fatal(err)
+	}
+	// a nil Log records nothing
+	if err = (*Log)(nil).Record(Event{Event: EventJoin}); err != nil {
+		t.Fatal(err)
+	}
+
+	file, err := os.Open(path)
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer file.Close()
+	if info, _ := file.Stat(); info.Mode().Perm() != 0600 {
+		t.Errorf("expected the audit log to be private, got %v", info.Mode().Perm())
+	}
+	var events []Event
+	scanner := bufio.NewScanner(file)
+	for scanner.Scan() {
+		var e Event
+		if err = json.Unmarshal(scanner.Bytes(), &e); err != nil {
+			t.Fatal(err)
+		}
+		events = append(events, e)
+	}
+	if len(events) != 2 || events[0].Event != EventCreate || events[0].Time.IsZero() {
+		t.Fatalf("unexpected events %+v", events)
+	}
+	if drop := events[1]; drop.Reason != "requested" || drop.BytesSent != 5 || drop.Duration != 1.5 {
+		t.Errorf("unexpected drop %+v", drop)
+	}
+}
@@ -0,0 +1,297 @@
+package sessionmgr
+
+import (
+	"bytes"
+	"crypto/hmac"
+	"crypto/rand"
+	"crypto/sha256"
+	"encoding/binary"
+	"fmt"
+	"sessionmgr/conf"
+	"sync"
+	"sync/atomic"
+	"time"
+)
+
+// Authenticator check a peer before its messages are delivered, both sides send a challenge,
+// answer the challenge of the peer and verify the answer to their own
+type Authenticator interface {
+	// Challenge return what is sent to the peer when the channel opens, it may be empty
+	Challenge(SessionID int32) ([]byte, error)
+	// Respond answer challenge of the peer, own is the challenge this side sent
+	Respond(SessionID int32, challenge, own []byte) ([]byte, error)
+	// Verify check the response of the peer to own, challenge is the one the peer sent
+	Verify(SessionID int32, own, challenge, response []byte) error
+}
+
+// TokenAuthenticator accept peers holding the same shared token, the response is a MAC of the challenges
+// keyed with the token so the token never crosses the wire and an echoed response does not pass
+type TokenAuthenticator struct {
+	Token string
+}
+
+func (a *TokenAuthenticator) Challenge(SessionID int32) ([]byte, error) {
+	return a.hmac().Challenge(SessionID)
+}
+
+func (a *TokenAuthenticator) Respond(SessionID int32, challenge, own []byte) ([]byte, error) {
+	return a.hmac().Respond(SessionID, challenge, own)
+}
+
+func (a *TokenAuthenticator) Verify(SessionID int32, own, challenge, response []byte) error {
+	if err := a.hmac().Verify(SessionID, own, challenge, response); err != nil {
+		return fmt.Errorf("%w: wrong token", ErrAuth)
+	}
+	return nil
+}
+
+func (a *TokenAuthenticator) hmac() *HMACAuthenticator {
+	return &HMACAuthenticator{Key: []byte(a.Token)}
+}
+
+// HMACAuthenticator accept peers proving they hold Key, the key itself never crosses the wire
+type HMACAuthenticator struct {
+	Key []byte
+}
+
+func (a *HMACAuthenticator) Challenge(int32) ([]byte, error) {
+	challenge := make([]byte, 32)
+	if _, err := rand.Read(challenge); err != nil {
+		return nil, err
+	}
+	return challenge, nil
+}
+
+func (a *HMACAuthenticator) Respond(_ int32, challenge, own []byte) ([]byte, error) {
+	// a peer reflecting our own challenge would get us to answer it
+	if bytes.Equal(challenge, own) {
+		return nil, fmt.Errorf("%w: challenge reflected", ErrAuth)
+	}
+	return a.mac(challenge, own), nil
+}
+
+func (a *HMACAuthenticator) Verify(_ int32, own, challenge, response []byte) error {
+	if !hmac.Equal(response, a.mac(own, challenge)) {
+		return fmt.Errorf("%w: wrong response", ErrAuth)
+	}
+	return nil
+}
+
+// mac bind both challenges in order, so a response can't be replayed as the other direction,
+// each is prefixed with its length so bytes can't be moved from one to the other
+func (a *HMACAuthenticator) mac(challenged, responder []byte) []byte {
+	mac := hmac.New(sha256.New, a.Key)
+	mac.Write([]byte("sessionmgr auth"))
+	for _, challenge := range [][]byte{challenged, responder} {
+		mac.Write(binary.BigEndian.AppendUint32(nil, uint32(len(challenge))))
+		mac.Write(challenge)
+	}
+	return mac.Sum(nil)
+}
+
+// handshake frames, the first byte of every message sent before authentication passed
+//
+//	challenge -> response -> accept, or reject with a reason
+const (
+	frameChallenge byte = 'c'
+	frameResponse  byte = 'r'
+	frameAccept    byte = 'a'
+	frameReject    byte = 'x'
+)
+
+// DefaultHandshakeTimeout is the time a peer has to pass the handshake when Auth.Timeout is not set
+const DefaultHandshakeTimeout = 10 * time.Second
+
+var errHandshakeTimeout = fmt.Errorf("%w: handshake timed out", ErrAuth)
+
+// challengeBook hold the challenges of the handshakes in progress of a manager, a peer sending one of them
+// back in another session would get us to answer for it
+type challengeBook struct {
+	mu     sync.Mutex
+	issued map[string]struct{}
+}
+
+func (b *challengeBook) add(challenge []byte) {
+	if b == nil {
+		return
+	}
+	b.mu.Lock()
+	defer b.mu.Unlock()
+	if b.issued == nil {
+		b.issued = make(map[string]struct{})
+	}
+	b.issued[string(challenge)] = struct{}{}
+}
+
+func (b *challengeBook) remove(challenge []byte) {
+	if b == nil {
+		return
+	}
+	b.mu.Lock()
+	defer b.mu.Unlock()
+	delete(b.issued, string(challenge))
+}
+
+func (b *challengeBook) has(challenge []byte) bool {
+	if b == nil {
+		return false
+	}
+	b.mu.Lock()
+	defer b.mu.Unlock()
+	_, ok := b.issued[string(challenge)]
+	return ok
+}
+
+// roleByte start every challenge so the responses of both directions differ
+func roleByte(role string) byte {
+	if role == roleOfferer {
+		return 'o'
+	}
+	return 'a'
+}
+
+// Handshake is the authentication state of a session
+type Handshake struct {
+	mu        sync.Mutex
+	auth      Authenticator
+	sessionID int32
+	own       []byte
+	peer      []byte
+	started   bool
+	// challenged is set once the peer challenge arrived, it may be empty
+	challenged bool
+	// verified: we accepted the peer, accepted: the peer accepted us
+	verified bool
+	accepted bool
+	passed   atomic.Bool
+	// timeout is the time the peer has from Start to pass
+	timeout time.Duration
+	// role is the first byte of our challenge, the peer challenge starts with the other one
+	role byte
+	book *challengeBook
+}
+
+// newHandshake start the handshake of SessionID on our side of role, book is shared by the sessions of a manager
+func newHandshake(SessionID int32, role string, auth Authenticator, book *challengeBook, timeout time.Duration) *Handshake {
+	if timeout <= 0 {
+		timeout = DefaultHandshakeTimeout
+	}
+	return &Handshake{auth: auth, sessionID: SessionID, role: roleByte(role), book: book, timeout: timeout}
+}
+
+// Passed tell whether both sides accepted each other, every later message is a user message
+func (h *Handshake) Passed() bool {
+	return h.passed.Load()
+}
+
+// Start send our challenge once, it is called when the channel opens
+func (h *Handshake) Start(send func([]byte) error) error {
+	h.mu.Lock()
+	defer h.mu.Unlock()
+	return h.start(send)
+}
+
+// Handle consume a handshake message of the peer, an error means the peer must be dropped
+func (h *Handshake) Handle(msg []byte, send func([]byte) error) error {
+	h.mu.Lock()
+	defer h.mu.Unlock()
+	// the peer message may arrive before our open event
+	if err := h.start(send); err != nil {
+		return err
+	}
+	if len(msg) == 0 {
+		return fmt.Errorf("%w: empty handshake message", ErrAuth)
+	}
+	body := msg[1:]
+	switch msg[0] {
+	case frameChallenge:
+		if h.challenged {
+			return fmt.Errorf("%w: challenge repeated", ErrAuth)
+		}
+		if len(body) == 0 || body[0] == h.role {
+			return fmt.Errorf("%w: peer claims our role", ErrAuth)
+		}
+		if h.book.has(body) {
+			return fmt.Errorf("%w: challenge of another session", ErrAuth)
+		}
+		h.peer, h.challenged = body, true
+		response, err := h.auth.Respond(h.sessionID, h.peer, h.own)
+		if err != nil {
+			return err
+		}
+		return send(append([]byte{frameResponse}, response...))
+	case frameResponse:
+		if !h.challenged || h.verified {
+			return fmt.Errorf("%w: unexpected response", ErrAuth)
+		}
+		if err := h.auth.Verify(h.sessionID, h.own, h.peer, body); err != nil {
+			return err
+		}
+		h.verified = true
+		if err := send([]byte{frameAccept}); err != nil {
+			return err
+		}
+	case frameAccept:
+		// the peer sends its response before it can accept ours
+		if !h.verified {
+			return fmt.Errorf("%w: accepted before responding", ErrAuth)
+		}
+		h.accepted = true
+	case frameReject:
+		return fmt.Errorf("%w: rejected by peer: %s", ErrAuth, body)
+	default:
+		return fmt.Errorf("%w: unexpected message before authentication", ErrAuth)
+	}
+	if h.verified && h.accepted {
+		h.book.remove(h.own)
+		h.passed.Store(true)
+	}
+	return nil
+}
+
+// Release forget our challenge, it is called when the session is dropped
+func (h *Handshake) Release() {
+	h.mu.Lock()
+	defer h.mu.Unlock()
+	h.book.remove(h.own)
+}
+
+// Reject tell the peer why it is dropped, best effort
+func (h *Handshake) Reject(reason error, send func([]byte) error) {
+	_ = send(append([]byte{frameReject}, reason.Error()...))
+}
+
+func (h *Handshake) start(send func([]byte) error) error {
+	if h.started {
+		return nil
+	}
+	h.started = true
+	nonce, err := h.auth.Challenge(h.sessionID)
+	if err != nil {
+		return err
+	}
+	// the responses cover both challenges, so they also cover which side is responding
+	h.own = append([]byte{h.role}, nonce...)
+	h.book.add(h.own)
+	return send(append([]byte{frameChallenge}, h.own...))
+}
+
+// newAuthenticator build the authenticator described by config, nil when disabled
+func newAuthenticator(config *conf.AuthConf) (Authenticator, error) {
+	switch config.Mode {
+	case "":
+		return nil, nil
+	case "token":
+		if config.Token == "" {
+			return nil, fmt.Errorf("%w: empty token", ErrAuth)
+		}
+		return &TokenAuthenticator{Token: config.Token}, nil
+	case "hmac":
+		if config.HMACKey == "" {
+			return nil, fmt.Errorf("%w: empty hmac key", ErrAuth)
+		}
+		return &HMACAuthenticator{Key: []byte(config.HMACKey)}, nil
+	default:
+		return nil, fmt.Errorf("%w: unknown mode %q", ErrAuth, config.Mode)
+	}
+}
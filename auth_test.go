@@ -0,0 +1,247 @@
+package sessionmgr
+
+import (
+	"bytes"
+	"errors"
+	"testing"
+	"time"
+)
+
+// pipe run two handshakes against each other in memory, returning the first failure
+func pipe(a, b *Handshake) (errA, errB error) {
+	var toA, toB [][]byte
+	sendA := func(msg []byte) error { toB = append(toB, msg); return nil }
+	sendB := func(msg []byte) error { toA = append(toA, msg); return nil }
+	if err := a.Start(sendA); err != nil {
+		return err, nil
+	}
+	if err := b.Start(sendB); err != nil {
+		return nil, err
+	}
+	for len(toA) > 0 || len(toB) > 0 {
+		if len(toB) > 0 {
+			msg := toB[0]
+			toB = toB[1:]
+			if err := b.Handle(msg, sendB); err != nil {
+				return nil, err
+			}
+		}
+		if len(toA) > 0 {
+			msg := toA[0]
+			toA = toA[1:]
+			if err := a.Handle(msg, sendA); err != nil {
+				return err, nil
+			}
+		}
+	}
+	return nil, nil
+}
+
+func TestHandshake(t *testing.T) {
+	cases := []struct {
+		name  string
+		a, b  Authenticator
+		valid bool
+	}{
+		{"token", &TokenAuthenticator{Token: "t"}, &TokenAuthenticator{Token: "t"}, true},
+		{"wrong token", &TokenAuthenticator{Token: "t"}, &TokenAuthenticator{Token: "u"}, false},
+		{"hmac", &HMACAuthenticator{Key: []byte("k")}, &HMACAuthenticator{Key: []byte("k")}, true},
+		{"wrong hmac key", &HMACAuthenticator{Key: []byte("k")}, &HMACAuthenticator{Key: []byte("l")}, false},
+	}
+	for _, c := range cases {
+		a, b := newHandshake(1, roleOfferer, c.a, nil, 0), newHandshake(2, roleAnswerer, c.b, nil, 0)
+		errA, errB := pipe(a, b)
+		if c.valid {
+			if errA != nil || errB != nil || !a.Passed() || !b.Passed() {
+				t.Errorf("%v: expected handshake to pass, got %v, %v", c.name, errA, errB)
+			}
+			continue
+		}
+		if !errors.Is(errA, ErrAuth) && !errors.Is(errB, ErrAuth) {
+			t.Errorf("%v: expected ErrAuth, got %v, %v", c.name, errA, errB)
+		}
+		if a.Passed() || b.Passed() {
+			t.Errorf("%v: handshake passed", c.name)
+		}
+	}
+}
+
+func TestHandshakeRejectsReflection(t *testing.T) {
+	h := newHandshake(1, roleOfferer, &HMACAuthenticator{Key: []byte("k")}, nil, 0)
+	var sent [][]byte
+	send := func(msg []byte) error { sent = append(sent, msg); return nil }
+	if err := h.Start(send); err != nil {
+		t.Fatal(err)
+	}
+	// echo our own challenge back
+	if err := h.Handle(sent[0], send); !errors.Is(err, ErrAuth) {
+		t.Errorf("expected reflected challenge to fail, got %v", err)
+	}
+	if err := newHandshake(2, roleOfferer, &TokenAuthenticator{Token: "t"}, nil, 0).Handle([]byte("user data"), send); !errors.Is(err, ErrAuth) {
+		t.Errorf("expected user data before authentication to fail, got %v", err)
+	}
+}
+
+func TestHandshakeRejectsEchoedToken(t *testing.T) {
+	h := newHandshake(1, roleOfferer, &TokenAuthenticator{Token: "t"}, nil, 0)
+	var sent [][]byte
+	send := func(msg []byte) error { sent = append(sent, msg); return nil }
+	if err := h.Start(send); err != nil {
+		t.Fatal(err)
+	}
+	// a peer without the token challenges us and sends our response back as its own
+	if err := h.Handle([]byte{frameChallenge, 'a', 1, 2, 3}, send); err != nil {
+		t.Fatal(err)
+	}
+	response := sent[len(sent)-1]
+	if response[0] != frameResponse || bytes.Equal(response[1:], []byte("t")) {
+		t.Fatalf("expected a response that is not the token, got %q", response)
+	}
+	if err := h.Handle(response, send); !errors.Is(err, ErrAuth) {
+		t.Errorf("expected an echoed response to fail, got %v", err)
+	}
+	if h.Passed() {
+		t.Error("handshake passed")
+	}
+}
+
+func TestHandshakeRejectsOtherSessions(t *testing.T) {
+	auth := &HMACAuthenticator{Key: []byte("k")}
+	var book challengeBook
+	var sent1, sent2 [][]byte
+	send1 := func(msg []byte) error { sent1 = append(sent1, msg); return nil }
+	send2 := func(msg []byte) error { sent2 = append(sent2, msg); return nil }
+	// the attacker is answerer in session 1 and offerer in session 2, so the roles fit
+	h1 := newHandshake(1, roleOfferer, auth, &book, 0)
+	h2 := newHandshake(2, roleAnswerer, auth, &book, 0)
+	if err := h1.Start(send1); err != nil {
+		t.Fatal(err)
+	}
+	if err := h2.Start(send2); err != nil {
+		t.Fatal(err)
+	}
+	// session 2 must not answer the challenge of session 1
+	if err := h2.Handle(sent1[0], send2); !errors.Is(err, ErrAuth) {
+		t.Errorf("expected the challenge of session 1 to fail in session 2, got %v", err)
+	}
+	if err := h1.Handle(sent2[0], send1); !errors.Is(err, ErrAuth) {
+		t.Errorf("expected the challenge of session 2 to fail in session 1, got %v", err)
+	}
+	// a peer claiming our role is refused even with a fresh challenge
+	h3 := newHandshake(3, roleOfferer, auth, &book, 0)
+	if err := h3.Handle([]byte{frameChallenge, 'o', 1, 2, 3}, send1); !errors.Is(err, ErrAuth) {
+		t.Errorf("expected a peer in our role to fail, got %v", err)
+	}
+	h1.Release()
+	h2.Release()
+	h3.Release()
+	if len(book.issued) != 0 {
+		t.Errorf("expected released challenges to be forgotten, got %d", len(book.issued))
+	}
+}
+
+func TestHandshakeRejectsShiftedChallenges(t *testing.T) {
+	auth := &HMACAuthenticator{Key: []byte("k")}
+	var book challengeBook
+	var sent1, sent2 [][]byte
+	send1 := func(msg []byte) error { sent1 = append(sent1, msg); return nil }
+	send2 := func(msg []byte) error { sent2 = append(sent2, msg); return nil }
+	h1 := newHandshake(1, roleOfferer, auth, &book, 0)
+	h2 := newHandshake(2, roleAnswerer, auth, &book, 0)
+	if err := h1.Start(send1); err != nil {
+		t.Fatal(err)
+	}
+	if err := h2.Start(send2); err != nil {
+		t.Fatal(err)
+	}
+	own1, own2 := sent1[0][1:], sent2[0][1:]
+	// own1+"a" then own2 in session 2 is the same bytes as own1 then "a"+own2 in session 1
+	shifted := append(append([]byte{frameChallenge}, own1...), 'a')
+	if err := h2.Handle(shifted, send2); err != nil {
+		t.Fatal(err)
+	}
+	response := sent2[len(sent2)-1]
+	if err := h1.Handle(append([]byte{frameChallenge, 'a'}, own2...), send1); err != nil {
+		t.Fatal(err)
+	}
+	if err := h1.Handle(response, send1); !errors.Is(err, ErrAuth) {
+		t.Errorf("expected the response of session 2 to fail in session 1, got %v", err)
+	}
+}
+
+func TestAuthenticatedExchange(t *testing.T) {
+	auth := `,"Auth":{"Mode":"hmac","HMACKey":"secret"}`
+	offerer := newTestManager(t, auth)
+	answerer := newTestManager(t, auth)
+	impostor := newTestManager(t, `,"Auth":{"Mode":"hmac","HMACKey":"guess"}`)
+
+	if err := offerer.CreateSession(1); err != nil {
+		t.Fatal(err)
+	}
+	offer := waitSDP(t, func() (string, error) { return offerer.Offer(1) })
+	if err := answerer.JoinSession(2, offer); err != nil {
+		t.Fatal(err)
+	}
+	answer := waitSDP(t, func() (string, error) { return answerer.Answer(2) })
+	if err := offerer.ConfirmAnswer(1, answer); err != nil {
+		t.Fatal(err)
+	}
+	waitDelivery(t, offerer, 1, answerer, 2)
+	waitDelivery(t, answerer, 2, offerer, 1)
+
+	if err := offerer.CreateSession(3); err != nil {
+		t.Fatal(err)
+	}
+	offer = waitSDP(t, func() (string, error) { return offerer.Offer(3) })
+	if err := impostor.JoinSession(4, offer); err != nil {
+		t.Fatal(err)
+	}
+	answer = waitSDP(t, func() (string, error) { return impostor.Answer(4) })
+	if err := offerer.ConfirmAnswer(3, answer); err != nil {
+		t.Fatal(err)
+	}
+	deadline := time.Now().Add(10 * time.Second)
+	for {
+		offerer.mu.Lock()
+		_, alive := offerer.sessionBook[3]
+		offerer.mu.Unlock()
+		if !alive {
+			break
+		}
+		// the session may be dropped right after the check
+		if err := offerer.Send(3, []byte("secret")); !errors.Is(err, ErrWait) && !errors.Is(err, ErrLost) {
+			t.Fatalf("expected ErrWait before authentication, got %v", err)
+		}
+		if time.Now().After(deadline) {
+			t.Fatal("session with impostor was not dropped")
+		}
+		time.Sleep(50 * time.Millisecond)
+	}
+}
+
+func TestHandshakeTimeout(t *testing.T) {
+	offerer := newTestManager(t, `,"Auth":{"Mode":"token","Token":"t","Timeout":1}`)
+	// without Auth the answerer never responds to the challenge
+	answerer := newTestManager(t, "")
+	if err := offerer.CreateSession(1); err != nil {
+		t.Fatal(err)
+	}
+	offer := waitSDP(t, func() (string, error) { return offerer.Offer(1) })
+	if err := answerer.JoinSession(2, offer); err != nil {
+		t.Fatal(err)
+	}
+	answer := waitSDP(t, func() (string, error) { return answerer.Answer(2) })
+	if err := offerer.ConfirmAnswer(1, answer); err != nil {
+		t.Fatal(err)
+	}
+	deadline := time.Now().Add(10 * time.Second)
+	for {
+		if _, err := offerer.Inspect(1); errors.Is(err, ErrLost) {
+			break
+		}
+		if time.Now().After(deadline) {
+			t.Fatal("session with a silent peer was not dropped")
+		}
+		time.Sleep(50 * time.Millisecond)
+	}
+}
@@ -0,0 +1,162 @@
+// Package main build the manager as a C shared library:
+//
+//	go build -buildmode=c-shared -o libsessionmgr.so sessionmgr/clib
+//
+// Every function but SM_Free return a serialized return.proto Return and write its length to outLen,
+// the buffer must be released with SM_Free. A failed call has Return.err set.
+package main
+
+/*
+#include <stdint.h>
+#include <stdlib.h>
+*/
+import "C"
+
+import (
+	"google.golang.org/protobuf/proto"
+	"sessionmgr"
+	pb "sessionmgr/proto/pkg/return_pb"
+	"unsafe"
+)
+
+// SM_New create a manager from the config at confPath and write its handle, 0 on failure
+//
+//export SM_New
+func SM_New(confPath *C.char, handle *C.int64_t, outLen *C.size_t) unsafe.Pointer {
+	*handle = 0
+	mgr, err := sessionmgr.NewSessionManagerImpl(C.GoString(confPath))
+	if err != nil {
+		return output(&pb.Return{Err: sessionmgr.ToProto(err)}, outLen)
+	}
+	*handle = C.int64_t(newHandle(mgr))
+	return output(&pb.Return{}, outLen)
+}
+
+//export SM_CreateSession
+func SM_CreateSession(handle C.int64_t, SessionID C.int32_t, outLen *C.size_t) unsafe.Pointer {
+	ret := &pb.Return{CreateSessionReturn: &pb.ReturnCreateSession{}}
+	call(ret, "CreateSession", handle, func(mgr *sessionmgr.SessionManagerImpl) error {
+		return mgr.CreateSession(int32(SessionID))
+	})
+	return output(ret, outLen)
+}
+
+//export SM_Offer
+func SM_Offer(handle C.int64_t, SessionID C.int32_t, outLen *C.size_t) unsafe.Pointer {
+	ret := &pb.Return{OfferReturn: &pb.ReturnOffer{}}
+	call(ret, "Offer", handle, func(mgr *sessionmgr.SessionManagerImpl) (err error) {
+		ret.OfferReturn.OfferBase64, err = mgr.Offer(int32(SessionID))
+		return err
+	})
+	return output(ret, outLen)
+}
+
+//export SM_JoinSession
+func SM_JoinSession(handle C.int64_t, SessionID C.int32_t, sdpBase64 *C.char, outLen *C.size_t) unsafe.Pointer {
+	ret := &pb.Return{JoinSessionReturn: &pb.ReturnJoinSession{}}
+	call(ret, "JoinSession", handle, func(mgr *sessionmgr.SessionManagerImpl) error {
+		return mgr.JoinSession(int32(SessionID), C.GoString(sdpBase64))
+	})
+	return output(ret, outLen)
+}
+
+//export SM_Answer
+func SM_Answer(handle C.int64_t, SessionID C.int32_t, outLen *C.size_t) unsafe.Pointer {
+	ret := &pb.Return{AnswerReturn: &pb.ReturnAnswer{}}
+	call(ret, "Answer", handle, func(mgr *sessionmgr.SessionManagerImpl) (err error) {
+		ret.AnswerReturn.AnswerBase64, err = mgr.Answer(int32(SessionID))
+		return err
+	})
+	return output(ret, outLen)
+}
+
+//export SM_ConfirmAnswer
+func SM_ConfirmAnswer(handle C.int64_t, SessionID C.int32_t, sdpBase64 *C.char, outLen *C.size_t) unsafe.Pointer {
+	ret := &pb.Return{ConfirmAnswerReturn: &pb.ReturnConfirmAnswer{}}
+	call(ret, "ConfirmAnswer", handle, func(mgr *sessionmgr.SessionManagerImpl) error {
+		return mgr.ConfirmAnswer(int32(SessionID), C.GoString(sdpBase64))
+	})
+	return output(ret, outLen)
+}
+
+//export SM_Send
+func SM_Send(handle C.int64_t, SessionID C.int32_t, dAtA unsafe.Pointer, dataLen C.size_t, outLen *C.size_t) unsafe.Pointer {
+	ret := &pb.Return{SendReturn: &pb.ReturnSend{}}
+	call(ret, "Send", handle, func(mgr *sessionmgr.SessionManagerImpl) error {
+		return mgr.Send(int32(SessionID), C.GoBytes(dAtA, C.int(dataLen)))
+	})
+	return output(ret, outLen)
+}
+
+//export SM_Ready
+func SM_Ready(handle C.int64_t, outLen *C.size_t) unsafe.Pointer {
+	ret := &pb.Return{ReadyReturn: &pb.ReturnReady{}}
+	call(ret, "Ready", handle, func(mgr *sessionmgr.SessionManagerImpl) (err error) {
+		ret.ReadyReturn.ReadyList, err = mgr.Ready()
+		return err
+	})
+	return output(ret, outLen)
+}
+
+//export SM_DropSession
+func SM_DropSession(handle C.int64_t, SessionID C.int32_t, outLen *C.size_t) unsafe.Pointer {
+	ret := &pb.Return{DropSessionReturn: &pb.ReturnDropSession{}}
+	call(ret, "DropSession", handle, func(mgr *sessionmgr.SessionManagerImpl) error {
+		return mgr.DropSession(int32(SessionID))
+	})
+	return output(ret, outLen)
+}
+
+//export SM_ReloadConfig
+func SM_ReloadConfig(handle C.int64_t, confPath *C.char, outLen *C.size_t) unsafe.Pointer {
+	ret := &pb.Return{ReloadConfigReturn: &pb.ReturnReloadConfig{}}
+	call(ret, "ReloadConfig", handle, func(mgr *sessionmgr.SessionManagerImpl) error {
+		return mgr.ReloadConfig(C.GoString(confPath))
+	})
+	return output(ret, outLen)
+}
+
+// SM_Discard discard the manager and release its handle
+//
+//export SM_Discard
+func SM_Discard(handle C.int64_t, outLen *C.size_t) unsafe.Pointer {
+	ret := &pb.Return{DiscardReturn: &pb.ReturnDiscard{}}
+	call(ret, "Discard", handle, func(mgr *sessionmgr.SessionManagerImpl) error {
+		deleteHandle(int64(handle))
+		return mgr.Discard()
+	})
+	return output(ret, outLen)
+}
+
+// SM_Free release a buffer returned by any other function
+//
+//export SM_Free
+func SM_Free(buffer unsafe.Pointer) {
+	C.free(buffer)
+}
+
+// call run fn on the manager of handle, a failure replaces the result of ret with Return.err
+func call(ret *pb.Return, op string, handle C.int64_t, fn func(mgr *sessionmgr.SessionManagerImpl) error) {
+	mgr, err := lookup(op, int64(handle))
+	if err == nil {
+		err = fn(mgr)
+	}
+	if err != nil {
+		proto.Reset(ret)
+		ret.Err = sessionmgr.ToProto(err)
+	}
+}
+
+// output copy ret to C memory, it is never NULL so that an empty Return can be told from a failure
+func output(ret *pb.Return, outLen *C.size_t) unsafe.Pointer {
+	data, err := proto.Marshal(ret)
+	if err != nil {
+		data, _ = proto.Marshal(&pb.Return{Err: sessionmgr.ToProto(err)})
+	}
+	*outLen = C.size_t(len(data))
+	buffer := C.malloc(C.size_t(len(data) + 1))
+	copy(unsafe.Slice((*byte)(buffer), len(data)+1), data)
+	return buffer
+}
+
+func main() {}
@@ -0,0 +1,32 @@
+package main
+
+import (
+	"os/exec"
+	"path/filepath"
+	"sessionmgr/internal/testconf"
+	"strings"
+	"testing"
+)
+
+// TestHarness build the shared library and run the C harness against it
+func TestHarness(t *testing.T) {
+	if _, err := exec.LookPath("gcc"); err != nil {
+		t.Skip("gcc not found")
+	}
+	dir := t.TempDir()
+	build := exec.Command("go", "build", "-buildmode=c-shared", "-o", filepath.Join(dir, "libsessionmgr.so"), ".")
+	if out, err := build.CombinedOutput(); err != nil {
+		t.Fatalf("build library: %v\n%s", err, out)
+	}
+	harness := filepath.Join(dir, "harness")
+	gcc := exec.Command("gcc", "-o", harness, filepath.Join("harness", "harness.c"),
+		"-I"+dir, "-L"+dir, "-lsessionmgr", "-Wl,-rpath,"+dir)
+	if out, err := gcc.CombinedOutput(); err != nil {
+		t.Fatalf("build harness: %v\n%s", err, out)
+	}
+
+	out, err := exec.Command(harness, testconf.Write(t, "")).CombinedOutput()
+	if err != nil || !strings.Contains(string(out), "ok") {
+		t.Fatalf("harness: %v\n%s", err, out)
+	}
+}
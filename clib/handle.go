@@ -0,0 +1,41 @@
+package main
+
+import (
+	"errors"
+	"sessionmgr"
+	"sync"
+)
+
+var errHandle = errors.New("invalid manager handle")
+
+// handles keep the managers alive while C holds their handle, 0 is never a valid handle
+var handles = struct {
+	mu   sync.Mutex
+	next int64
+	book map[int64]*sessionmgr.SessionManagerImpl
+}{book: make(map[int64]*sessionmgr.SessionManagerImpl)}
+
+func newHandle(mgr *sessionmgr.SessionManagerImpl) int64 {
+	handles.mu.Lock()
+	defer handles.mu.Unlock()
+	handles.next++
+	handles.book[handles.next] = mgr
+	return handles.next
+}
+
+// lookup return the manager of handle, the error matches sessionmgr.ErrCall
+func lookup(op string, handle int64) (*sessionmgr.SessionManagerImpl, error) {
+	handles.mu.Lock()
+	defer handles.mu.Unlock()
+	mgr := handles.book[handle]
+	if mgr == nil {
+		return nil, &sessionmgr.Error{Op: op, Kind: sessionmgr.ErrCall, Err: errHandle}
+	}
+	return mgr, nil
+}
+
+func deleteHandle(handle int64) {
+	handles.mu.Lock()
+	defer handles.mu.Unlock()
+	delete(handles.book, handle)
+}
@@ -0,0 +1,267 @@
+// harness drive two managers of libsessionmgr against each other in one process:
+// create, offer, join, answer, confirm, send and ready. It prints "ok" and exits 0 on success.
+//
+//	gcc -o harness harness.c -I<dir of libsessionmgr.h> -L<dir> -lsessionmgr
+//	./harness conf.json
+#include <stdint.h>
+#include <stdio.h>
+#include <stdlib.h>
+#include <string.h>
+#include <unistd.h>
+#include "libsessionmgr.h"
+
+// field numbers of return.proto Return
+enum {
+	RETURN_ERR = 1,
+	RETURN_OFFER = 3,
+	RETURN_ANSWER = 5,
+	RETURN_READY = 8,
+};
+
+// field numbers of error.proto Error
+enum {
+	ERROR_MESSAGE = 1,
+	ERROR_WAIT = 5,
+};
+
+// span is a slice of a serialized message
+typedef struct {
+	const uint8_t *data;
+	size_t len;
+} span;
+
+static int varint(span *s, uint64_t *v) {
+	*v = 0;
+	for (int shift = 0; shift < 64 && s->len > 0; shift += 7) {
+		uint8_t b = *s->data++;
+		s->len--;
+		*v |= (uint64_t)(b & 0x7f) << shift;
+		if (!(b & 0x80)) {
+			return 0;
+		}
+	}
+	return -1;
+}
+
+// next read one field, varints are returned in value and length-delimited fields in body
+static int next(span *s, int *field, uint64_t *value, span *body) {
+	uint64_t key;
+	if (varint(s, &key) != 0) {
+		return -1;
+	}
+	*field = (int)(key >> 3);
+	switch (key & 7) {
+	case 0:
+		return varint(s, value);
+	case 2:
+		if (varint(s, value) != 0 || *value > s->len) {
+			return -1;
+		}
+		body->data = s->data;
+		body->len = (size_t)*value;
+		s->data += *value;
+		s->len -= *value;
+		return 0;
+	default:
+		return -1;
+	}
+}
+
+// find the first length-delimited field number in msg
+static int find(span msg, int number, span *out) {
+	int field;
+	uint64_t value;
+	span body;
+	while (msg.len > 0) {
+		if (next(&msg, &field, &value, &body) != 0) {
+			return -1;
+		}
+		if (field == number) {
+			*out = body;
+			return 0;
+		}
+	}
+	return -1;
+}
+
+// result hold a Return, its buffer is released by done
+// wrap reads len once the call filled it, argument evaluation order is unspecified
+typedef struct {
+	void *buffer;
+	span msg;
+	int failed;
+	int wait;
+	char error[256];
+} result;
+
+static result wrap(void *buffer, size_t *len) {
+	result r = {buffer, {buffer, *len}, 0, 0, ""};
+	span err, message, wait;
+	if (find(r.msg, RETURN_ERR, &err) == 0) {
+		r.failed = 1;
+		r.wait = find(err, ERROR_WAIT, &wait) == 0;
+		if (find(err, ERROR_MESSAGE, &message) == 0) {
+			size_t n = message.len < sizeof(r.error) - 1 ? message.len : sizeof(r.error) - 1;
+			memcpy(r.error, message.data, n);
+			r.error[n] = 0;
+		}
+	}
+	return r;
+}
+
+static void done(result *r) {
+	SM_Free(r->buffer);
+}
+
+static void check(result *r, const char *op) {
+	if (r->failed) {
+		fprintf(stderr, "%s: %s\n", op, r->error);
+		exit(1);
+	}
+}
+
+// text copy field 1 of sub-message number, as offer_base64 and answer_base64 are
+static char *text(result *r, int number) {
+	span sub, str;
+	if (find(r->msg, number, &sub) != 0 || find(sub, 1, &str) != 0) {
+		return NULL;
+	}
+	char *s = malloc(str.len + 1);
+	memcpy(s, str.data, str.len);
+	s[str.len] = 0;
+	return s;
+}
+
+// again release r and sleep when it failed with ErrWait, for about 10 seconds of attempts
+static int again(result *r, int attempt) {
+	if (!r->wait || attempt == 200) {
+		return 0;
+	}
+	done(r);
+	usleep(50 * 1000);
+	return 1;
+}
+
+// received tell whether a Return of SM_Ready holds msg from SessionID
+static int received(result *r, int32_t SessionID, const char *msg) {
+	span list, ready = r->msg, body;
+	int field;
+	uint64_t value;
+	if (find(ready, RETURN_READY, &list) != 0) {
+		return 0;
+	}
+	while (list.len > 0) {
+		if (next(&list, &field, &value, &body) != 0) {
+			return 0;
+		}
+		int64_t id = -1;
+		span data = {NULL, 0}, inner = body;
+		while (inner.len > 0) {
+			if (next(&inner, &field, &value, &body) != 0) {
+				return 0;
+			}
+			if (field == 1) {
+				id = (int32_t)value;
+			} else if (field == 2) {
+				data = body;
+			}
+		}
+		if (id == SessionID && data.len == strlen(msg) && memcmp(data.data, msg, data.len) == 0) {
+			return 1;
+		}
+	}
+	return 0;
+}
+
+int main(int argc, char **argv) {
+	if (argc < 2) {
+		fprintf(stderr, "usage: %s conf.json\n", argv[0]);
+		return 2;
+	}
+	int64_t offerer, answerer;
+	size_t len;
+	result r;
+
+	r = wrap(SM_New(argv[1], &offerer, &len), &len);
+	check(&r, "new offerer");
+	done(&r);
+	r = wrap(SM_New(argv[1], &answerer, &len), &len);
+	check(&r, "new answerer");
+	done(&r);
+
+	r = wrap(SM_CreateSession(offerer, 1, &len), &len);
+	check(&r, "create session");
+	done(&r);
+
+	for (int i = 0; r = wrap(SM_Offer(offerer, 1, &len), &len), again(&r, i); i++) {
+	}
+	check(&r, "offer");
+	char *offer = text(&r, RETURN_OFFER);
+	done(&r);
+	if (offer == NULL) {
+		fprintf(stderr, "offer: no offer_return\n");
+		return 1;
+	}
+
+	r = wrap(SM_JoinSession(answerer, 2, offer, &len), &len);
+	check(&r, "join session");
+	done(&r);
+	free(offer);
+
+	for (int i = 0; r = wrap(SM_Answer(answerer, 2, &len), &len), again(&r, i); i++) {
+	}
+	check(&r, "answer");
+	char *answer = text(&r, RETURN_ANSWER);
+	done(&r);
+	if (answer == NULL) {
+		fprintf(stderr, "answer: no answer_return\n");
+		return 1;
+	}
+
+	r = wrap(SM_ConfirmAnswer(offerer, 1, answer, &len), &len);
+	check(&r, "confirm answer");
+	done(&r);
+	free(answer);
+
+	const char *hello = "hello from C";
+	for (int i = 0; r = wrap(SM_Send(offerer, 1, (void *)hello, strlen(hello), &len), &len), again(&r, i); i++) {
+	}
+	check(&r, "send");
+	done(&r);
+
+	int ok = 0;
+	for (int i = 0; i < 200 && !ok; i++) {
+		r = wrap(SM_Ready(answerer, &len), &len);
+		check(&r, "ready");
+		ok = received(&r, 2, hello);
+		done(&r);
+		if (!ok) {
+			usleep(50 * 1000);
+		}
+	}
+	if (!ok) {
+		fprintf(stderr, "ready: message not delivered\n");
+		return 1;
+	}
+
+	r = wrap(SM_DropSession(offerer, 1, &len), &len);
+	check(&r, "drop session");
+	done(&r);
+	r = wrap(SM_Discard(offerer, &len), &len);
+	check(&r, "discard offerer");
+	done(&r);
+	r = wrap(SM_Discard(answerer, &len), &len);
+	check(&r, "discard answerer");
+	done(&r);
+
+	// a released handle is rejected
+	r = wrap(SM_Ready(offerer, &len), &len);
+	if (!r.failed) {
+		fprintf(stderr, "ready: discarded handle accepted\n");
+		return 1;
+	}
+	done(&r);
+
+	printf("ok\n");
+	return 0;
+}
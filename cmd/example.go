@@ -9,14 +9,15 @@ import (
 	"sessionmgr"
 	"sessionmgr/dbg"
 	pb "sessionmgr/proto/pkg/ready_pb"
+	"sessionmgr/util"
 	"time"
 )
 
 func main() {
 	args := os.Args[1:]
 	if len(args) < 1 {
-		fmt.Println("Usage: ./webrtcdemo sender")
-		fmt.Println("Usage: ./webrtcdemo receiver")
+		fmt.Println("Usage: ./webrtcdemo sender [-qr]")
+		fmt.Println("Usage: ./webrtcdemo receiver [-qr]")
 		PressAnyKey()
 		return
 	}
@@ -26,24 +27,25 @@ func main() {
 	}
 	defer dbg.Close()
 	cmd := args[0]
+	// -qr prints the compact offer/answer as a QR code as well
+	qr := len(args) > 1 && args[1] == "-qr"
 	switch cmd {
 	case "sender":
-		startSender()
+		startSender(qr)
 	case "receiver":
-		startReceiver()
+		startReceiver(qr)
 	default:
-		fmt.Println("Usage: ./webrtcdemo sender")
-		fmt.Println("Usage: ./webrtcdemo receiver")
+		fmt.Println("Usage: ./webrtcdemo sender [-qr]")
+		fmt.Println("Usage: ./webrtcdemo receiver [-qr]")
 	}
 }
 
-func startSender() {
-	var sender sessionmgr.SessionManager
-	var err error
-	sender, err = sessionmgr.NewSessionManagerImpl("conf.json")
+func startSender(qr bool) {
+	impl, err := sessionmgr.NewSessionManagerImpl("conf.json")
 	if err != nil {
 		dbg.Fatal(dbg.ELSE, err)
 	}
+	var sender sessionmgr.SessionManager = impl
 
 	// 1. create a session
 	sessionID := rand.Int31()
@@ -57,17 +59,26 @@ func startSender() {
 	}
 
 	// 2. acquire offer
-	offerSDP, err := sender.Offer(sessionID)
+	offer := sender.Offer
+	if qr {
+		offer = func(SessionID int32) (string, error) { return impl.OfferAs(SessionID, util.FormatCompact) }
+	}
+	offerSDP, err := offer(sessionID)
 	for err != nil {
 		if !errors.Is(err, sessionmgr.ErrWait) {
 			dbg.Fatal(dbg.ELSE, err)
 		}
-		offerSDP, err = sender.Offer(sessionID)
+		offerSDP, err = offer(sessionID)
 	}
 
 	// 3. print offer
 	_ = offerSDP
 	fmt.Println("offer:", offerSDP)
+	if qr {
+		if err = PrintQR(offerSDP); err != nil {
+			dbg.Fatal(dbg.ELSE, err)
+		}
+	}
 
 	// 4. read answer
 	fmt.Println("input answer:")
@@ -94,13 +105,12 @@ func startSender() {
 	}
 }
 
-func startReceiver() {
-	var receiver sessionmgr.SessionManager
-	var err error
-	receiver, err = sessionmgr.NewSessionManagerImpl("conf.json")
+func startReceiver(qr bool) {
+	impl, err := sessionmgr.NewSessionManagerImpl("conf.json")
 	if err != nil {
 		dbg.Fatal(dbg.ELSE, err)
 	}
+	var receiver sessionmgr.SessionManager = impl
 
 	// 1. join session
 	fmt.Println("input offerSDP:")
@@ -118,15 +128,24 @@ func startReceiver() {
 	}
 
 	// 2. get answer
-	answerSDP, err := receiver.Answer(sessionID)
+	answer := receiver.Answer
+	if qr {
+		answer = func(SessionID int32) (string, error) { return impl.AnswerAs(SessionID, util.FormatCompact) }
+	}
+	answerSDP, err := answer(sessionID)
 	for err != nil {
 		if !errors.Is(err, sessionmgr.ErrWait) {
 			dbg.Fatal(dbg.ELSE, err)
 		}
-		answerSDP, err = receiver.Answer(sessionID)
+		answerSDP, err = answer(sessionID)
 	}
 	_ = answerSDP
 	fmt.Println("answer:", answerSDP)
+	if qr {
+		if err = PrintQR(answerSDP); err != nil {
+			dbg.Fatal(dbg.ELSE, err)
+		}
+	}
 
 	// 3. receive data
 	for {
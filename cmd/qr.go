@@ -0,0 +1,42 @@
+package main
+
+import (
+	"fmt"
+	"rsc.io/qr"
+	"strings"
+)
+
+// PrintQR draw text as a QR code on the terminal, two modules per character cell
+func PrintQR(text string) error {
+	code, err := qr.Encode(text, qr.L)
+	if err != nil {
+		return err
+	}
+	// a quiet zone of 2 modules keeps phone scanners happy
+	const quiet = 2
+	black := func(x, y int) bool {
+		x, y = x-quiet, y-quiet
+		return x >= 0 && y >= 0 && x < code.Size && y < code.Size && code.Black(x, y)
+	}
+	size := code.Size + 2*quiet
+	var sb strings.Builder
+	for y := 0; y < size; y += 2 {
+		for x := 0; x < size; x++ {
+			// light on dark terminals: draw white where the code is white
+			top, bottom := !black(x, y), !black(x, y+1)
+			switch {
+			case top && bottom:
+				sb.WriteString("█")
+			case top:
+				sb.WriteString("▀")
+			case bottom:
+				sb.WriteString("▄")
+			default:
+				sb.WriteString(" ")
+			}
+		}
+		sb.WriteString("\n")
+	}
+	fmt.Print(sb.String())
+	return nil
+}
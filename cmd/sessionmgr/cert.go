@@ -0,0 +1,20 @@
+package main
+
+import (
+	"fmt"
+	"github.com/pion/webrtc/v4"
+	"sessionmgr/util"
+)
+
+// printFingerprint print the fingerprint in the form PinnedFingerprints expects
+func printFingerprint(certificate *webrtc.Certificate, err error) error {
+	if err != nil {
+		return err
+	}
+	fingerprint, err := util.CertificateFingerprint(certificate)
+	if err != nil {
+		return err
+	}
+	fmt.Println(fingerprint)
+	return nil
+}
@@ -0,0 +1,39 @@
+package main
+
+import (
+	"fmt"
+	"os"
+	"sessionmgr/util"
+)
+
+const usage = `Usage: ./sessionmgr gencert <path>              generate a persistent DTLS certificate and print its fingerprint
+Usage: ./sessionmgr fingerprint <path>           print the fingerprint of a certificate to pin it on the peer
+Usage: ./sessionmgr serve --stdio [conf.json]    speak length-prefixed request.proto/return.proto over stdin/stdout
+Usage: ./sessionmgr replay [-speed 1] [-direction out] [-record] [-turn] -loopback [-peer-conf <file>]|-signal <dir> <recording>
+                                                 send the messages of a recording again with their original timing`
+
+func main() {
+	args := os.Args[1:]
+	if len(args) < 2 {
+		fmt.Fprintln(os.Stderr, usage)
+		os.Exit(2)
+	}
+	var err error
+	switch args[0] {
+	case "gencert":
+		err = printFingerprint(util.GenerateCertificate(args[1]))
+	case "fingerprint":
+		err = printFingerprint(util.LoadCertificate(args[1]))
+	case "serve":
+		err = serveStdio(args[1:])
+	case "replay":
+		err = replay(args[1:])
+	default:
+		fmt.Fprintln(os.Stderr, usage)
+		os.Exit(2)
+	}
+	if err != nil {
+		fmt.Fprintln(os.Stderr, err)
+		os.Exit(1)
+	}
+}
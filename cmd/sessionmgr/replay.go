@@ -0,0 +1,196 @@
+package main
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"flag"
+	"fmt"
+	"os"
+	"os/signal"
+	"path/filepath"
+	"sessionmgr"
+	"sessionmgr/record"
+	"sessionmgr/signaling"
+	"strings"
+	"sync/atomic"
+	"syscall"
+	"time"
+)
+
+// replay send the messages of a recording again through a loopback or a live session,
+// every message goes out on the data channel of the session whatever channel it was recorded on
+func replay(args []string) error {
+	flags := flag.NewFlagSet("replay", flag.ContinueOnError)
+	confPath := flags.String("conf", "conf.json", "manager configuration")
+	speed := flags.Float64("speed", 1, "divide the recorded gaps, 2 replays twice as fast, 0 sends without waiting")
+	direction := flags.String("direction", "out", "messages to send again, out (sent by the recording side) or in (received)")
+	loopback := flags.Bool("loopback", false, "connect to a second manager in this process")
+	peerConfPath := flags.String("peer-conf", "", "configuration of the -loopback manager, -conf without TurnServer and Record by default")
+	recording := flags.Bool("record", false, "keep the Record of -conf, the replay is recorded again")
+	turn := flags.Bool("turn", false, "keep the TurnServer of -conf")
+	signalDir := flags.String("signal", "", "directory the descriptions are exchanged in with a live peer")
+	name := flags.String("name", "replay", "name of the description files in -signal")
+	role := flags.String("role", "offerer", "side of the live exchange, offerer or answerer")
+	if err := flags.Parse(args); err != nil {
+		return err
+	}
+	if flags.NArg() != 1 {
+		return errors.New("replay takes one recording")
+	}
+	if !*loopback && *signalDir == "" {
+		return errors.New("replay needs -loopback or -signal")
+	}
+	opts := record.ReplayOptions{Speed: *speed}
+	var err error
+	if opts.Direction, err = record.ParseDirection(*direction); err != nil {
+		return err
+	}
+	file, err := os.Open(flags.Arg(0))
+	if err != nil {
+		return err
+	}
+	defer file.Close()
+	reader, err := record.NewReader(file)
+	if err != nil {
+		return err
+	}
+
+	ctx, stop := signal.NotifyContext(context.Background(), os.Interrupt, syscall.SIGTERM)
+	defer stop()
+	dir, err := os.MkdirTemp("", "sessionmgr-replay")
+	if err != nil {
+		return err
+	}
+	defer os.RemoveAll(dir)
+	var stripped []string
+	if !*turn {
+		stripped = append(stripped, "TurnServer")
+	}
+	if !*recording {
+		stripped = append(stripped, "Record")
+	}
+	path, err := stripConf(*confPath, filepath.Join(dir, "replay.json"), stripped...)
+	if err != nil {
+		return err
+	}
+	mgr, err := sessionmgr.NewSessionManagerImpl(path)
+	if err != nil {
+		return err
+	}
+	defer mgr.Discard()
+	SessionID := reader.SessionID
+	var received atomic.Int64
+	var peer *sessionmgr.SessionManagerImpl
+	switch {
+	case *loopback:
+		path := *peerConfPath
+		if path == "" {
+			if path, err = stripConf(*confPath, filepath.Join(dir, "peer.json"), "TurnServer", "Record"); err != nil {
+				return err
+			}
+		}
+		if peer, err = sessionmgr.NewSessionManagerImpl(path); err != nil {
+			return err
+		}
+		defer peer.Discard()
+		signaler := signaling.NewMemorySignaler()
+		answered := make(chan error, 1)
+		go func() { answered <- signaling.Connect(ctx, peer, signaler, signaling.Answerer, SessionID) }()
+		if err = signaling.Connect(ctx, mgr, signaler, signaling.Offerer, SessionID); err != nil {
+			return err
+		}
+		if err = <-answered; err != nil {
+			return err
+		}
+		go printReady(ctx, peer, &received)
+	default:
+		signalRole := signaling.Offerer
+		if *role == "answerer" {
+			signalRole = signaling.Answerer
+		} else if *role != "offerer" {
+			return fmt.Errorf("unknown role %q, expected offerer or answerer", *role)
+		}
+		if err = signaling.Connect(ctx, mgr, signaling.NewFileSignaler(*signalDir, *name), signalRole, SessionID); err != nil {
+			return err
+		}
+		go printReady(ctx, mgr, &received)
+	}
+
+	var sent int64
+	err = record.Replay(ctx, reader, opts, func(m record.Message) error {
+		if err := sendWhenOpen(ctx, mgr, SessionID, m.Payload); err != nil {
+			return err
+		}
+		sent++
+		return nil
+	})
+	fmt.Fprintf(os.Stderr, "replayed %d messages of session %d\n", sent, SessionID)
+	if err != nil {
+		return err
+	}
+	// the last messages may still be on the way to the loopback peer
+	deadline := time.Now().Add(5 * time.Second)
+	for peer != nil && received.Load() < sent && time.Now().Before(deadline) {
+		time.Sleep(50 * time.Millisecond)
+	}
+	return nil
+}
+
+// stripConf write to path the configuration at confPath without the sections keys, confPath itself without keys.
+// A replay would otherwise start the TurnServer of the recorded setup and record itself next to the recording,
+// the second manager of a loopback would listen on the same address.
+func stripConf(confPath, path string, keys ...string) (string, error) {
+	if len(keys) == 0 {
+		return confPath, nil
+	}
+	data, err := os.ReadFile(confPath)
+	if err != nil {
+		return "", err
+	}
+	var fields map[string]json.RawMessage
+	if err = json.Unmarshal(data, &fields); err != nil {
+		return "", fmt.Errorf("parse %v: %w", confPath, err)
+	}
+	// encoding/json matches the keys without case
+	for field := range fields {
+		for _, key := range keys {
+			if strings.EqualFold(field, key) {
+				delete(fields, field)
+			}
+		}
+	}
+	if data, err = json.Marshal(fields); err != nil {
+		return "", err
+	}
+	return path, os.WriteFile(path, data, 0600)
+}
+
+// sendWhenOpen retry Send while the channel opens or the handshake runs
+func sendWhenOpen(ctx context.Context, mgr sessionmgr.SessionManager, SessionID int32, data []byte) error {
+	for {
+		err := mgr.Send(SessionID, data)
+		if !errors.Is(err, sessionmgr.ErrWait) {
+			return err
+		}
+		select {
+		case <-ctx.Done():
+			return ctx.Err()
+		case <-time.After(50 * time.Millisecond):
+		}
+	}
+}
+
+// printReady print the size of every message the replay gets back and count them
+func printReady(ctx context.Context, mgr *sessionmgr.SessionManagerImpl, received *atomic.Int64) {
+	for {
+		rlist, err := mgr.WaitReady(ctx)
+		if err != nil {
+			return
+		}
+		for _, ready := range rlist {
+			received.Add(1)
+			fmt.Printf("session %d received %d bytes\n", ready.SessionID, len(ready.DAtA))
+		}
+	}
+}
@@ -0,0 +1,34 @@
+package main
+
+import (
+	"context"
+	"fmt"
+	"os"
+	"sessionmgr"
+	"sessionmgr/conf"
+	"sessionmgr/serve"
+)
+
+// serveStdio run the manager for a host process, stdout carries frames only so a Log.Output of stdout is refused,
+// logs go to stderr or a file
+func serveStdio(args []string) error {
+	if args[0] != "--stdio" {
+		return fmt.Errorf("unknown transport %q, only --stdio is supported", args[0])
+	}
+	confPath := "conf.json"
+	if len(args) > 1 {
+		confPath = args[1]
+	}
+	config, err := conf.LoadConfig(confPath)
+	if err != nil {
+		return err
+	}
+	if config.Log != nil && config.Log.Output == "stdout" {
+		return fmt.Errorf("%v: Log.Output stdout would mix log lines into the frames, use stderr or a file", confPath)
+	}
+	mgr, err := sessionmgr.NewSessionManagerImpl(confPath)
+	if err != nil {
+		return err
+	}
+	return serve.Serve(context.Background(), mgr, os.Stdin, os.Stdout)
+}
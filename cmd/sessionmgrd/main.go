@@ -0,0 +1,63 @@
+package main
+
+import (
+	"context"
+	"flag"
+	"fmt"
+	"net/http"
+	"os"
+	"os/signal"
+	"sessionmgr"
+	"sessionmgr/admin"
+	"sessionmgr/daemon"
+	"syscall"
+)
+
+func main() {
+	socket := flag.String("socket", "/tmp/sessionmgrd.sock", "unix socket the clients connect to")
+	confPath := flag.String("conf", "conf.json", "manager configuration")
+	metricsAddr := flag.String("metrics", "", "address serving /metrics, e.g. 127.0.0.1:9090, empty disables it")
+	adminAddr := flag.String("admin", "", "loopback address of the admin API, e.g. 127.0.0.1:8081, empty disables it")
+	flag.Parse()
+	if err := run(*socket, *confPath, *metricsAddr, *adminAddr); err != nil {
+		fmt.Fprintln(os.Stderr, err)
+		os.Exit(1)
+	}
+}
+
+func run(socket string, confPath string, metricsAddr string, adminAddr string) error {
+	mgr, err := sessionmgr.NewSessionManagerImpl(confPath)
+	if err != nil {
+		return err
+	}
+	defer mgr.Discard()
+	if metricsAddr != "" {
+		mux := http.NewServeMux()
+		mux.Handle("/metrics", mgr.MetricsHandler())
+		server := &http.Server{Addr: metricsAddr, Handler: mux}
+		go func() {
+			if err := server.ListenAndServe(); err != nil && err != http.ErrServerClosed {
+				fmt.Fprintln(os.Stderr, "metrics:", err)
+			}
+		}()
+		defer server.Close()
+	}
+	if adminAddr != "" {
+		l, err := admin.Listen(adminAddr)
+		if err != nil {
+			return err
+		}
+		server := &http.Server{Handler: admin.New(mgr, confPath)}
+		go func() { _ = server.Serve(l) }()
+		defer server.Close()
+	}
+	l, err := daemon.Listen(socket)
+	if err != nil {
+		return err
+	}
+	defer os.Remove(socket)
+
+	ctx, stop := signal.NotifyContext(context.Background(), os.Interrupt, syscall.SIGTERM)
+	defer stop()
+	return daemon.New(mgr).Serve(ctx, l)
+}
@@ -0,0 +1,165 @@
+package sessionmgr
+
+import (
+	"encoding/base64"
+	"sessionmgr/conf"
+	"sessionmgr/logs"
+	"sessionmgr/util"
+	"strings"
+	"time"
+)
+
+const defaultEnvelopeTTL = 10 * time.Minute
+
+// chunkOverhead bounds chunked input before it is joined, relative to MaxEncodedSize
+const chunkOverhead = 4
+
+// encodeOutput wrap a local description as configured, caller must hold mu
+//
+//	format -> envelope -> encryption
+func (s *SessionManagerImpl) encodeOutput(sdp string) (string, error) {
+	var err error
+	if s.envelope != nil {
+		if sdp, err = util.SealEnvelope(sdp, s.envelope, time.Now()); err != nil {
+			s.logger().Error(logs.MANAGER, "seal envelope", "err", err)
+			return "", err
+		}
+	}
+	if s.secret != nil {
+		if sdp, err = util.EncryptSDP(sdp, s.secret); err != nil {
+			s.logger().Error(logs.MANAGER, "encrypt description", "err", err)
+			return "", err
+		}
+	}
+	return sdp, nil
+}
+
+// decodeInput unwrap a remote description as configured, caller must not hold mu:
+// a salt the passphrase was not derived for costs a scrypt run that would stall every session
+//
+//	chunks -> encryption -> envelope -> format
+func (s *SessionManagerImpl) decodeInput(in string) (string, error) {
+	s.mu.Lock()
+	limit := s.sdpLimits().MaxEncodedSize
+	secret := s.secret
+	opts := s.envelope
+	s.mu.Unlock()
+	var err error
+	if util.IsChunked(in) {
+		// part headers add overhead, the joined description is checked below
+		if len(in) > chunkOverhead*limit {
+			return "", &util.SDPError{Check: util.CheckDecode, Detail: "input too long", Err: util.ErrTooLarge}
+		}
+		joined, err := util.JoinSDP(strings.Fields(in))
+		if err != nil {
+			s.logger().Warn(logs.SESSION, "join chunks", "err", err)
+			return "", err
+		}
+		in = joined
+	}
+	if len(in) > limit {
+		return "", &util.SDPError{Check: util.CheckDecode, Detail: "input too long", Err: util.ErrTooLarge}
+	}
+	switch {
+	case secret != nil:
+		if in, err = util.DecryptSDP(in, secret); err != nil {
+			s.logger().Warn(logs.SESSION, "decrypt description", "err", err)
+			return "", err
+		}
+	case util.IsEncrypted(in):
+		return "", &util.SDPError{Check: util.CheckEncryption, Detail: "encryption is not enabled", Err: util.ErrDecrypt}
+	}
+
+	if opts == nil {
+		if util.IsEnvelope(in) {
+			return "", &util.SDPError{Check: util.CheckEnvelope, Detail: "envelopes are not enabled", Err: util.ErrEnvelope}
+		}
+		return in, nil
+	}
+	sdp, err := util.OpenEnvelope(in, opts, time.Now())
+	if err != nil {
+		s.logger().Warn(logs.SESSION, "open envelope", "err", err)
+		return "", err
+	}
+	return sdp, nil
+}
+
+// newEnvelope return nil when envelopes are disabled, keys are parsed here once per config
+func (s *SessionManagerImpl) newEnvelope(config *conf.EnvelopeConf) (*util.EnvelopeOptions, error) {
+	if !config.Enable {
+		return nil, nil
+	}
+	opts := &util.EnvelopeOptions{
+		TTL:   time.Duration(config.TTL) * time.Second,
+		Token: config.Token,
+	}
+	if opts.TTL <= 0 {
+		opts.TTL = defaultEnvelopeTTL
+	}
+	if config.HMACKey != "" {
+		opts.Keys.HMAC = []byte(config.HMACKey)
+	}
+	if config.Ed25519PrivateKey != "" {
+		key, err := util.ParseEd25519PrivateKey(config.Ed25519PrivateKey)
+		if err != nil {
+			s.logger().Error(logs.CONFIG, "envelope private key", "err", err)
+			return nil, err
+		}
+		opts.Keys.Ed25519Private = key
+	}
+	for _, encoded := range config.Ed25519PublicKeys {
+		key, err := util.ParseEd25519PublicKey(encoded)
+		if err != nil {
+			s.logger().Error(logs.CONFIG, "envelope public key", "err", err)
+			return nil, err
+		}
+		opts.Keys.Ed25519Public = append(opts.Keys.Ed25519Public, key)
+	}
+	if opts.Keys.HMAC == nil && opts.Keys.Ed25519Private == nil {
+		s.logger().Error(logs.CONFIG, "envelope enabled without HMACKey or Ed25519PrivateKey")
+		return nil, util.ErrEnvelope
+	}
+	return opts, nil
+}
+
+// newSecret return nil when encryption is disabled, a passphrase is derived here once per config
+func (s *SessionManagerImpl) newSecret(config *conf.EncryptionConf) (*util.Secret, error) {
+	if !config.Enable {
+		return nil, nil
+	}
+	secret := &util.Secret{Passphrase: config.Passphrase}
+	if config.Key != "" {
+		key, err := base64.StdEncoding.DecodeString(config.Key)
+		if err != nil {
+			s.logger().Error(logs.CONFIG, "encryption key", "err", err)
+			return nil, err
+		}
+		if len(key) != 32 {
+			s.logger().Error(logs.CONFIG, "encryption key must be 32 bytes", "size", len(key))
+			return nil, util.ErrDecrypt
+		}
+		secret.Key = key
+	}
+	if secret.Key == nil && secret.Passphrase == "" {
+		s.logger().Error(logs.CONFIG, "encryption enabled without key or passphrase")
+		return nil, util.ErrDecrypt
+	}
+	if err := secret.Prepare(); err != nil {
+		s.logger().Error(logs.CONFIG, "derive encryption key", "err", err)
+		return nil, err
+	}
+	return secret, nil
+}
+
+// split cut an encoded description into parts of ChunkSize, caller must hold mu
+func (s *SessionManagerImpl) split(sdp string) ([]string, error) {
+	if s.config.ChunkSize <= 0 {
+		return []string{sdp}, nil
+	}
+	parts, err := util.SplitSDP(sdp, s.config.ChunkSize)
+	if err != nil {
+		s.logger().Error(logs.MANAGER, "split description", "err", err)
+		return nil, err
+	}
+	return parts, nil
+}
@@ -2,29 +2,109 @@ package conf
 
 import (
 	"encoding/json"
-	"fmt"
-	"sessionmgr/dbg"
+	"sessionmgr/logs"
 	"github.com/pion/webrtc/v4"
 	"os"
 )
 
 type Configuration struct {
 	WebrtcConf       webrtc.Configuration `json:"WebRTC"`
-	CacheSize        int                  `json:"CacheSize"`
+	CacheSize        int                  `json:"CacheSize"` // received messages waiting for Ready, delivery blocks when it is full
 	SessionLifeCycle int                  `json:"SessionLifeCycle"`
+	TurnServer       TurnServerConf       `json:"TurnServer"`
+	// SDPFormat is the output of Offer and Answer: compressed (default), json, raw or compact
+	SDPFormat string `json:"SDPFormat"`
+	// MaxEncodedSDP and MaxDecodedSDP bound remote descriptions in bytes, 0 means the default
+	MaxEncodedSDP int            `json:"MaxEncodedSDP"`
+	MaxDecodedSDP int            `json:"MaxDecodedSDP"`
+	Envelope      EnvelopeConf   `json:"Envelope"`
+	Encryption    EncryptionConf `json:"Encryption"`
+	// ChunkSize is the longest part returned by OfferChunks and AnswerChunks, 0 means no split
+	ChunkSize int `json:"ChunkSize"`
+	// Certificate is a PEM file with the DTLS certificate and key, empty means one per session
+	Certificate string `json:"Certificate"`
+	// PinnedFingerprints are the allowed remote certificates as "sha-256 AB:CD:...", empty allows any
+	PinnedFingerprints []string `json:"PinnedFingerprints"`
+	Auth               AuthConf `json:"Auth"`
+	// Log configure the logger of the manager when it is created, without it logs.Default is used
+	Log *logs.Config `json:"Log"`
+	// Audit is the JSON Lines file of session lifecycle events, opened when the manager is created,
+	// empty disables it
+	Audit string `json:"Audit"`
+	// Record is the directory of the datachannel messages of every session, one file per session,
+	// set when the manager is created, empty disables it
+	Record string `json:"Record"`
+}
+
+// AuthConf describe the handshake peers go through before their messages are delivered
+type AuthConf struct {
+	// Mode is empty (no handshake), token or hmac
+	Mode string `json:"Mode"`
+	// Token is shared by both sides, only a response keyed with it crosses the wire
+	Token string `json:"Token"`
+	// HMACKey is shared by both sides, only a challenge response crosses the wire
+	HMACKey string `json:"HMACKey"`
+	// Timeout is the seconds a peer has to pass the handshake once the channel opens, 0 means 10
+	Timeout int `json:"Timeout"`
+}
+
+// EncryptionConf describe the encryption of offers and answers
+type EncryptionConf struct {
+	// Enable encrypt every Offer/Answer and reject plain descriptions
+	Enable bool `json:"Enable"`
+	// Passphrase is shared by both sides, the key is derived with scrypt
+	Passphrase string `json:"Passphrase"`
+	// Key is a base64 32 byte key, it is used instead of Passphrase when set
+	Key string `json:"Key"`
+}
+
+// EnvelopeConf describe the signed envelope around offers and answers
+type EnvelopeConf struct {
+	// Enable sign every Offer/Answer and reject unsigned descriptions
+	Enable bool `json:"Enable"`
+	// HMACKey is a secret shared by both sides
+	HMACKey string `json:"HMACKey"`
+	// Ed25519PrivateKey is a base64 seed, it is used instead of HMACKey when set
+	Ed25519PrivateKey string `json:"Ed25519PrivateKey"`
+	// Ed25519PublicKeys are the base64 keys of trusted peers
+	Ed25519PublicKeys []string `json:"Ed25519PublicKeys"`
+	// TTL is the lifetime of an envelope in seconds
+	TTL int `json:"TTL"`
+	// Token is a shared session token carried in every envelope
+	Token string `json:"Token"`
+}
+
+// TurnServerConf describe the embedded STUN/TURN server, it is read at start and ReloadConfig keeps it
+type TurnServerConf struct {
+	// Enable start the server together with the manager
+	Enable bool `json:"Enable"`
+	// ListenAddr is the UDP address to listen on, e.g. "0.0.0.0:3478"
+	ListenAddr string `json:"ListenAddr"`
+	// PublicIP is the address advertised in ICE servers and relay candidates
+	PublicIP string `json:"PublicIP"`
+	Realm    string `json:"Realm"`
+	// Users are static username/password pairs
+	Users map[string]string `json:"Users"`
+	// AuthSecret enables HMAC based time-windowed credentials
+	AuthSecret string `json:"AuthSecret"`
+	// CredentialTTL is the lifetime of HMAC credentials in seconds
+	CredentialTTL int `json:"CredentialTTL"`
+	// RelayPortMin and RelayPortMax limit the relay ports, 0 means any
+	RelayPortMin uint16 `json:"RelayPortMin"`
+	RelayPortMax uint16 `json:"RelayPortMax"`
 }
 
 func LoadConfig(ConfPath string) (*Configuration, error) {
-	data, err := os.ReadFile("conf.json")
+	data, err := os.ReadFile(ConfPath)
 	if err != nil {
-		dbg.Println(dbg.CONFIG, err)
+		logs.Default().Error(logs.CONFIG, "read config", "path", ConfPath, "err", err)
 		return nil, err
 	}
 
 	config := &Configuration{}
 	err = json.Unmarshal(data, config)
 	if err != nil {
-		fmt.Println(dbg.CONFIG, err)
+		logs.Default().Error(logs.CONFIG, "parse config", "path", ConfPath, "err", err)
 		return nil, err
 	}
 
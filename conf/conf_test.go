@@ -1,7 +1,10 @@
 package conf
 
 import (
+	"errors"
 	"fmt"
+	"os"
+	"path/filepath"
 	"testing"
 )
 
@@ -13,3 +16,21 @@ func TestLoadConfig(t *testing.T) {
 	}
 	fmt.Println(conf)
 }
+
+func TestLoadConfigPath(t *testing.T) {
+	// the working directory holds no conf.json, only ConfPath is read
+	path := filepath.Join(t.TempDir(), "manager.json")
+	if err := os.WriteFile(path, []byte(`{"CacheSize":7,"SessionLifeCycle":30}`), 0644); err != nil {
+		t.Fatal(err)
+	}
+	conf, err := LoadConfig(path)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if conf.CacheSize != 7 || conf.SessionLifeCycle != 30 {
+		t.Errorf("unexpected config %+v", conf)
+	}
+	if _, err = LoadConfig(filepath.Join(t.TempDir(), "missing.json")); !errors.Is(err, os.ErrNotExist) {
+		t.Errorf("expected ErrNotExist, got %v", err)
+	}
+}
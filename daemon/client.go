@@ -0,0 +1,189 @@
+package daemon
+
+import (
+	"errors"
+	"google.golang.org/protobuf/proto"
+	"io"
+	"net"
+	"sessionmgr"
+	"sessionmgr/logs"
+	readypb "sessionmgr/proto/pkg/ready_pb"
+	reqpb "sessionmgr/proto/pkg/request_pb"
+	pb "sessionmgr/proto/pkg/return_pb"
+	"sessionmgr/serve"
+	"sync"
+)
+
+// client is one connection, it implements serve.Manager over the sessions it owns
+type client struct {
+	d    *Daemon
+	conn net.Conn
+	// sessions map the IDs of the client to manager session IDs, protected by d.mu
+	sessions map[int32]int32
+	pushes   chan *pb.Return
+	done     chan struct{}
+	mu       sync.Mutex // serialize frames on conn
+}
+
+// serve answer the requests of the client until it disconnects or sends Discard
+func (c *client) serve() {
+	for {
+		frame, err := serve.ReadFrame(c.conn)
+		if err != nil {
+			if !errors.Is(err, io.EOF) && !errors.Is(err, net.ErrClosed) {
+				logs.Default().Warn(logs.MANAGER, "read request", "err", err)
+			}
+			return
+		}
+		req := &reqpb.Request{}
+		if err = proto.Unmarshal(frame, req); err != nil {
+			logs.Default().Warn(logs.MANAGER, "malformed request", "err", err)
+			if err = c.write(serve.Malformed(frame, err)); err != nil {
+				return
+			}
+			continue
+		}
+		ret := serve.Handle(c, req)
+		ret.Id = req.GetId()
+		if err = c.write(ret); err != nil {
+			return
+		}
+		if req.GetDiscard() != nil {
+			return
+		}
+	}
+}
+
+// push write the pushes routed to the client
+func (c *client) push() {
+	for {
+		select {
+		case ret := <-c.pushes:
+			if err := c.write(ret); err != nil {
+				_ = c.conn.Close()
+				return
+			}
+		case <-c.done:
+			return
+		}
+	}
+}
+
+// enqueue never blocks the router, a client that does not keep up is disconnected
+func (c *client) enqueue(ret *pb.Return) {
+	select {
+	case c.pushes <- ret:
+	case <-c.done:
+	default:
+		logs.Default().Warn(logs.MANAGER, "client does not read its messages, disconnecting")
+		_ = c.conn.Close()
+	}
+}
+
+func (c *client) write(ret *pb.Return) error {
+	frame, err := proto.Marshal(ret)
+	if err != nil {
+		return err
+	}
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	return serve.WriteFrame(c.conn, frame)
+}
+
+func (c *client) CreateSession(SessionID int32) error {
+	global, err := c.d.allocate(c, SessionID)
+	if err != nil {
+		return c.error("CreateSession", SessionID, err)
+	}
+	if err = c.d.mgr.CreateSession(global); err != nil {
+		c.d.release(c, SessionID)
+		return c.error("CreateSession", SessionID, err)
+	}
+	return nil
+}
+
+func (c *client) Offer(SessionID int32) (string, error) {
+	global, err := c.d.global(c, SessionID)
+	if err != nil {
+		return "", c.error("Offer", SessionID, err)
+	}
+	offer, err := c.d.mgr.Offer(global)
+	return offer, c.error("Offer", SessionID, err)
+}
+
+func (c *client) JoinSession(SessionID int32, sdpBase64 string) error {
+	global, err := c.d.allocate(c, SessionID)
+	if err != nil {
+		return c.error("JoinSession", SessionID, err)
+	}
+	if err = c.d.mgr.JoinSession(global, sdpBase64); err != nil {
+		c.d.release(c, SessionID)
+		return c.error("JoinSession", SessionID, err)
+	}
+	return nil
+}
+
+func (c *client) Answer(SessionID int32) (string, error) {
+	global, err := c.d.global(c, SessionID)
+	if err != nil {
+		return "", c.error("Answer", SessionID, err)
+	}
+	answer, err := c.d.mgr.Answer(global)
+	return answer, c.error("Answer", SessionID, err)
+}
+
+func (c *client) ConfirmAnswer(SessionID int32, sdpBase64 string) error {
+	global, err := c.d.global(c, SessionID)
+	if err != nil {
+		return c.error("ConfirmAnswer", SessionID, err)
+	}
+	return c.error("ConfirmAnswer", SessionID, c.d.mgr.ConfirmAnswer(global, sdpBase64))
+}
+
+func (c *client) Send(SessionID int32, dAtA []byte) error {
+	global, err := c.d.global(c, SessionID)
+	if err != nil {
+		return c.error("Send", SessionID, err)
+	}
+	return c.error("Send", SessionID, c.d.mgr.Send(global, dAtA))
+}
+
+// Ready is always empty, messages are pushed to the client as they arrive
+func (c *client) Ready() ([]*readypb.Ready, error) {
+	return []*readypb.Ready{}, nil
+}
+
+func (c *client) DropSession(SessionID int32) error {
+	global, err := c.d.global(c, SessionID)
+	if err != nil {
+		return c.error("DropSession", SessionID, err)
+	}
+	c.d.release(c, SessionID)
+	return c.error("DropSession", SessionID, c.d.mgr.DropSession(global))
+}
+
+// ReloadConfig is refused, the shared manager is reloaded by the operator of the daemon
+// through the admin API and not from a path any client picks
+func (c *client) ReloadConfig(ConfPath string) error {
+	logs.Default().Warn(logs.MANAGER, "client reload refused", "path", ConfPath)
+	return &sessionmgr.Error{Op: "ReloadConfig", Kind: sessionmgr.ErrCall}
+}
+
+// Discard end the connection of the client, the shared manager is left running
+func (c *client) Discard() error {
+	return nil
+}
+
+// error report err under the session ID the client knows, err is a sentinel unless the manager returned it
+func (c *client) error(op string, SessionID int32, err error) error {
+	if err == nil {
+		return nil
+	}
+	var e *sessionmgr.Error
+	if errors.As(err, &e) {
+		local := *e
+		local.SessionID, local.HasSessionID = SessionID, true
+		return &local
+	}
+	return &sessionmgr.Error{Op: op, SessionID: SessionID, HasSessionID: true, Kind: err}
+}
@@ -0,0 +1,224 @@
+package daemon
+
+import (
+	"context"
+	"errors"
+	"net"
+	"os"
+	"sessionmgr"
+	"sessionmgr/logs"
+	readypb "sessionmgr/proto/pkg/ready_pb"
+	pb "sessionmgr/proto/pkg/return_pb"
+	"sync"
+)
+
+// pushBacklog is how many pushes a client may leave unread before it is disconnected
+const pushBacklog = 256
+
+// Daemon share one manager between the clients of a socket, every client sees only the sessions
+// it created under its own IDs and they are dropped when it disconnects
+type Daemon struct {
+	mgr *sessionmgr.SessionManagerImpl
+
+	mu      sync.Mutex
+	owners  map[int32]owner // by manager session ID
+	clients map[*client]struct{}
+	nextID  int32
+}
+
+// owner is the client of a manager session and the ID the client knows it by
+type owner struct {
+	client *client
+	local  int32
+}
+
+func New(mgr *sessionmgr.SessionManagerImpl) *Daemon {
+	d := &Daemon{
+		mgr:     mgr,
+		owners:  make(map[int32]owner),
+		clients: make(map[*client]struct{}),
+	}
+	mgr.OnSessionDropped(d.dropped)
+	return d
+}
+
+// Listen listen on a Unix socket at path, a socket left by a previous daemon is removed
+func Listen(path string) (net.Listener, error) {
+	if info, err := os.Lstat(path); err == nil && info.Mode()&os.ModeSocket != 0 {
+		if conn, err := net.Dial("unix", path); err == nil {
+			_ = conn.Close()
+			return nil, errors.New("daemon already listening on " + path)
+		}
+		if err = os.Remove(path); err != nil {
+			return nil, err
+		}
+	}
+	return net.Listen("unix", path)
+}
+
+// Serve accept clients until ctx is done or l is closed, then disconnect every client
+func (d *Daemon) Serve(ctx context.Context, l net.Listener) error {
+	ctx, cancel := context.WithCancel(ctx)
+	defer cancel()
+	go func() {
+		<-ctx.Done()
+		_ = l.Close()
+	}()
+	go d.route(ctx)
+
+	var wg sync.WaitGroup
+	defer wg.Wait()
+	for {
+		conn, err := l.Accept()
+		if err != nil {
+			d.disconnectAll()
+			if ctx.Err() != nil {
+				return nil
+			}
+			return err
+		}
+		c := d.connect(conn)
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			c.serve()
+			d.disconnect(c)
+		}()
+	}
+}
+
+func (d *Daemon) connect(conn net.Conn) *client {
+	c := &client{
+		d:        d,
+		conn:     conn,
+		sessions: make(map[int32]int32),
+		pushes:   make(chan *pb.Return, pushBacklog),
+		done:     make(chan struct{}),
+	}
+	d.mu.Lock()
+	d.clients[c] = struct{}{}
+	d.mu.Unlock()
+	go c.push()
+	logs.Default().Info(logs.MANAGER, "client connected")
+	return c
+}
+
+// disconnect drop every session of c
+func (d *Daemon) disconnect(c *client) {
+	d.mu.Lock()
+	if _, ok := d.clients[c]; !ok {
+		d.mu.Unlock()
+		return
+	}
+	delete(d.clients, c)
+	globals := make([]int32, 0, len(c.sessions))
+	for local, global := range c.sessions {
+		globals = append(globals, global)
+		delete(d.owners, global)
+		delete(c.sessions, local)
+	}
+	d.mu.Unlock()
+
+	close(c.done)
+	_ = c.conn.Close()
+	for _, global := range globals {
+		if err := d.mgr.DropSession(global); err != nil {
+			logs.Default().Warn(logs.MANAGER, "drop session of client", "err", err)
+		}
+	}
+	logs.Default().Info(logs.MANAGER, "client disconnected", "dropped", len(globals))
+}
+
+func (d *Daemon) disconnectAll() {
+	d.mu.Lock()
+	clients := make([]*client, 0, len(d.clients))
+	for c := range d.clients {
+		clients = append(clients, c)
+	}
+	d.mu.Unlock()
+	for _, c := range clients {
+		// the serve loop of c ends and disconnects it
+		_ = c.conn.Close()
+	}
+}
+
+// route push every received message to the client owning its session, under the client's ID
+func (d *Daemon) route(ctx context.Context) {
+	for {
+		rlist, err := d.mgr.WaitReady(ctx)
+		if err != nil {
+			return
+		}
+		byClient := make(map[*client][]*readypb.Ready)
+		d.mu.Lock()
+		for _, ready := range rlist {
+			o, ok := d.owners[ready.SessionID]
+			if !ok {
+				logs.Default().Debug(logs.READY, "message of unowned session", "session", ready.SessionID)
+				continue
+			}
+			byClient[o.client] = append(byClient[o.client], &readypb.Ready{SessionID: o.local, DAtA: ready.DAtA})
+		}
+		d.mu.Unlock()
+		for c, list := range byClient {
+			c.enqueue(&pb.Return{ReadyReturn: &pb.ReturnReady{ReadyList: list}})
+		}
+	}
+}
+
+// allocate bind local of c to a fresh manager session ID
+func (d *Daemon) allocate(c *client, local int32) (int32, error) {
+	d.mu.Lock()
+	defer d.mu.Unlock()
+	if _, existed := c.sessions[local]; existed {
+		return 0, sessionmgr.ErrID
+	}
+	for {
+		d.nextID++
+		if d.nextID <= 0 {
+			d.nextID = 1
+		}
+		if _, used := d.owners[d.nextID]; !used {
+			break
+		}
+	}
+	d.owners[d.nextID] = owner{client: c, local: local}
+	c.sessions[local] = d.nextID
+	return d.nextID, nil
+}
+
+// release unbind local of c
+func (d *Daemon) release(c *client, local int32) {
+	d.mu.Lock()
+	defer d.mu.Unlock()
+	if global, ok := c.sessions[local]; ok {
+		delete(d.owners, global)
+		delete(c.sessions, local)
+	}
+}
+
+// dropped unbind a session the manager dropped by itself, on timeout or when its peer left,
+// so the client may reuse its ID
+func (d *Daemon) dropped(global int32) {
+	d.mu.Lock()
+	defer d.mu.Unlock()
+	o, ok := d.owners[global]
+	if !ok {
+		return
+	}
+	delete(d.owners, global)
+	if o.client.sessions[o.local] == global {
+		delete(o.client.sessions, o.local)
+	}
+}
+
+// global return the manager session ID of local of c
+func (d *Daemon) global(c *client, local int32) (int32, error) {
+	d.mu.Lock()
+	defer d.mu.Unlock()
+	global, ok := c.sessions[local]
+	if !ok {
+		return 0, sessionmgr.ErrLost
+	}
+	return global, nil
+}
@@ -0,0 +1,219 @@
+package daemon
+
+import (
+	"context"
+	"errors"
+	"google.golang.org/protobuf/proto"
+	"net"
+	"path/filepath"
+	"sessionmgr"
+	"sessionmgr/internal/testmgr"
+	readypb "sessionmgr/proto/pkg/ready_pb"
+	reqpb "sessionmgr/proto/pkg/request_pb"
+	pb "sessionmgr/proto/pkg/return_pb"
+	"sessionmgr/serve"
+	"testing"
+	"time"
+)
+
+func newDaemon(t *testing.T) (*Daemon, string) {
+	mgr := testmgr.New(t, "")
+	socket := filepath.Join(t.TempDir(), "sessionmgrd.sock")
+	l, err := Listen(socket)
+	if err != nil {
+		t.Fatal(err)
+	}
+	d := New(mgr)
+	ctx, cancel := context.WithCancel(context.Background())
+	done := make(chan error, 1)
+	go func() { done <- d.Serve(ctx, l) }()
+	t.Cleanup(func() {
+		cancel()
+		if err := <-done; err != nil {
+			t.Error(err)
+		}
+	})
+	return d, socket
+}
+
+// testClient speak to the daemon like a local process would
+type testClient struct {
+	t      *testing.T
+	conn   net.Conn
+	nextID uint64
+	pushed []*readypb.Ready
+}
+
+func dial(t *testing.T, socket string) *testClient {
+	conn, err := net.Dial("unix", socket)
+	if err != nil {
+		t.Fatal(err)
+	}
+	t.Cleanup(func() { _ = conn.Close() })
+	return &testClient{t: t, conn: conn}
+}
+
+func (c *testClient) call(req *reqpb.Request) *pb.Return {
+	c.nextID++
+	req.Id = c.nextID
+	frame, err := proto.Marshal(req)
+	if err != nil {
+		c.t.Fatal(err)
+	}
+	if err = serve.WriteFrame(c.conn, frame); err != nil {
+		c.t.Fatal(err)
+	}
+	for {
+		ret := c.read()
+		if ret.GetId() == req.Id {
+			return ret
+		}
+		c.pushed = append(c.pushed, ret.GetReadyReturn().GetReadyList()...)
+	}
+}
+
+func (c *testClient) read() *pb.Return {
+	_ = c.conn.SetReadDeadline(time.Now().Add(10 * time.Second))
+	frame, err := serve.ReadFrame(c.conn)
+	if err != nil {
+		c.t.Fatal(err)
+	}
+	ret := &pb.Return{}
+	if err = proto.Unmarshal(frame, ret); err != nil {
+		c.t.Fatal(err)
+	}
+	return ret
+}
+
+// retry call until it stops failing with ErrWait
+func (c *testClient) retry(req func() *reqpb.Request) *pb.Return {
+	deadline := time.Now().Add(10 * time.Second)
+	for {
+		ret := c.call(req())
+		if ret.GetErr().GetErrWait() == nil || time.Now().After(deadline) {
+			if ret.GetErr() != nil {
+				c.t.Fatal(sessionmgr.FromProto(ret.GetErr()))
+			}
+			return ret
+		}
+		time.Sleep(50 * time.Millisecond)
+	}
+}
+
+func (c *testClient) mustCall(req *reqpb.Request) *pb.Return {
+	ret := c.call(req)
+	if ret.GetErr() != nil {
+		c.t.Fatal(sessionmgr.FromProto(ret.GetErr()))
+	}
+	return ret
+}
+
+func TestDaemon(t *testing.T) {
+	d, socket := newDaemon(t)
+	alice, bob, eve := dial(t, socket), dial(t, socket), dial(t, socket)
+
+	// both clients use session 1, each sees its own
+	alice.mustCall(&reqpb.Request{CreateSession: &reqpb.RequestCreateSession{SessionId: 1}})
+	offer := alice.retry(func() *reqpb.Request { return &reqpb.Request{Offer: &reqpb.RequestOffer{SessionId: 1}} })
+	bob.mustCall(&reqpb.Request{JoinSession: &reqpb.RequestJoinSession{SessionId: 1, OfferBase64: offer.GetOfferReturn().GetOfferBase64()}})
+	answer := bob.retry(func() *reqpb.Request { return &reqpb.Request{Answer: &reqpb.RequestAnswer{SessionId: 1}} })
+	alice.mustCall(&reqpb.Request{ConfirmAnswer: &reqpb.RequestConfirmAnswer{SessionId: 1, AnswerBase64: answer.GetAnswerReturn().GetAnswerBase64()}})
+	alice.retry(func() *reqpb.Request {
+		return &reqpb.Request{Send: &reqpb.RequestSend{SessionId: 1, DAtA: []byte("hi bob")}}
+	})
+
+	for len(bob.pushed) == 0 {
+		bob.pushed = append(bob.pushed, bob.read().GetReadyReturn().GetReadyList()...)
+	}
+	if bob.pushed[0].GetSessionID() != 1 || string(bob.pushed[0].GetDAtA()) != "hi bob" {
+		t.Errorf("unexpected push %v", bob.pushed[0])
+	}
+
+	ret := eve.call(&reqpb.Request{Send: &reqpb.RequestSend{SessionId: 1, DAtA: []byte("hijack")}})
+	if ret.GetErr().GetErrLost().GetID() != 1 {
+		t.Errorf("expected ErrLost for a session of another client, got %v", ret)
+	}
+	ret = alice.call(&reqpb.Request{CreateSession: &reqpb.RequestCreateSession{SessionId: 1}})
+	if ret.GetErr().GetErrId().GetID() != 1 {
+		t.Errorf("expected ErrID for a repeated session, got %v", ret)
+	}
+
+	// alice leaves, her session goes with her, bob's may follow once its peer is gone
+	alice.mustCall(&reqpb.Request{Discard: &reqpb.RequestDiscard{}})
+	deadline := time.Now().Add(10 * time.Second)
+	for {
+		d.mu.Lock()
+		owned := len(d.owners)
+		d.mu.Unlock()
+		if owned <= 1 {
+			break
+		}
+		if time.Now().After(deadline) {
+			t.Fatalf("expected only bob's session left, got %d", owned)
+		}
+		time.Sleep(50 * time.Millisecond)
+	}
+	if err := d.mgr.Send(1, []byte("late")); !errors.Is(err, sessionmgr.ErrLost) {
+		t.Errorf("expected alice's session to be dropped, got %v", err)
+	}
+
+	// bob just hangs up
+	_ = bob.conn.Close()
+	for deadline := time.Now().Add(10 * time.Second); ; {
+		d.mu.Lock()
+		owned := len(d.owners)
+		d.mu.Unlock()
+		if owned == 0 {
+			break
+		}
+		if time.Now().After(deadline) {
+			t.Fatal("bob's session outlived his connection")
+		}
+		time.Sleep(50 * time.Millisecond)
+	}
+}
+
+func TestDaemonReleasesDroppedSessions(t *testing.T) {
+	d, socket := newDaemon(t)
+	alice := dial(t, socket)
+	alice.mustCall(&reqpb.Request{CreateSession: &reqpb.RequestCreateSession{SessionId: 1}})
+	d.mu.Lock()
+	var global int32
+	for id := range d.owners {
+		global = id
+	}
+	d.mu.Unlock()
+
+	// the manager drops the session by itself, as its lifetime would
+	if err := d.mgr.DropSession(global); err != nil {
+		t.Fatal(err)
+	}
+	d.mu.Lock()
+	owned := len(d.owners)
+	d.mu.Unlock()
+	if owned != 0 {
+		t.Fatalf("expected the dropped session to be released, %d left", owned)
+	}
+	alice.mustCall(&reqpb.Request{CreateSession: &reqpb.RequestCreateSession{SessionId: 1}})
+}
+
+func TestDaemonRefusesReload(t *testing.T) {
+	_, socket := newDaemon(t)
+	client := dial(t, socket)
+	ret := client.call(&reqpb.Request{ReloadConfig: &reqpb.RequestReloadConfig{ConfPath: "/tmp/elsewhere.json"}})
+	if err := sessionmgr.FromProto(ret.GetErr()); !errors.Is(err, sessionmgr.ErrCall) {
+		t.Errorf("expected ErrCall, got %v", err)
+	}
+}
+
+func TestDaemonAnswersMalformed(t *testing.T) {
+	_, socket := newDaemon(t)
+	client := dial(t, socket)
+	if err := serve.WriteFrame(client.conn, []byte{0x08, 0x07, 0xff}); err != nil {
+		t.Fatal(err)
+	}
+	if ret := client.read(); ret.GetId() != 7 || ret.GetErr() == nil {
+		t.Errorf("expected a malformed reply to 7, got %v", ret)
+	}
+	client.mustCall(&reqpb.Request{CreateSession: &reqpb.RequestCreateSession{SessionId: 1}})
+}
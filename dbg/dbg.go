@@ -2,8 +2,10 @@ package dbg
 
 import (
 	"fmt"
-	"log"
+	"log/slog"
 	"os"
+	"path/filepath"
+	"sessionmgr/logs"
 )
 
 type DebugTopic int
@@ -41,27 +43,44 @@ var DebugModeToStr = map[DebugMode]string{
 }
 
 var Mode DebugMode = SILENT
-var FileLogger *log.Logger
-var File *os.File
 
+// Dir is where SINGLEFILE writes sessionmgr.log
+var Dir = "./log/"
+
+// Rotate limit the files of SINGLEFILE, by default they grow without limit
+var Rotate logs.RotateOptions
+
+// PerSession make SINGLEFILE write each session to its own file session-<ID>.log in Dir
+var PerSession bool
+
+var File *logs.RotatingFile
+
+// Init route logs.Default to mode, every topic at debug level unless logs.EnvVar says otherwise
+//
+// Deprecated: inject a *logs.Logger, or configure Log in conf.Configuration
 func Init(mode DebugMode) error {
+	opts := logs.Options{Level: slog.LevelDebug}
 	switch mode {
 	case SILENT:
 		Mode = SILENT
+		logs.SetDefault(nil)
 	case STDOUT:
 		Mode = STDOUT
+		logs.SetDefault(logs.New(os.Stdout, opts))
 	case SINGLEFILE:
 		Mode = SINGLEFILE
 		var err error
-		err = os.MkdirAll("./log/", 0755)
+		File, err = logs.OpenRotating(filepath.Join(Dir, "sessionmgr.log"), Rotate)
 		if err != nil {
 			return err
 		}
-		File, err = os.CreateTemp("./log/", "log")
-		if err != nil {
-			return err
+		if PerSession {
+			opts.SessionDir, opts.Rotate = Dir, Rotate
 		}
-		FileLogger = log.New(File, "", log.LstdFlags)
+		logs.SetDefault(logs.New(File, opts))
+	}
+	if err := logs.Default().SetLevels(os.Getenv(logs.EnvVar)); err != nil {
+		return fmt.Errorf("%v: %w", logs.EnvVar, err)
 	}
 	return nil
 }
@@ -72,17 +91,17 @@ func Close() {
 	}
 }
 
+// Println write a line to logs.Default at debug level, nothing in SILENT mode
+//
+// Deprecated: use a *logs.Logger
 func Println(topic DebugTopic, a ...interface{}) {
-	switch Mode {
-	case SILENT:
-	case STDOUT:
-		fmt.Printf("[%v] %v\n", DebugTopicToStr[topic], fmt.Sprint(a...))
-	case SINGLEFILE:
-		FileLogger.Printf("[%v] %v\n", DebugTopicToStr[topic], fmt.Sprint(a...))
+	if Mode == SILENT {
+		return
 	}
+	logs.Default().Debug(logs.Topic(DebugTopicToStr[topic]), fmt.Sprint(a...))
 }
 
 func Fatal(topic DebugTopic, a ...interface{}) {
-	Println(topic, a...)
+	logs.Default().Error(logs.Topic(DebugTopicToStr[topic]), fmt.Sprint(a...))
 	os.Exit(1)
 }
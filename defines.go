@@ -3,6 +3,7 @@ package sessionmgr
 import (
 	"errors"
 	pb "sessionmgr/proto/pkg/ready_pb"
+	"sessionmgr/util"
 )
 
 type SessionManager interface {
@@ -17,6 +18,7 @@ type SessionManager interface {
 	// ConfirmAnswer confirms a session description
 	ConfirmAnswer(SessionID int32, sdpBase64 string) error
 	// Send add dAtA to send queue, it is not a obstructive function
+	// it returns ErrWait until the channel is open, on the answer side also before the peer opened it
 	Send(SessionID int32, dAtA []byte) error
 	// Ready return a list of received messages and where are they from
 	Ready() ([]*pb.Ready, error)
@@ -27,8 +29,13 @@ type SessionManager interface {
 	Discard() error
 }
 
+// methods of SessionManagerImpl return these wrapped in an *Error, match them with errors.Is
 var ErrID = errors.New("SessionID invalid")
 var ErrCall = errors.New("manager has been discarded")
 var ErrLost = errors.New("session lost")
 var ErrWait = errors.New("service is not prepared")
-var ErrSdp = errors.New("sdp invalid")
+var ErrFingerprint = errors.New("remote fingerprint not pinned")
+var ErrAuth = errors.New("peer authentication failed")
+
+// ErrSdp is matched by every *util.SDPError
+var ErrSdp = util.ErrSdp
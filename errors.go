@@ -0,0 +1,157 @@
+package sessionmgr
+
+import (
+	"errors"
+	"fmt"
+	errpb "sessionmgr/proto/pkg/error_pb"
+)
+
+// Error is returned by every manager method, it matches its sentinel (ErrID, ErrLost...)
+// and its cause with errors.Is
+type Error struct {
+	// Op is the manager method that failed
+	Op string
+	// SessionID is meaningful when HasSessionID is set, manager wide methods have none
+	SessionID    int32
+	HasSessionID bool
+	// Kind is one of the sentinel errors, nil for a foreign error such as one from pion
+	Kind error
+	// Err is the underlying cause, may be nil
+	Err error
+	// msg replaces the formatted message of an error decoded from protobuf
+	msg string
+}
+
+// sentinels are the kinds an Error can have, in matching order
+var sentinels = []error{ErrID, ErrCall, ErrLost, ErrWait, ErrSdp, ErrFingerprint, ErrAuth}
+
+func (e *Error) Error() string {
+	if e.msg != "" {
+		return e.msg
+	}
+	msg := e.Op
+	if e.HasSessionID {
+		msg = fmt.Sprintf("%s session %d", msg, e.SessionID)
+	}
+	switch {
+	case e.Err == nil && e.Kind == nil:
+		return msg + ": unknown error"
+	case e.Err == nil:
+		return msg + ": " + e.Kind.Error()
+	case e.Kind == nil || errors.Is(e.Err, e.Kind):
+		return msg + ": " + e.Err.Error()
+	default:
+		return msg + ": " + e.Kind.Error() + ": " + e.Err.Error()
+	}
+}
+
+func (e *Error) Unwrap() []error {
+	errs := make([]error, 0, 2)
+	if e.Kind != nil {
+		errs = append(errs, e.Kind)
+	}
+	if e.Err != nil {
+		errs = append(errs, e.Err)
+	}
+	return errs
+}
+
+// newError wrap err with the context of a session method, an *Error is returned as is
+func newError(op string, SessionID int32, err error) error {
+	e, ok := wrap(op, err)
+	if ok {
+		e.SessionID, e.HasSessionID = SessionID, true
+		return e
+	}
+	return err
+}
+
+// managerError wrap err with the context of a manager wide method, an *Error is returned as is
+func managerError(op string, err error) error {
+	if e, ok := wrap(op, err); ok {
+		return e
+	}
+	return err
+}
+
+// wrap tell whether err needed wrapping, nil and *Error do not
+func wrap(op string, err error) (*Error, bool) {
+	var e *Error
+	if err == nil || errors.As(err, &e) {
+		return nil, false
+	}
+	e = &Error{Op: op, Err: err}
+	for _, sentinel := range sentinels {
+		if errors.Is(err, sentinel) {
+			e.Kind = sentinel
+			break
+		}
+	}
+	if e.Err == e.Kind {
+		e.Err = nil
+	}
+	return e, true
+}
+
+// wrapError is deferred by manager methods with named results to wrap whatever they return
+func wrapError(err *error, op string, SessionID int32) {
+	*err = newError(op, SessionID, *err)
+}
+
+// ToProto convert err to its protobuf form, the session ID is kept for ErrID and ErrLost
+func ToProto(err error) *errpb.Error {
+	if err == nil {
+		return nil
+	}
+	pbErr := &errpb.Error{Message: err.Error()}
+	var e *Error
+	var SessionID int32
+	if errors.As(err, &e) {
+		SessionID = e.SessionID
+	}
+	switch {
+	case errors.Is(err, ErrID):
+		pbErr.ErrId = &errpb.ErrID{ID: SessionID}
+	case errors.Is(err, ErrCall):
+		pbErr.ErrCall = &errpb.ErrCall{}
+	case errors.Is(err, ErrLost):
+		pbErr.ErrLost = &errpb.ErrLost{ID: SessionID}
+	case errors.Is(err, ErrWait):
+		pbErr.ErrWait = &errpb.ErrWait{}
+	case errors.Is(err, ErrSdp):
+		pbErr.ErrSdp = &errpb.ErrSdp{}
+	case errors.Is(err, ErrFingerprint):
+		pbErr.ErrFingerprint = &errpb.ErrFingerprint{}
+	case errors.Is(err, ErrAuth):
+		pbErr.ErrAuth = &errpb.ErrAuth{}
+	}
+	return pbErr
+}
+
+// FromProto rebuild an *Error from its protobuf form, its message is the original one
+func FromProto(pbErr *errpb.Error) error {
+	if pbErr == nil {
+		return nil
+	}
+	e := &Error{msg: pbErr.GetMessage()}
+	switch {
+	case pbErr.GetErrId() != nil:
+		e.Kind, e.SessionID, e.HasSessionID = ErrID, pbErr.GetErrId().GetID(), true
+	case pbErr.GetErrCall() != nil:
+		e.Kind = ErrCall
+	case pbErr.GetErrLost() != nil:
+		e.Kind, e.SessionID, e.HasSessionID = ErrLost, pbErr.GetErrLost().GetID(), true
+	case pbErr.GetErrWait() != nil:
+		e.Kind = ErrWait
+	case pbErr.GetErrSdp() != nil:
+		e.Kind = ErrSdp
+	case pbErr.GetErrFingerprint() != nil:
+		e.Kind = ErrFingerprint
+	case pbErr.GetErrAuth() != nil:
+		e.Kind = ErrAuth
+	}
+	if e.msg == "" {
+		e.msg = "unknown error"
+	}
+	return e
+}
@@ -0,0 +1,77 @@
+package sessionmgr
+
+import (
+	"errors"
+	"fmt"
+	"sessionmgr/util"
+	"strings"
+	"testing"
+)
+
+func TestError(t *testing.T) {
+	cause := errors.New("ice agent closed")
+	cases := []struct {
+		err     error
+		kind    error
+		message string
+	}{
+		{newError("Send", 7, ErrLost), ErrLost, "Send session 7: session lost"},
+		{newError("Offer", 7, cause), cause, "Offer session 7: ice agent closed"},
+		{newError("JoinSession", 7, &util.SDPError{Check: util.CheckType, Detail: "expected offer"}), ErrSdp, "JoinSession session 7: sdp invalid: type: expected offer"},
+		{managerError("ReloadConfig", ErrCall), ErrCall, "ReloadConfig: manager has been discarded"},
+	}
+	for _, c := range cases {
+		if !errors.Is(c.err, c.kind) {
+			t.Errorf("%v does not match %v", c.err, c.kind)
+		}
+		if c.err.Error() != c.message {
+			t.Errorf("expected %q, got %q", c.message, c.err.Error())
+		}
+	}
+
+	wrapped := newError("Send", 7, ErrWait)
+	if again := newError("Send", 8, wrapped); again != wrapped {
+		t.Errorf("an *Error was wrapped twice: %v", again)
+	}
+	if newError("Send", 7, nil) != nil {
+		t.Error("nil was wrapped")
+	}
+}
+
+func TestErrorProto(t *testing.T) {
+	for _, err := range []error{
+		newError("Send", 7, ErrLost),
+		newError("CreateSession", 7, ErrID),
+		newError("Send", 7, ErrWait),
+		managerError("Discard", ErrCall),
+		newError("ConfirmAnswer", 7, &util.SDPError{Check: util.CheckICE, Detail: "no ice-pwd"}),
+		newError("Send", 7, ErrFingerprint),
+		managerError("SetAuthenticator", fmt.Errorf("%w: empty token", ErrAuth)),
+	} {
+		pbErr := ToProto(err)
+		back := FromProto(pbErr)
+		if back.Error() != err.Error() {
+			t.Errorf("expected %q, got %q", err.Error(), back.Error())
+		}
+		var e *Error
+		if !errors.As(back, &e) || !errors.Is(back, e.Kind) || !errors.Is(err, e.Kind) {
+			t.Errorf("%v lost its kind through protobuf", err)
+		}
+	}
+	if ToProto(newError("Send", 7, ErrFingerprint)).GetErrFingerprint() == nil || ToProto(newError("Send", 7, ErrAuth)).GetErrAuth() == nil {
+		t.Error("expected ErrFingerprint and ErrAuth to have their own codes")
+	}
+	if id := ToProto(newError("Send", 7, ErrLost)).GetErrLost().GetID(); id != 7 {
+		t.Errorf("expected session 7 in ErrLost, got %d", id)
+	}
+	var e *Error
+	if !errors.As(FromProto(ToProto(newError("CreateSession", 9, ErrID))), &e) || e.SessionID != 9 || !e.HasSessionID {
+		t.Errorf("session ID of ErrID lost through protobuf: %+v", e)
+	}
+	if ToProto(nil) != nil || FromProto(nil) != nil {
+		t.Error("nil converted to an error")
+	}
+	if msg := FromProto(ToProto(errors.New("foreign"))).Error(); !strings.Contains(msg, "foreign") {
+		t.Errorf("unexpected message %q", msg)
+	}
+}
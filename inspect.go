@@ -0,0 +1,108 @@
+package sessionmgr
+
+import (
+	"github.com/pion/webrtc/v4"
+	"log/slog"
+	"sessionmgr/conf"
+	"sessionmgr/logs"
+	"sort"
+	"time"
+)
+
+// SessionInfo describe a session for inspection
+type SessionInfo struct {
+	SessionID       int32  `json:"SessionID"`
+	Role            string `json:"Role"`
+	ConnectionState string `json:"ConnectionState"`
+	ICEState        string `json:"ICEState"`
+	// DataChannelState is empty until the answer side received the channel
+	DataChannelState string    `json:"DataChannelState"`
+	Authenticated    bool      `json:"Authenticated"`
+	Created          time.Time `json:"Created"`
+	LastUsed         time.Time `json:"LastUsed"`
+}
+
+// Sessions describe every session by ID, it does not count as use of the sessions
+func (s *SessionManagerImpl) Sessions() (infos []SessionInfo, err error) {
+	span := s.tracing().Start(nil, "Sessions")
+	defer func() {
+		span.SetAttributes(slog.Int("sessions", len(infos)))
+		span.End(err)
+	}()
+	defer func() { err = managerError("Sessions", err) }()
+	if s.discarded.Load() {
+		return nil, ErrCall
+	}
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	infos = make([]SessionInfo, 0, len(s.sessionBook))
+	for SessionID, session := range s.sessionBook {
+		infos = append(infos, describe(SessionID, session))
+	}
+	sort.Slice(infos, func(i, j int) bool { return infos[i].SessionID < infos[j].SessionID })
+	return infos, nil
+}
+
+// Inspect describe one session, it does not count as use of the session
+func (s *SessionManagerImpl) Inspect(SessionID int32) (info SessionInfo, err error) {
+	span := s.startSpan("Inspect", SessionID)
+	defer func() { span.End(err) }()
+	defer wrapError(&err, "Inspect", SessionID)
+	if s.discarded.Load() {
+		return SessionInfo{}, ErrCall
+	}
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	session := s.sessionBook[SessionID]
+	if session == nil {
+		return SessionInfo{}, ErrLost
+	}
+	return describe(SessionID, session), nil
+}
+
+// SessionStats return the pion statistics of a session
+func (s *SessionManagerImpl) SessionStats(SessionID int32) (stats webrtc.StatsReport, err error) {
+	span := s.startSpan("SessionStats", SessionID)
+	defer func() { span.End(err) }()
+	defer wrapError(&err, "SessionStats", SessionID)
+	if s.discarded.Load() {
+		return nil, ErrCall
+	}
+	s.mu.Lock()
+	session := s.sessionBook[SessionID]
+	s.mu.Unlock()
+	if session == nil {
+		return nil, ErrLost
+	}
+	return session.Connection.GetStats(), nil
+}
+
+// Config return a copy of the current configuration, its secrets included
+func (s *SessionManagerImpl) Config() *conf.Configuration {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	config := *s.config
+	return &config
+}
+
+// Logger return the Logger of the manager, nil when lines are discarded
+func (s *SessionManagerImpl) Logger() *logs.Logger {
+	return s.logger()
+}
+
+// describe caller must hold mu
+func describe(SessionID int32, session *Session) SessionInfo {
+	info := SessionInfo{
+		SessionID:       SessionID,
+		Role:            session.Role,
+		ConnectionState: session.Connection.ConnectionState().String(),
+		ICEState:        session.Connection.ICEConnectionState().String(),
+		Authenticated:   session.Handshake == nil || session.Handshake.Passed(),
+		Created:         session.Created,
+		LastUsed:        session.LastUsed,
+	}
+	if session.DataCh != nil {
+		info.DataChannelState = session.DataCh.ReadyState().String()
+	}
+	return info
+}
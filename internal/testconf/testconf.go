@@ -0,0 +1,21 @@
+// Package testconf write the manager configurations of the tests
+package testconf
+
+import (
+	"os"
+	"path/filepath"
+	"testing"
+)
+
+// Base is the smallest configuration a manager starts from, without its closing brace
+const Base = `{"WebRTC":{},"CacheSize":100,"SessionLifeCycle":600`
+
+// Write write Base with extra merged into its top level object to a temporary conf.json and return its path,
+// a key of extra that Base already has replaces it
+func Write(t *testing.T, extra string) string {
+	path := filepath.Join(t.TempDir(), "conf.json")
+	if err := os.WriteFile(path, []byte(Base+extra+`}`), 0644); err != nil {
+		t.Fatal(err)
+	}
+	return path
+}
@@ -0,0 +1,23 @@
+// Package testmgr create the managers of the tests outside the sessionmgr package
+package testmgr
+
+import (
+	"sessionmgr"
+	"sessionmgr/internal/testconf"
+	"testing"
+)
+
+// New create a manager from testconf.Write(t, extra), it is discarded when the test ends
+func New(t *testing.T, extra string) *sessionmgr.SessionManagerImpl {
+	return Open(t, testconf.Write(t, extra))
+}
+
+// Open create a manager from the configuration at path, it is discarded when the test ends
+func Open(t *testing.T, path string) *sessionmgr.SessionManagerImpl {
+	mgr, err := sessionmgr.NewSessionManagerImpl(path)
+	if err != nil {
+		t.Fatal(err)
+	}
+	t.Cleanup(func() { _ = mgr.Discard() })
+	return mgr
+}
@@ -0,0 +1,104 @@
+package logs
+
+import (
+	"fmt"
+	"io"
+	"log/slog"
+	"os"
+	"strings"
+	"time"
+)
+
+// Config describe a Logger in conf.Configuration
+type Config struct {
+	// Level is trace, debug, info, warn, error or off
+	Level string `json:"Level"`
+	// Topics override Level per topic, e.g. {"ICE": "debug"}, pion logs to ICE, DTLS, SCTP and PC
+	Topics map[string]string `json:"Topics"`
+	// Format is text (default) or json
+	Format string `json:"Format"`
+	// Output is stderr (default), stdout or a file path
+	Output string `json:"Output"`
+	// Payloads log messages and descriptions in full, they are redacted by default
+	Payloads bool `json:"Payloads"`
+	// SessionDir is where each session gets its own file session-<ID>.log, empty keeps them in Output
+	SessionDir string `json:"SessionDir"`
+	// Rotate applies to an Output file and to the session files, MaxBackups and MaxAge also bound
+	// the files of ended sessions
+	Rotate RotateConfig `json:"Rotate"`
+}
+
+// RotateConfig is RotateOptions in conf.Configuration, zero values disable the limit
+type RotateConfig struct {
+	// MaxSize is in megabytes
+	MaxSize int64 `json:"MaxSize"`
+	// Interval is in seconds
+	Interval   int64 `json:"Interval"`
+	MaxBackups int   `json:"MaxBackups"`
+	// MaxAge is in seconds
+	MaxAge int64 `json:"MaxAge"`
+}
+
+// Options convert the config to RotateOptions
+func (c RotateConfig) Options() RotateOptions {
+	return RotateOptions{
+		MaxSize:    c.MaxSize << 20,
+		Interval:   time.Duration(c.Interval) * time.Second,
+		MaxBackups: c.MaxBackups,
+		MaxAge:     time.Duration(c.MaxAge) * time.Second,
+	}
+}
+
+// FromConfig create the Logger described by config, the levels in EnvVar take precedence
+func FromConfig(config *Config) (*Logger, error) {
+	opts := Options{
+		Level:      slog.LevelInfo,
+		Topics:     make(map[Topic]slog.Level),
+		Payloads:   config.Payloads,
+		SessionDir: config.SessionDir,
+		Rotate:     config.Rotate.Options(),
+	}
+	if config.Level != "" {
+		level, err := ParseLevel(config.Level)
+		if err != nil {
+			return nil, fmt.Errorf("log level: %w", err)
+		}
+		opts.Level = level
+	}
+	for topic, value := range config.Topics {
+		level, err := ParseLevel(value)
+		if err != nil {
+			return nil, fmt.Errorf("log level of %v: %w", topic, err)
+		}
+		opts.Topics[Topic(strings.ToUpper(topic))] = level
+	}
+	if err := parseLevels(os.Getenv(EnvVar), &opts); err != nil {
+		return nil, fmt.Errorf("%v: %w", EnvVar, err)
+	}
+
+	switch strings.ToLower(config.Format) {
+	case "", "text":
+	case "json":
+		opts.JSON = true
+	default:
+		return nil, fmt.Errorf("unknown log format %q", config.Format)
+	}
+
+	var w io.Writer
+	var closer io.Closer
+	switch config.Output {
+	case "", "stderr":
+		w = os.Stderr
+	case "stdout":
+		w = os.Stdout
+	default:
+		file, err := OpenRotating(config.Output, opts.Rotate)
+		if err != nil {
+			return nil, err
+		}
+		w, closer = file, file
+	}
+	l := New(w, opts)
+	l.closer = closer
+	return l, nil
+}
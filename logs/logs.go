@@ -0,0 +1,274 @@
+package logs
+
+import (
+	"context"
+	"fmt"
+	"io"
+	"log/slog"
+	"path/filepath"
+	"strings"
+	"sync"
+	"sync/atomic"
+)
+
+// Topic group the lines of one part of the manager, each topic has its own level
+type Topic string
+
+const (
+	CONFIG  Topic = "CONFIG"
+	READY   Topic = "READY"
+	SESSION Topic = "SESSION"
+	MANAGER Topic = "MANAGER"
+	ICE     Topic = "ICE"
+	ELSE    Topic = "ELSE"
+	// DTLS, SCTP and PC are the lines of pion, see PionFactory
+	DTLS Topic = "DTLS"
+	SCTP Topic = "SCTP"
+	PC   Topic = "PC"
+)
+
+// LevelOff is above every level, a topic set to it is silent
+const LevelOff = slog.Level(100)
+
+// EnvVar overrides the configured levels, e.g. SESSIONMGR_LOG=info,ICE=debug,SESSION=off
+const EnvVar = "SESSIONMGR_LOG"
+
+// Logger write leveled, structured lines tagged with their topic, a nil *Logger discards everything
+type Logger struct {
+	logger *slog.Logger
+	opts   Options
+	// levels are shared with the Loggers derived by With and Session
+	levels *levels
+	// closer is the file opened for Output or for the session, if any, it is not shared by With
+	closer io.Closer
+	// sessions are the files open in SessionDir, shared like levels
+	sessions *sessionFiles
+}
+
+// Options describe a Logger
+type Options struct {
+	// Level applies to topics missing from Topics
+	Level  slog.Level
+	Topics map[Topic]slog.Level
+	JSON   bool
+	// Payloads log messages and descriptions in full instead of their size
+	Payloads bool
+	// SessionDir is where Session writes each session to its own file, empty keeps them in w
+	SessionDir string
+	// Rotate applies to the session files, MaxBackups and MaxAge also bound the files of ended sessions
+	Rotate RotateOptions
+}
+
+// New create a Logger writing to w
+func New(w io.Writer, opts Options) *Logger {
+	topics := make(map[Topic]slog.Level, len(opts.Topics))
+	for topic, level := range opts.Topics {
+		topics[topic] = level
+	}
+	// thresholds live in levels, SetLevels changes them
+	opts.Topics = nil
+	return &Logger{
+		logger:   slog.New(newHandler(w, opts.JSON)),
+		opts:     opts,
+		levels:   &levels{level: opts.Level, topics: topics},
+		sessions: &sessionFiles{open: make(map[string]int)},
+	}
+}
+
+// levels are the thresholds of a Logger, they can change while it is used
+type levels struct {
+	mu     sync.RWMutex
+	level  slog.Level
+	topics map[Topic]slog.Level
+}
+
+func newHandler(w io.Writer, json bool) slog.Handler {
+	// topics are filtered by the Logger, the handler lets everything through
+	handlerOpts := &slog.HandlerOptions{Level: slog.Level(-100), ReplaceAttr: levelName}
+	if json {
+		return slog.NewJSONHandler(w, handlerOpts)
+	}
+	return slog.NewTextHandler(w, handlerOpts)
+}
+
+// levelName write LevelTrace as TRACE instead of DEBUG-4
+func levelName(groups []string, attr slog.Attr) slog.Attr {
+	if len(groups) == 0 && attr.Key == slog.LevelKey {
+		if level, ok := attr.Value.Any().(slog.Level); ok && level == LevelTrace {
+			attr.Value = slog.StringValue("TRACE")
+		}
+	}
+	return attr
+}
+
+// Close release the output file opened by FromConfig
+func (l *Logger) Close() error {
+	if l == nil || l.closer == nil {
+		return nil
+	}
+	return l.closer.Close()
+}
+
+var defaultLogger atomic.Pointer[Logger]
+
+// Default is used where no Logger was injected, it discards everything until SetDefault
+func Default() *Logger {
+	return defaultLogger.Load()
+}
+
+func SetDefault(l *Logger) {
+	defaultLogger.Store(l)
+}
+
+// Enabled tell whether a line of topic at level would be written
+func (l *Logger) Enabled(topic Topic, level slog.Level) bool {
+	if l == nil {
+		return false
+	}
+	l.levels.mu.RLock()
+	defer l.levels.mu.RUnlock()
+	threshold, ok := l.levels.topics[topic]
+	if !ok {
+		threshold = l.levels.level
+	}
+	return level >= threshold
+}
+
+func (l *Logger) Debug(topic Topic, msg string, args ...any) {
+	l.Log(topic, slog.LevelDebug, msg, args...)
+}
+
+func (l *Logger) Info(topic Topic, msg string, args ...any) {
+	l.Log(topic, slog.LevelInfo, msg, args...)
+}
+
+func (l *Logger) Warn(topic Topic, msg string, args ...any) {
+	l.Log(topic, slog.LevelWarn, msg, args...)
+}
+
+func (l *Logger) Error(topic Topic, msg string, args ...any) {
+	l.Log(topic, slog.LevelError, msg, args...)
+}
+
+// Log write msg with args as slog key-value pairs
+func (l *Logger) Log(topic Topic, level slog.Level, msg string, args ...any) {
+	if !l.Enabled(topic, level) {
+		return
+	}
+	l.logger.Log(context.Background(), level, msg, append([]any{slog.String("topic", string(topic))}, args...)...)
+}
+
+// With return a Logger adding args to every line, e.g. the session ID
+func (l *Logger) With(args ...any) *Logger {
+	if l == nil {
+		return nil
+	}
+	with := *l
+	with.logger = l.logger.With(args...)
+	with.closer = nil
+	return &with
+}
+
+// Session return the Logger of a session, its lines go to their own file when SessionDir is set
+// and the file is closed with Close, the files of ended sessions are pruned as Rotate says
+func (l *Logger) Session(SessionID int32) (*Logger, error) {
+	if l == nil || l.opts.SessionDir == "" {
+		return l.With("session", SessionID), nil
+	}
+	path := filepath.Join(l.opts.SessionDir, fmt.Sprintf("session-%d.log", SessionID))
+	l.sessions.acquire(path)
+	file, err := OpenRotating(path, l.opts.Rotate)
+	if err != nil {
+		l.sessions.release(path, l.opts)
+		return nil, err
+	}
+	closer := &sessionFile{RotatingFile: file, sessions: l.sessions, opts: l.opts}
+	session := &Logger{logger: slog.New(newHandler(file, l.opts.JSON)), opts: l.opts, levels: l.levels, closer: closer, sessions: l.sessions}
+	session.logger = session.logger.With("session", SessionID)
+	return session, nil
+}
+
+// Payload is an attribute holding data, only its size unless payloads are enabled
+func (l *Logger) Payload(key string, data []byte) slog.Attr {
+	if l != nil && l.opts.Payloads {
+		return slog.String(key, string(data))
+	}
+	return slog.String(key, fmt.Sprintf("[%d bytes redacted]", len(data)))
+}
+
+// SDP is an attribute holding a description, only its size unless payloads are enabled,
+// a description carries ICE credentials
+func (l *Logger) SDP(key string, sdp string) slog.Attr {
+	return l.Payload(key, []byte(sdp))
+}
+
+// ParseLevel read trace, debug, info, warn, error or off, case is ignored
+func ParseLevel(s string) (slog.Level, error) {
+	switch {
+	case strings.EqualFold(s, "off"):
+		return LevelOff, nil
+	case strings.EqualFold(s, "trace"):
+		return LevelTrace, nil
+	}
+	var level slog.Level
+	if err := level.UnmarshalText([]byte(s)); err != nil {
+		return 0, err
+	}
+	return level, nil
+}
+
+// Levels return the default level and the topics that override it
+func (l *Logger) Levels() (slog.Level, map[Topic]slog.Level) {
+	if l == nil {
+		return LevelOff, nil
+	}
+	l.levels.mu.RLock()
+	defer l.levels.mu.RUnlock()
+	topics := make(map[Topic]slog.Level, len(l.levels.topics))
+	for topic, level := range l.levels.topics {
+		topics[topic] = level
+	}
+	return l.levels.level, topics
+}
+
+// SetLevels apply a spec such as "info,ICE=debug,SESSION=off" to l and the Loggers derived from it,
+// topics missing from spec are unchanged
+func (l *Logger) SetLevels(spec string) error {
+	if l == nil {
+		return nil
+	}
+	level, topics := l.Levels()
+	opts := Options{Level: level, Topics: topics}
+	if err := parseLevels(spec, &opts); err != nil {
+		return err
+	}
+	l.levels.mu.Lock()
+	defer l.levels.mu.Unlock()
+	l.levels.level, l.levels.topics = opts.Level, opts.Topics
+	return nil
+}
+
+// parseLevels apply "level,TOPIC=level,..." to opts, a bare level is the default one
+func parseLevels(spec string, opts *Options) error {
+	for _, item := range strings.Split(spec, ",") {
+		item = strings.TrimSpace(item)
+		if item == "" {
+			continue
+		}
+		topic, value, found := strings.Cut(item, "=")
+		if !found {
+			level, err := ParseLevel(item)
+			if err != nil {
+				return err
+			}
+			opts.Level = level
+			continue
+		}
+		level, err := ParseLevel(value)
+		if err != nil {
+			return err
+		}
+		opts.Topics[Topic(strings.ToUpper(strings.TrimSpace(topic)))] = level
+	}
+	return nil
+}
@@ -0,0 +1,119 @@
+package logs
+
+import (
+	"bytes"
+	"encoding/json"
+	"log/slog"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+)
+
+func TestTopicLevels(t *testing.T) {
+	var buf bytes.Buffer
+	l := New(&buf, Options{Level: slog.LevelWarn, Topics: map[Topic]slog.Level{ICE: slog.LevelDebug, SESSION: LevelOff}})
+	l.Info(MANAGER, "hidden")
+	l.Warn(MANAGER, "manager warning")
+	l.Debug(ICE, "candidate")
+	l.Error(SESSION, "silenced")
+	out := buf.String()
+	for _, want := range []string{"manager warning", "candidate", "topic=ICE"} {
+		if !strings.Contains(out, want) {
+			t.Errorf("expected %q in %q", want, out)
+		}
+	}
+	for _, unwanted := range []string{"hidden", "silenced"} {
+		if strings.Contains(out, unwanted) {
+			t.Errorf("unexpected %q in %q", unwanted, out)
+		}
+	}
+}
+
+func TestNilLogger(t *testing.T) {
+	var l *Logger
+	l.With("session", 1).Error(MANAGER, "discarded")
+	if l.Enabled(MANAGER, slog.LevelError) {
+		t.Error("expected a nil logger to be disabled")
+	}
+	if err := l.Close(); err != nil {
+		t.Error(err)
+	}
+}
+
+func TestPayloadRedaction(t *testing.T) {
+	var buf bytes.Buffer
+	l := New(&buf, Options{Level: slog.LevelDebug})
+	l.Debug(SESSION, "receive", l.Payload("data", []byte("secret message")), l.SDP("sdp", "v=0"))
+	out := buf.String()
+	if strings.Contains(out, "secret message") || strings.Contains(out, "v=0") {
+		t.Errorf("payload leaked in %q", out)
+	}
+	if !strings.Contains(out, "[14 bytes redacted]") {
+		t.Errorf("expected the payload size in %q", out)
+	}
+
+	buf.Reset()
+	l = New(&buf, Options{Level: slog.LevelDebug, Payloads: true})
+	l.Debug(SESSION, "receive", l.Payload("data", []byte("secret message")))
+	if !strings.Contains(buf.String(), "secret message") {
+		t.Errorf("expected the payload in %q", buf.String())
+	}
+}
+
+func TestFromConfig(t *testing.T) {
+	t.Setenv(EnvVar, "debug,SESSION=off")
+	path := filepath.Join(t.TempDir(), "log.json")
+	l, err := FromConfig(&Config{Level: "error", Topics: map[string]string{"ice": "warn"}, Format: "json", Output: path})
+	if err != nil {
+		t.Fatal(err)
+	}
+	l.With("session", int32(7)).Debug(MANAGER, "create session")
+	l.Info(ICE, "hidden")
+	l.Warn(ICE, "turn auth rejected")
+	l.Error(SESSION, "silenced")
+	if err = l.Close(); err != nil {
+		t.Fatal(err)
+	}
+
+	data, err := os.ReadFile(path)
+	if err != nil {
+		t.Fatal(err)
+	}
+	lines := strings.Split(strings.TrimSpace(string(data)), "\n")
+	if len(lines) != 2 {
+		t.Fatalf("expected 2 lines, got %q", data)
+	}
+	var line map[string]any
+	if err = json.Unmarshal([]byte(lines[0]), &line); err != nil {
+		t.Fatal(err)
+	}
+	if line["msg"] != "create session" || line["topic"] != "MANAGER" || line["session"] != float64(7) {
+		t.Errorf("unexpected line %v", line)
+	}
+
+	for _, config := range []*Config{{Level: "loud"}, {Format: "xml"}, {Topics: map[string]string{"ICE": "verbose"}}} {
+		if _, err = FromConfig(config); err == nil {
+			t.Errorf("expected %+v to be rejected", config)
+		}
+	}
+}
+
+func TestPionFactory(t *testing.T) {
+	var buf bytes.Buffer
+	l := New(&buf, Options{Level: slog.LevelInfo, Topics: map[Topic]slog.Level{DTLS: LevelTrace}}).With("session", 3)
+	factory := l.PionFactory()
+	factory.NewLogger("ice").Debugf("hidden %d", 1)
+	factory.NewLogger("ice").Warnf("checks failed for %v", "pair")
+	factory.NewLogger("dtls").Trace("flight 1")
+	factory.NewLogger("ortc").Info("to pc")
+	out := buf.String()
+	for _, want := range []string{"checks failed for pair", "scope=ice topic=ICE", "level=TRACE", "flight 1", "scope=ortc topic=PC", "session=3"} {
+		if !strings.Contains(out, want) {
+			t.Errorf("expected %q in %q", want, out)
+		}
+	}
+	if strings.Contains(out, "hidden") {
+		t.Errorf("unexpected debug line in %q", out)
+	}
+}
@@ -0,0 +1,76 @@
+package logs
+
+import (
+	"fmt"
+	"github.com/pion/logging"
+	"log/slog"
+)
+
+// LevelTrace is below debug, pion logs packets and connectivity checks at it
+const LevelTrace = slog.LevelDebug - 4
+
+// pionTopics map the scopes of pion loggers to topics, other scopes go to PC
+var pionTopics = map[string]Topic{
+	"ice":         ICE,
+	"turn":        ICE,
+	"turnc":       ICE,
+	"stun":        ICE,
+	"mdns":        ICE,
+	"dtls":        DTLS,
+	"sctp":        SCTP,
+	"datachannel": SCTP,
+}
+
+// PionFactory forward the loggers pion creates to l, each line has the pion scope
+func (l *Logger) PionFactory() logging.LoggerFactory {
+	return pionFactory{l}
+}
+
+type pionFactory struct {
+	l *Logger
+}
+
+func (f pionFactory) NewLogger(scope string) logging.LeveledLogger {
+	topic, ok := pionTopics[scope]
+	if !ok {
+		topic = PC
+	}
+	return &pionLogger{l: f.l.With("scope", scope), topic: topic}
+}
+
+// pionLogger implement logging.LeveledLogger, messages are formatted only when enabled
+type pionLogger struct {
+	l     *Logger
+	topic Topic
+}
+
+func (p *pionLogger) log(level slog.Level, msg string) {
+	p.l.Log(p.topic, level, msg)
+}
+
+func (p *pionLogger) logf(level slog.Level, format string, args ...interface{}) {
+	if p.l.Enabled(p.topic, level) {
+		p.l.Log(p.topic, level, fmt.Sprintf(format, args...))
+	}
+}
+
+func (p *pionLogger) Trace(msg string) { p.log(LevelTrace, msg) }
+func (p *pionLogger) Tracef(format string, args ...interface{}) {
+	p.logf(LevelTrace, format, args...)
+}
+func (p *pionLogger) Debug(msg string) { p.log(slog.LevelDebug, msg) }
+func (p *pionLogger) Debugf(format string, args ...interface{}) {
+	p.logf(slog.LevelDebug, format, args...)
+}
+func (p *pionLogger) Info(msg string) { p.log(slog.LevelInfo, msg) }
+func (p *pionLogger) Infof(format string, args ...interface{}) {
+	p.logf(slog.LevelInfo, format, args...)
+}
+func (p *pionLogger) Warn(msg string) { p.log(slog.LevelWarn, msg) }
+func (p *pionLogger) Warnf(format string, args ...interface{}) {
+	p.logf(slog.LevelWarn, format, args...)
+}
+func (p *pionLogger) Error(msg string) { p.log(slog.LevelError, msg) }
+func (p *pionLogger) Errorf(format string, args ...interface{}) {
+	p.logf(slog.LevelError, format, args...)
+}
@@ -0,0 +1,232 @@
+package logs
+
+import (
+	"os"
+	"path/filepath"
+	"sort"
+	"strings"
+	"sync"
+	"time"
+)
+
+// backupLayout is the time suffix of rotated files, it sorts in chronological order
+const backupLayout = "2006-01-02T15-04-05.000"
+
+// RotateOptions describe when a RotatingFile starts a new file and how many old ones it keeps,
+// zero values disable the limit
+type RotateOptions struct {
+	// MaxSize is the size in bytes after which the file is rotated
+	MaxSize int64
+	// Interval is the age after which the file is rotated
+	Interval time.Duration
+	// MaxBackups is how many rotated files are kept
+	MaxBackups int
+	// MaxAge is how long rotated files are kept
+	MaxAge time.Duration
+}
+
+// RotatingFile append to path, the full file is renamed to name-<time>.ext and a new one is started
+type RotatingFile struct {
+	path string
+	opts RotateOptions
+	now  func() time.Time
+
+	mu     sync.Mutex
+	file   *os.File
+	size   int64
+	opened time.Time
+}
+
+// OpenRotating open path for appending, its directory is created if needed
+func OpenRotating(path string, opts RotateOptions) (*RotatingFile, error) {
+	f := &RotatingFile{path: path, opts: opts, now: time.Now}
+	if err := os.MkdirAll(filepath.Dir(path), 0755); err != nil {
+		return nil, err
+	}
+	if err := f.open(); err != nil {
+		return nil, err
+	}
+	return f, nil
+}
+
+// open start appending to path, caller must hold mu
+func (f *RotatingFile) open() error {
+	file, err := os.OpenFile(f.path, os.O_WRONLY|os.O_CREATE|os.O_APPEND, 0644)
+	if err != nil {
+		return err
+	}
+	info, err := file.Stat()
+	if err != nil {
+		_ = file.Close()
+		return err
+	}
+	f.file, f.size, f.opened = file, info.Size(), f.now()
+	return nil
+}
+
+func (f *RotatingFile) Write(p []byte) (int, error) {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	if f.file == nil {
+		return 0, os.ErrClosed
+	}
+	if f.due(int64(len(p))) {
+		if err := f.rotate(); err != nil {
+			return 0, err
+		}
+	}
+	n, err := f.file.Write(p)
+	f.size += int64(n)
+	return n, err
+}
+
+// due tell whether writing n more bytes needs a new file, caller must hold mu
+func (f *RotatingFile) due(n int64) bool {
+	if f.size == 0 {
+		return false
+	}
+	if f.opts.MaxSize > 0 && f.size+n > f.opts.MaxSize {
+		return true
+	}
+	return f.opts.Interval > 0 && f.now().Sub(f.opened) >= f.opts.Interval
+}
+
+// rotate rename the current file and remove the backups beyond retention, caller must hold mu
+func (f *RotatingFile) rotate() error {
+	if err := f.file.Close(); err != nil {
+		return err
+	}
+	f.file = nil
+	ext := filepath.Ext(f.path)
+	backup := strings.TrimSuffix(f.path, ext) + "-" + f.now().UTC().Format(backupLayout) + ext
+	if err := os.Rename(f.path, backup); err != nil {
+		return err
+	}
+	if err := f.open(); err != nil {
+		return err
+	}
+	f.prune()
+	return nil
+}
+
+// prune remove the oldest backups, failures are left for the next rotation, caller must hold mu
+func (f *RotatingFile) prune() {
+	ext := filepath.Ext(f.path)
+	prefix := strings.TrimSuffix(f.path, ext) + "-"
+	matches, err := filepath.Glob(prefix + "*" + ext)
+	if err != nil {
+		return
+	}
+	// files of other names may share the prefix, only rotated ones are pruned
+	backups := make([]string, 0, len(matches))
+	for _, match := range matches {
+		if _, err := time.Parse(backupLayout, strings.TrimSuffix(strings.TrimPrefix(match, prefix), ext)); err == nil {
+			backups = append(backups, match)
+		}
+	}
+	// newest first
+	sort.Sort(sort.Reverse(sort.StringSlice(backups)))
+	for i, backup := range backups {
+		expired := f.opts.MaxBackups > 0 && i >= f.opts.MaxBackups
+		if !expired && f.opts.MaxAge > 0 {
+			if info, err := os.Stat(backup); err == nil {
+				expired = f.now().Sub(info.ModTime()) > f.opts.MaxAge
+			}
+		}
+		if expired {
+			_ = os.Remove(backup)
+		}
+	}
+}
+
+func (f *RotatingFile) Close() error {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	if f.file == nil {
+		return nil
+	}
+	err := f.file.Close()
+	f.file = nil
+	return err
+}
+
+// sessionFiles count the open files of SessionDir, only the files of ended sessions are pruned
+type sessionFiles struct {
+	mu   sync.Mutex
+	open map[string]int
+}
+
+func (s *sessionFiles) acquire(path string) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.open[path]++
+}
+
+// release forget path and prune the directory
+func (s *sessionFiles) release(path string, opts Options) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	if s.open[path]--; s.open[path] <= 0 {
+		delete(s.open, path)
+	}
+	s.prune(opts)
+}
+
+// prune remove the files of ended sessions, their rotated files included, beyond MaxBackups and MaxAge,
+// newest first, caller must hold mu
+func (s *sessionFiles) prune(opts Options) {
+	if opts.Rotate.MaxBackups <= 0 && opts.Rotate.MaxAge <= 0 {
+		return
+	}
+	matches, err := filepath.Glob(filepath.Join(opts.SessionDir, "session-*.log"))
+	if err != nil {
+		return
+	}
+	type ended struct {
+		path    string
+		modTime time.Time
+	}
+	files := make([]ended, 0, len(matches))
+	for _, match := range matches {
+		if s.isOpen(match) {
+			continue
+		}
+		if info, err := os.Stat(match); err == nil {
+			files = append(files, ended{match, info.ModTime()})
+		}
+	}
+	sort.Slice(files, func(i, j int) bool { return files[i].modTime.After(files[j].modTime) })
+	for i, file := range files {
+		expired := opts.Rotate.MaxBackups > 0 && i >= opts.Rotate.MaxBackups
+		if !expired && opts.Rotate.MaxAge > 0 {
+			expired = time.Since(file.modTime) > opts.Rotate.MaxAge
+		}
+		if expired {
+			_ = os.Remove(file.path)
+		}
+	}
+}
+
+// isOpen tell whether path is the file of an open session or one of its rotated files, caller must hold mu
+func (s *sessionFiles) isOpen(path string) bool {
+	for open := range s.open {
+		if path == open || strings.HasPrefix(path, strings.TrimSuffix(open, ".log")+"-") {
+			return true
+		}
+	}
+	return false
+}
+
+// sessionFile release its session when it is closed
+type sessionFile struct {
+	*RotatingFile
+	sessions *sessionFiles
+	opts     Options
+	once     sync.Once
+}
+
+func (f *sessionFile) Close() error {
+	err := f.RotatingFile.Close()
+	f.once.Do(func() { f.sessions.release(f.RotatingFile.path, f.opts) })
+	return err
+}
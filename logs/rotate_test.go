@@ -0,0 +1,167 @@
+package logs
+
+import (
+	"fmt"
+	"io"
+	"log/slog"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+	"time"
+)
+
+// fakeClock advance by a second on every call so rotated files get distinct names
+type fakeClock struct {
+	now time.Time
+}
+
+func (c *fakeClock) Now() time.Time {
+	c.now = c.now.Add(time.Second)
+	return c.now
+}
+
+func openTestFile(t *testing.T, opts RotateOptions) (*RotatingFile, *fakeClock, string) {
+	dir := t.TempDir()
+	f, err := OpenRotating(filepath.Join(dir, "sessionmgr.log"), opts)
+	if err != nil {
+		t.Fatal(err)
+	}
+	clock := &fakeClock{now: time.Now()}
+	f.now = clock.Now
+	t.Cleanup(func() { _ = f.Close() })
+	return f, clock, dir
+}
+
+func backups(t *testing.T, dir string) []string {
+	matches, err := filepath.Glob(filepath.Join(dir, "sessionmgr-*.log"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	return matches
+}
+
+func TestRotateBySize(t *testing.T) {
+	f, _, dir := openTestFile(t, RotateOptions{MaxSize: 10, MaxBackups: 2})
+	for _, line := range []string{"first\n", "second\n", "third\n", "fourth\n", "fifth\n"} {
+		if _, err := f.Write([]byte(line)); err != nil {
+			t.Fatal(err)
+		}
+	}
+	if got := backups(t, dir); len(got) != 2 {
+		t.Fatalf("expected 2 backups, got %v", got)
+	}
+	data, err := os.ReadFile(filepath.Join(dir, "sessionmgr.log"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if string(data) != "fifth\n" {
+		t.Errorf("unexpected current file %q", data)
+	}
+}
+
+func TestRotateByInterval(t *testing.T) {
+	f, clock, dir := openTestFile(t, RotateOptions{Interval: time.Hour, MaxAge: 2 * time.Hour})
+	if _, err := f.Write([]byte("old\n")); err != nil {
+		t.Fatal(err)
+	}
+	if got := backups(t, dir); len(got) != 0 {
+		t.Fatalf("rotated too early: %v", got)
+	}
+	clock.now = clock.now.Add(time.Hour)
+	if _, err := f.Write([]byte("new\n")); err != nil {
+		t.Fatal(err)
+	}
+	got := backups(t, dir)
+	if len(got) != 1 {
+		t.Fatalf("expected 1 backup, got %v", got)
+	}
+
+	// the backup outlives MaxAge and is removed at the next rotation
+	clock.now = clock.now.Add(3 * time.Hour)
+	if _, err := f.Write([]byte("newer\n")); err != nil {
+		t.Fatal(err)
+	}
+	for _, backup := range backups(t, dir) {
+		if backup == got[0] {
+			t.Errorf("expected %v to be removed", backup)
+		}
+	}
+}
+
+func TestSessionFiles(t *testing.T) {
+	dir := t.TempDir()
+	var main strings.Builder
+	l := New(&main, Options{Level: slog.LevelInfo, SessionDir: dir})
+	session, err := l.Session(4)
+	if err != nil {
+		t.Fatal(err)
+	}
+	session.Info(SESSION, "connection state changed")
+	l.Info(MANAGER, "create session", "session", 4)
+	if err = session.Close(); err != nil {
+		t.Fatal(err)
+	}
+
+	data, err := os.ReadFile(filepath.Join(dir, "session-4.log"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !strings.Contains(string(data), "connection state changed") || !strings.Contains(string(data), "session=4") {
+		t.Errorf("unexpected session file %q", data)
+	}
+	if strings.Contains(main.String(), "connection state changed") || !strings.Contains(main.String(), "create session") {
+		t.Errorf("unexpected main output %q", main.String())
+	}
+	// closing a session does not close the Logger it came from
+	if err = l.With("session", 5).Close(); err != nil {
+		t.Error(err)
+	}
+}
+
+func TestSessionFilesPruned(t *testing.T) {
+	dir := t.TempDir()
+	l := New(io.Discard, Options{Level: slog.LevelInfo, SessionDir: dir, Rotate: RotateOptions{MaxBackups: 1}})
+	old := time.Now().Add(-time.Hour)
+	for _, SessionID := range []int32{1, 2} {
+		session, err := l.Session(SessionID)
+		if err != nil {
+			t.Fatal(err)
+		}
+		session.Info(SESSION, "connected")
+		if err = session.Close(); err != nil {
+			t.Fatal(err)
+		}
+		path := filepath.Join(dir, fmt.Sprintf("session-%d.log", SessionID))
+		_ = os.Chtimes(path, old, old)
+		old = old.Add(time.Minute)
+	}
+	// a backup of the open session is not counted
+	if err := os.WriteFile(filepath.Join(dir, "session-3-2020-01-01T00-00-00.000.log"), nil, 0644); err != nil {
+		t.Fatal(err)
+	}
+	open, err := l.Session(3)
+	if err != nil {
+		t.Fatal(err)
+	}
+	ended, err := l.Session(4)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if err = ended.Close(); err != nil {
+		t.Fatal(err)
+	}
+
+	for name, kept := range map[string]bool{
+		"session-1.log":                         false,
+		"session-2.log":                         false,
+		"session-3.log":                         true,
+		"session-3-2020-01-01T00-00-00.000.log": true,
+		"session-4.log":                         true,
+	} {
+		if _, err := os.Stat(filepath.Join(dir, name)); (err == nil) != kept {
+			t.Errorf("%v: expected kept %v, got %v", name, kept, err)
+		}
+	}
+	_ = open.Close()
+}
@@ -0,0 +1,92 @@
+package sessionmgr
+
+import (
+	"errors"
+	"net/http"
+	"sessionmgr/metrics"
+)
+
+// roles of a session, the side that called CreateSession offers
+const (
+	roleOfferer  = "offerer"
+	roleAnswerer = "answerer"
+)
+
+// reasons a session is dropped
+const (
+	dropRequested = "requested"
+	dropInactive  = "inactive"
+	dropLost      = "lost"
+	dropRejected  = "rejected"
+	dropDiscarded = "discarded"
+)
+
+// managerMetrics is what MetricsHandler exposes
+type managerMetrics struct {
+	registry         *metrics.Registry
+	sessions         *metrics.Gauge
+	created          *metrics.Counter
+	dropped          *metrics.Counter
+	sentBytes        *metrics.Counter
+	sentMessages     *metrics.Counter
+	receivedBytes    *metrics.Counter
+	receivedMessages *metrics.Counter
+	readyDepth       *metrics.Gauge
+	readyFull        *metrics.Counter
+	gathering        *metrics.Histogram
+	connect          *metrics.Histogram
+	sendErrors       *metrics.Counter
+}
+
+func newManagerMetrics(s *SessionManagerImpl) *managerMetrics {
+	r := metrics.NewRegistry()
+	m := &managerMetrics{
+		registry:         r,
+		sessions:         r.Gauge("sessionmgr_sessions", "Sessions by role and connection state.", "role", "state"),
+		created:          r.Counter("sessionmgr_sessions_created_total", "Sessions created by CreateSession or JoinSession.", "role"),
+		dropped:          r.Counter("sessionmgr_sessions_dropped_total", "Sessions dropped by reason.", "reason"),
+		sentBytes:        r.Counter("sessionmgr_sent_bytes_total", "Bytes sent to peers."),
+		sentMessages:     r.Counter("sessionmgr_sent_messages_total", "Messages sent to peers."),
+		receivedBytes:    r.Counter("sessionmgr_received_bytes_total", "Bytes received from peers."),
+		receivedMessages: r.Counter("sessionmgr_received_messages_total", "Messages received from peers."),
+		readyDepth:       r.Gauge("sessionmgr_ready_queue_depth", "Received messages waiting for Ready."),
+		readyFull:        r.Counter("sessionmgr_ready_queue_full_total", "Received messages that waited for room in a full ready queue."),
+		gathering:        r.Histogram("sessionmgr_ice_gathering_seconds", "Time from session creation to complete ICE gathering.", metrics.DefaultBuckets, "role"),
+		connect:          r.Histogram("sessionmgr_connect_seconds", "Time from session creation to a connected peer.", metrics.DefaultBuckets, "role"),
+		sendErrors:       r.Counter("sessionmgr_send_errors_total", "Send failures by error.", "error"),
+	}
+	r.OnCollect(func() {
+		m.readyDepth.Set(float64(len(s.readyChannel)))
+		s.mu.Lock()
+		defer s.mu.Unlock()
+		m.sessions.Reset()
+		for _, session := range s.sessionBook {
+			m.sessions.Add(1, session.Role, session.Connection.ConnectionState().String())
+		}
+	})
+	return m
+}
+
+// MetricsHandler serve the metrics of the manager in the Prometheus text format, e.g. on /metrics
+func (s *SessionManagerImpl) MetricsHandler() http.Handler {
+	return s.metrics.registry
+}
+
+// errorLabel name the sentinel of err for sendErrors
+func errorLabel(err error) string {
+	labels := map[error]string{
+		ErrID:          "id",
+		ErrCall:        "call",
+		ErrLost:        "lost",
+		ErrWait:        "wait",
+		ErrSdp:         "sdp",
+		ErrFingerprint: "fingerprint",
+		ErrAuth:        "auth",
+	}
+	for _, sentinel := range sentinels {
+		if errors.Is(err, sentinel) {
+			return labels[sentinel]
+		}
+	}
+	return "other"
+}
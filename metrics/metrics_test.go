@@ -0,0 +1,58 @@
+package metrics
+
+import (
+	"net/http/httptest"
+	"strings"
+	"testing"
+)
+
+func TestWrite(t *testing.T) {
+	r := NewRegistry()
+	created := r.Counter("test_created_total", "Sessions created.", "role")
+	depth := r.Gauge("test_depth", "Queue depth.")
+	latency := r.Histogram("test_seconds", "Latency.", []float64{0.1, 1})
+	r.OnCollect(func() { depth.Set(3) })
+
+	created.Inc("offerer")
+	created.Add(2, `a"b`)
+	created.Add(-1, "offerer")
+	latency.Observe(0.05)
+	latency.Observe(0.1)
+	latency.Observe(5)
+
+	var out strings.Builder
+	if err := r.Write(&out); err != nil {
+		t.Fatal(err)
+	}
+	want := `# HELP test_created_total Sessions created.
+# TYPE test_created_total counter
+test_created_total{role="a\"b"} 2
+test_created_total{role="offerer"} 1
+# HELP test_depth Queue depth.
+# TYPE test_depth gauge
+test_depth 3
+# HELP test_seconds Latency.
+# TYPE test_seconds histogram
+test_seconds_bucket{le="0.1"} 2
+test_seconds_bucket{le="1"} 2
+test_seconds_bucket{le="+Inf"} 3
+test_seconds_sum 5.15
+test_seconds_count 3
+`
+	if out.String() != want {
+		t.Errorf("got\n%s\nwant\n%s", out.String(), want)
+	}
+}
+
+func TestServeHTTP(t *testing.T) {
+	r := NewRegistry()
+	r.Gauge("test_up", "Up.").Set(1)
+	rec := httptest.NewRecorder()
+	r.ServeHTTP(rec, httptest.NewRequest("GET", "/metrics", nil))
+	if rec.Header().Get("Content-Type") != ContentType {
+		t.Errorf("unexpected content type %q", rec.Header().Get("Content-Type"))
+	}
+	if !strings.Contains(rec.Body.String(), "test_up 1\n") {
+		t.Errorf("unexpected body %q", rec.Body.String())
+	}
+}
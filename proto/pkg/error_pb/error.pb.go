@@ -25,12 +25,14 @@ type Error struct {
 	sizeCache     protoimpl.SizeCache
 	unknownFields protoimpl.UnknownFields
 
-	Message string   `protobuf:"bytes,1,opt,name=Message,proto3" json:"Message,omitempty"`
-	ErrId   *ErrID   `protobuf:"bytes,2,opt,name=err_id,json=errId,proto3" json:"err_id,omitempty"`
-	ErrCall *ErrCall `protobuf:"bytes,3,opt,name=err_call,json=errCall,proto3" json:"err_call,omitempty"`
-	ErrLost *ErrLost `protobuf:"bytes,4,opt,name=err_lost,json=errLost,proto3" json:"err_lost,omitempty"`
-	ErrWait *ErrWait `protobuf:"bytes,5,opt,name=err_wait,json=errWait,proto3" json:"err_wait,omitempty"`
-	ErrSdp  *ErrSdp  `protobuf:"bytes,6,opt,name=err_sdp,json=errSdp,proto3" json:"err_sdp,omitempty"`
+	Message        string          `protobuf:"bytes,1,opt,name=Message,proto3" json:"Message,omitempty"`
+	ErrId          *ErrID          `protobuf:"bytes,2,opt,name=err_id,json=errId,proto3" json:"err_id,omitempty"`
+	ErrCall        *ErrCall        `protobuf:"bytes,3,opt,name=err_call,json=errCall,proto3" json:"err_call,omitempty"`
+	ErrLost        *ErrLost        `protobuf:"bytes,4,opt,name=err_lost,json=errLost,proto3" json:"err_lost,omitempty"`
+	ErrWait        *ErrWait        `protobuf:"bytes,5,opt,name=err_wait,json=errWait,proto3" json:"err_wait,omitempty"`
+	ErrSdp         *ErrSdp         `protobuf:"bytes,6,opt,name=err_sdp,json=errSdp,proto3" json:"err_sdp,omitempty"`
+	ErrFingerprint *ErrFingerprint `protobuf:"bytes,7,opt,name=err_fingerprint,json=errFingerprint,proto3" json:"err_fingerprint,omitempty"`
+	ErrAuth        *ErrAuth        `protobuf:"bytes,8,opt,name=err_auth,json=errAuth,proto3" json:"err_auth,omitempty"`
 }
 
 func (x *Error) Reset() {
@@ -107,6 +109,20 @@ func (x *Error) GetErrSdp() *ErrSdp {
 	return nil
 }
 
+func (x *Error) GetErrFingerprint() *ErrFingerprint {
+	if x != nil {
+		return x.ErrFingerprint
+	}
+	return nil
+}
+
+func (x *Error) GetErrAuth() *ErrAuth {
+	if x != nil {
+		return x.ErrAuth
+	}
+	return nil
+}
+
 type ErrID struct {
 	state         protoimpl.MessageState
 	sizeCache     protoimpl.SizeCache
@@ -315,10 +331,86 @@ func (*ErrSdp) Descriptor() ([]byte, []int) {
 	return file_error_proto_rawDescGZIP(), []int{5}
 }
 
+type ErrFingerprint struct {
+	state         protoimpl.MessageState
+	sizeCache     protoimpl.SizeCache
+	unknownFields protoimpl.UnknownFields
+}
+
+func (x *ErrFingerprint) Reset() {
+	*x = ErrFingerprint{}
+	if protoimpl.UnsafeEnabled {
+		mi := &file_error_proto_msgTypes[6]
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		ms.StoreMessageInfo(mi)
+	}
+}
+
+func (x *ErrFingerprint) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*ErrFingerprint) ProtoMessage() {}
+
+func (x *ErrFingerprint) ProtoReflect() protoreflect.Message {
+	mi := &file_error_proto_msgTypes[6]
+	if protoimpl.UnsafeEnabled && x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use ErrFingerprint.ProtoReflect.Descriptor instead.
+func (*ErrFingerprint) Descriptor() ([]byte, []int) {
+	return file_error_proto_rawDescGZIP(), []int{6}
+}
+
+type ErrAuth struct {
+	state         protoimpl.MessageState
+	sizeCache     protoimpl.SizeCache
+	unknownFields protoimpl.UnknownFields
+}
+
+func (x *ErrAuth) Reset() {
+	*x = ErrAuth{}
+	if protoimpl.UnsafeEnabled {
+		mi := &file_error_proto_msgTypes[7]
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		ms.StoreMessageInfo(mi)
+	}
+}
+
+func (x *ErrAuth) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*ErrAuth) ProtoMessage() {}
+
+func (x *ErrAuth) ProtoReflect() protoreflect.Message {
+	mi := &file_error_proto_msgTypes[7]
+	if protoimpl.UnsafeEnabled && x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use ErrAuth.ProtoReflect.Descriptor instead.
+func (*ErrAuth) Descriptor() ([]byte, []int) {
+	return file_error_proto_rawDescGZIP(), []int{7}
+}
+
 var File_error_proto protoreflect.FileDescriptor
 
 var file_error_proto_rawDesc = []byte{
-	0x0a, 0x0b, 0x65, 0x72, 0x72, 0x6f, 0x72, 0x2e, 0x70, 0x72, 0x6f, 0x74, 0x6f, 0x22, 0xd1, 0x01,
+	0x0a, 0x0b, 0x65, 0x72, 0x72, 0x6f, 0x72, 0x2e, 0x70, 0x72, 0x6f, 0x74, 0x6f, 0x22, 0xb0, 0x02,
 	0x0a, 0x05, 0x45, 0x72, 0x72, 0x6f, 0x72, 0x12, 0x18, 0x0a, 0x07, 0x4d, 0x65, 0x73, 0x73, 0x61,
 	0x67, 0x65, 0x18, 0x01, 0x20, 0x01, 0x28, 0x09, 0x52, 0x07, 0x4d, 0x65, 0x73, 0x73, 0x61, 0x67,
 	0x65, 0x12, 0x1d, 0x0a, 0x06, 0x65, 0x72, 0x72, 0x5f, 0x69, 0x64, 0x18, 0x02, 0x20, 0x01, 0x28,
@@ -332,14 +424,22 @@ var file_error_proto_rawDesc = []byte{
 	0x72, 0x72, 0x57, 0x61, 0x69, 0x74, 0x52, 0x07, 0x65, 0x72, 0x72, 0x57, 0x61, 0x69, 0x74, 0x12,
 	0x20, 0x0a, 0x07, 0x65, 0x72, 0x72, 0x5f, 0x73, 0x64, 0x70, 0x18, 0x06, 0x20, 0x01, 0x28, 0x0b,
 	0x32, 0x07, 0x2e, 0x45, 0x72, 0x72, 0x53, 0x64, 0x70, 0x52, 0x06, 0x65, 0x72, 0x72, 0x53, 0x64,
-	0x70, 0x22, 0x17, 0x0a, 0x05, 0x45, 0x72, 0x72, 0x49, 0x44, 0x12, 0x0e, 0x0a, 0x02, 0x49, 0x44,
-	0x18, 0x01, 0x20, 0x01, 0x28, 0x05, 0x52, 0x02, 0x49, 0x44, 0x22, 0x09, 0x0a, 0x07, 0x45, 0x72,
-	0x72, 0x43, 0x61, 0x6c, 0x6c, 0x22, 0x19, 0x0a, 0x07, 0x45, 0x72, 0x72, 0x4c, 0x6f, 0x73, 0x74,
-	0x12, 0x0e, 0x0a, 0x02, 0x49, 0x44, 0x18, 0x01, 0x20, 0x01, 0x28, 0x05, 0x52, 0x02, 0x49, 0x44,
-	0x22, 0x09, 0x0a, 0x07, 0x45, 0x72, 0x72, 0x57, 0x61, 0x69, 0x74, 0x22, 0x08, 0x0a, 0x06, 0x45,
-	0x72, 0x72, 0x53, 0x64, 0x70, 0x42, 0x1f, 0x5a, 0x1d, 0x73, 0x65, 0x73, 0x73, 0x69, 0x6f, 0x6e,
-	0x6d, 0x67, 0x72, 0x2f, 0x70, 0x72, 0x6f, 0x74, 0x6f, 0x2f, 0x70, 0x6b, 0x67, 0x2f, 0x65, 0x72,
-	0x72, 0x6f, 0x72, 0x5f, 0x70, 0x62, 0x62, 0x06, 0x70, 0x72, 0x6f, 0x74, 0x6f, 0x33,
+	0x70, 0x12, 0x38, 0x0a, 0x0f, 0x65, 0x72, 0x72, 0x5f, 0x66, 0x69, 0x6e, 0x67, 0x65, 0x72, 0x70,
+	0x72, 0x69, 0x6e, 0x74, 0x18, 0x07, 0x20, 0x01, 0x28, 0x0b, 0x32, 0x0f, 0x2e, 0x45, 0x72, 0x72,
+	0x46, 0x69, 0x6e, 0x67, 0x65, 0x72, 0x70, 0x72, 0x69, 0x6e, 0x74, 0x52, 0x0e, 0x65, 0x72, 0x72,
+	0x46, 0x69, 0x6e, 0x67, 0x65, 0x72, 0x70, 0x72, 0x69, 0x6e, 0x74, 0x12, 0x23, 0x0a, 0x08, 0x65,
+	0x72, 0x72, 0x5f, 0x61, 0x75, 0x74, 0x68, 0x18, 0x08, 0x20, 0x01, 0x28, 0x0b, 0x32, 0x08, 0x2e,
+	0x45, 0x72, 0x72, 0x41, 0x75, 0x74, 0x68, 0x52, 0x07, 0x65, 0x72, 0x72, 0x41, 0x75, 0x74, 0x68,
+	0x22, 0x17, 0x0a, 0x05, 0x45, 0x72, 0x72, 0x49, 0x44, 0x12, 0x0e, 0x0a, 0x02, 0x49, 0x44, 0x18,
+	0x01, 0x20, 0x01, 0x28, 0x05, 0x52, 0x02, 0x49, 0x44, 0x22, 0x09, 0x0a, 0x07, 0x45, 0x72, 0x72,
+	0x43, 0x61, 0x6c, 0x6c, 0x22, 0x19, 0x0a, 0x07, 0x45, 0x72, 0x72, 0x4c, 0x6f, 0x73, 0x74, 0x12,
+	0x0e, 0x0a, 0x02, 0x49, 0x44, 0x18, 0x01, 0x20, 0x01, 0x28, 0x05, 0x52, 0x02, 0x49, 0x44, 0x22,
+	0x09, 0x0a, 0x07, 0x45, 0x72, 0x72, 0x57, 0x61, 0x69, 0x74, 0x22, 0x08, 0x0a, 0x06, 0x45, 0x72,
+	0x72, 0x53, 0x64, 0x70, 0x22, 0x10, 0x0a, 0x0e, 0x45, 0x72, 0x72, 0x46, 0x69, 0x6e, 0x67, 0x65,
+	0x72, 0x70, 0x72, 0x69, 0x6e, 0x74, 0x22, 0x09, 0x0a, 0x07, 0x45, 0x72, 0x72, 0x41, 0x75, 0x74,
+	0x68, 0x42, 0x1f, 0x5a, 0x1d, 0x73, 0x65, 0x73, 0x73, 0x69, 0x6f, 0x6e, 0x6d, 0x67, 0x72, 0x2f,
+	0x70, 0x72, 0x6f, 0x74, 0x6f, 0x2f, 0x70, 0x6b, 0x67, 0x2f, 0x65, 0x72, 0x72, 0x6f, 0x72, 0x5f,
+	0x70, 0x62, 0x62, 0x06, 0x70, 0x72, 0x6f, 0x74, 0x6f, 0x33,
 }
 
 var (
@@ -354,14 +454,16 @@ func file_error_proto_rawDescGZIP() []byte {
 	return file_error_proto_rawDescData
 }
 
-var file_error_proto_msgTypes = make([]protoimpl.MessageInfo, 6)
+var file_error_proto_msgTypes = make([]protoimpl.MessageInfo, 8)
 var file_error_proto_goTypes = []any{
-	(*Error)(nil),   // 0: Error
-	(*ErrID)(nil),   // 1: ErrID
-	(*ErrCall)(nil), // 2: ErrCall
-	(*ErrLost)(nil), // 3: ErrLost
-	(*ErrWait)(nil), // 4: ErrWait
-	(*ErrSdp)(nil),  // 5: ErrSdp
+	(*Error)(nil),          // 0: Error
+	(*ErrID)(nil),          // 1: ErrID
+	(*ErrCall)(nil),        // 2: ErrCall
+	(*ErrLost)(nil),        // 3: ErrLost
+	(*ErrWait)(nil),        // 4: ErrWait
+	(*ErrSdp)(nil),         // 5: ErrSdp
+	(*ErrFingerprint)(nil), // 6: ErrFingerprint
+	(*ErrAuth)(nil),        // 7: ErrAuth
 }
 var file_error_proto_depIdxs = []int32{
 	1, // 0: Error.err_id:type_name -> ErrID
@@ -369,11 +471,13 @@ var file_error_proto_depIdxs = []int32{
 	3, // 2: Error.err_lost:type_name -> ErrLost
 	4, // 3: Error.err_wait:type_name -> ErrWait
 	5, // 4: Error.err_sdp:type_name -> ErrSdp
-	5, // [5:5] is the sub-list for method output_type
-	5, // [5:5] is the sub-list for method input_type
-	5, // [5:5] is the sub-list for extension type_name
-	5, // [5:5] is the sub-list for extension extendee
-	0, // [0:5] is the sub-list for field type_name
+	6, // 5: Error.err_fingerprint:type_name -> ErrFingerprint
+	7, // 6: Error.err_auth:type_name -> ErrAuth
+	7, // [7:7] is the sub-list for method output_type
+	7, // [7:7] is the sub-list for method input_type
+	7, // [7:7] is the sub-list for extension type_name
+	7, // [7:7] is the sub-list for extension extendee
+	0, // [0:7] is the sub-list for field type_name
 }
 
 func init() { file_error_proto_init() }
@@ -454,6 +558,30 @@ func file_error_proto_init() {
 				return nil
 			}
 		}
+		file_error_proto_msgTypes[6].Exporter = func(v any, i int) any {
+			switch v := v.(*ErrFingerprint); i {
+			case 0:
+				return &v.state
+			case 1:
+				return &v.sizeCache
+			case 2:
+				return &v.unknownFields
+			default:
+				return nil
+			}
+		}
+		file_error_proto_msgTypes[7].Exporter = func(v any, i int) any {
+			switch v := v.(*ErrAuth); i {
+			case 0:
+				return &v.state
+			case 1:
+				return &v.sizeCache
+			case 2:
+				return &v.unknownFields
+			default:
+				return nil
+			}
+		}
 	}
 	type x struct{}
 	out := protoimpl.TypeBuilder{
@@ -461,7 +589,7 @@ func file_error_proto_init() {
 			GoPackagePath: reflect.TypeOf(x{}).PkgPath(),
 			RawDescriptor: file_error_proto_rawDesc,
 			NumEnums:      0,
-			NumMessages:   6,
+			NumMessages:   8,
 			NumExtensions: 0,
 			NumServices:   0,
 		},
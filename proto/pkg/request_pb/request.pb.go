@@ -0,0 +1,902 @@
+// Code generated by protoc-gen-go. DO NOT EDIT.
+// versions:
+// 	protoc-gen-go v1.34.2
+// 	protoc        v5.28.3
+// source: request.proto
+
+package request_pb
+
+import (
+	protoreflect "google.golang.org/protobuf/reflect/protoreflect"
+	protoimpl "google.golang.org/protobuf/runtime/protoimpl"
+	reflect "reflect"
+	sync "sync"
+)
+
+const (
+	// Verify that this generated code is sufficiently up-to-date.
+	_ = protoimpl.EnforceVersion(20 - protoimpl.MinVersion)
+	// Verify that runtime/protoimpl is sufficiently up-to-date.
+	_ = protoimpl.EnforceVersion(protoimpl.MaxVersion - 20)
+)
+
+type Request struct {
+	state         protoimpl.MessageState
+	sizeCache     protoimpl.SizeCache
+	unknownFields protoimpl.UnknownFields
+
+	Id            uint64                `protobuf:"varint,1,opt,name=id,proto3" json:"id,omitempty"`
+	CreateSession *RequestCreateSession `protobuf:"bytes,2,opt,name=create_session,json=createSession,proto3" json:"create_session,omitempty"`
+	Offer         *RequestOffer         `protobuf:"bytes,3,opt,name=offer,proto3" json:"offer,omitempty"`
+	JoinSession   *RequestJoinSession   `protobuf:"bytes,4,opt,name=join_session,json=joinSession,proto3" json:"join_session,omitempty"`
+	Answer        *RequestAnswer        `protobuf:"bytes,5,opt,name=answer,proto3" json:"answer,omitempty"`
+	ConfirmAnswer *RequestConfirmAnswer `protobuf:"bytes,6,opt,name=confirm_answer,json=confirmAnswer,proto3" json:"confirm_answer,omitempty"`
+	Send          *RequestSend          `protobuf:"bytes,7,opt,name=send,proto3" json:"send,omitempty"`
+	Ready         *RequestReady         `protobuf:"bytes,8,opt,name=ready,proto3" json:"ready,omitempty"`
+	DropSession   *RequestDropSession   `protobuf:"bytes,9,opt,name=drop_session,json=dropSession,proto3" json:"drop_session,omitempty"`
+	ReloadConfig  *RequestReloadConfig  `protobuf:"bytes,10,opt,name=reload_config,json=reloadConfig,proto3" json:"reload_config,omitempty"`
+	Discard       *RequestDiscard       `protobuf:"bytes,11,opt,name=discard,proto3" json:"discard,omitempty"`
+}
+
+func (x *Request) Reset() {
+	*x = Request{}
+	if protoimpl.UnsafeEnabled {
+		mi := &file_request_proto_msgTypes[0]
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		ms.StoreMessageInfo(mi)
+	}
+}
+
+func (x *Request) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*Request) ProtoMessage() {}
+
+func (x *Request) ProtoReflect() protoreflect.Message {
+	mi := &file_request_proto_msgTypes[0]
+	if protoimpl.UnsafeEnabled && x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use Request.ProtoReflect.Descriptor instead.
+func (*Request) Descriptor() ([]byte, []int) {
+	return file_request_proto_rawDescGZIP(), []int{0}
+}
+
+func (x *Request) GetId() uint64 {
+	if x != nil {
+		return x.Id
+	}
+	return 0
+}
+
+func (x *Request) GetCreateSession() *RequestCreateSession {
+	if x != nil {
+		return x.CreateSession
+	}
+	return nil
+}
+
+func (x *Request) GetOffer() *RequestOffer {
+	if x != nil {
+		return x.Offer
+	}
+	return nil
+}
+
+func (x *Request) GetJoinSession() *RequestJoinSession {
+	if x != nil {
+		return x.JoinSession
+	}
+	return nil
+}
+
+func (x *Request) GetAnswer() *RequestAnswer {
+	if x != nil {
+		return x.Answer
+	}
+	return nil
+}
+
+func (x *Request) GetConfirmAnswer() *RequestConfirmAnswer {
+	if x != nil {
+		return x.ConfirmAnswer
+	}
+	return nil
+}
+
+func (x *Request) GetSend() *RequestSend {
+	if x != nil {
+		return x.Send
+	}
+	return nil
+}
+
+func (x *Request) GetReady() *RequestReady {
+	if x != nil {
+		return x.Ready
+	}
+	return nil
+}
+
+func (x *Request) GetDropSession() *RequestDropSession {
+	if x != nil {
+		return x.DropSession
+	}
+	return nil
+}
+
+func (x *Request) GetReloadConfig() *RequestReloadConfig {
+	if x != nil {
+		return x.ReloadConfig
+	}
+	return nil
+}
+
+func (x *Request) GetDiscard() *RequestDiscard {
+	if x != nil {
+		return x.Discard
+	}
+	return nil
+}
+
+type RequestCreateSession struct {
+	state         protoimpl.MessageState
+	sizeCache     protoimpl.SizeCache
+	unknownFields protoimpl.UnknownFields
+
+	SessionId int32 `protobuf:"varint,1,opt,name=session_id,json=sessionId,proto3" json:"session_id,omitempty"`
+}
+
+func (x *RequestCreateSession) Reset() {
+	*x = RequestCreateSession{}
+	if protoimpl.UnsafeEnabled {
+		mi := &file_request_proto_msgTypes[1]
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		ms.StoreMessageInfo(mi)
+	}
+}
+
+func (x *RequestCreateSession) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*RequestCreateSession) ProtoMessage() {}
+
+func (x *RequestCreateSession) ProtoReflect() protoreflect.Message {
+	mi := &file_request_proto_msgTypes[1]
+	if protoimpl.UnsafeEnabled && x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use RequestCreateSession.ProtoReflect.Descriptor instead.
+func (*RequestCreateSession) Descriptor() ([]byte, []int) {
+	return file_request_proto_rawDescGZIP(), []int{1}
+}
+
+func (x *RequestCreateSession) GetSessionId() int32 {
+	if x != nil {
+		return x.SessionId
+	}
+	return 0
+}
+
+type RequestOffer struct {
+	state         protoimpl.MessageState
+	sizeCache     protoimpl.SizeCache
+	unknownFields protoimpl.UnknownFields
+
+	SessionId int32 `protobuf:"varint,1,opt,name=session_id,json=sessionId,proto3" json:"session_id,omitempty"`
+}
+
+func (x *RequestOffer) Reset() {
+	*x = RequestOffer{}
+	if protoimpl.UnsafeEnabled {
+		mi := &file_request_proto_msgTypes[2]
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		ms.StoreMessageInfo(mi)
+	}
+}
+
+func (x *RequestOffer) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*RequestOffer) ProtoMessage() {}
+
+func (x *RequestOffer) ProtoReflect() protoreflect.Message {
+	mi := &file_request_proto_msgTypes[2]
+	if protoimpl.UnsafeEnabled && x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use RequestOffer.ProtoReflect.Descriptor instead.
+func (*RequestOffer) Descriptor() ([]byte, []int) {
+	return file_request_proto_rawDescGZIP(), []int{2}
+}
+
+func (x *RequestOffer) GetSessionId() int32 {
+	if x != nil {
+		return x.SessionId
+	}
+	return 0
+}
+
+type RequestJoinSession struct {
+	state         protoimpl.MessageState
+	sizeCache     protoimpl.SizeCache
+	unknownFields protoimpl.UnknownFields
+
+	SessionId   int32  `protobuf:"varint,1,opt,name=session_id,json=sessionId,proto3" json:"session_id,omitempty"`
+	OfferBase64 string `protobuf:"bytes,2,opt,name=offer_base64,json=offerBase64,proto3" json:"offer_base64,omitempty"`
+}
+
+func (x *RequestJoinSession) Reset() {
+	*x = RequestJoinSession{}
+	if protoimpl.UnsafeEnabled {
+		mi := &file_request_proto_msgTypes[3]
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		ms.StoreMessageInfo(mi)
+	}
+}
+
+func (x *RequestJoinSession) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*RequestJoinSession) ProtoMessage() {}
+
+func (x *RequestJoinSession) ProtoReflect() protoreflect.Message {
+	mi := &file_request_proto_msgTypes[3]
+	if protoimpl.UnsafeEnabled && x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use RequestJoinSession.ProtoReflect.Descriptor instead.
+func (*RequestJoinSession) Descriptor() ([]byte, []int) {
+	return file_request_proto_rawDescGZIP(), []int{3}
+}
+
+func (x *RequestJoinSession) GetSessionId() int32 {
+	if x != nil {
+		return x.SessionId
+	}
+	return 0
+}
+
+func (x *RequestJoinSession) GetOfferBase64() string {
+	if x != nil {
+		return x.OfferBase64
+	}
+	return ""
+}
+
+type RequestAnswer struct {
+	state         protoimpl.MessageState
+	sizeCache     protoimpl.SizeCache
+	unknownFields protoimpl.UnknownFields
+
+	SessionId int32 `protobuf:"varint,1,opt,name=session_id,json=sessionId,proto3" json:"session_id,omitempty"`
+}
+
+func (x *RequestAnswer) Reset() {
+	*x = RequestAnswer{}
+	if protoimpl.UnsafeEnabled {
+		mi := &file_request_proto_msgTypes[4]
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		ms.StoreMessageInfo(mi)
+	}
+}
+
+func (x *RequestAnswer) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*RequestAnswer) ProtoMessage() {}
+
+func (x *RequestAnswer) ProtoReflect() protoreflect.Message {
+	mi := &file_request_proto_msgTypes[4]
+	if protoimpl.UnsafeEnabled && x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use RequestAnswer.ProtoReflect.Descriptor instead.
+func (*RequestAnswer) Descriptor() ([]byte, []int) {
+	return file_request_proto_rawDescGZIP(), []int{4}
+}
+
+func (x *RequestAnswer) GetSessionId() int32 {
+	if x != nil {
+		return x.SessionId
+	}
+	return 0
+}
+
+type RequestConfirmAnswer struct {
+	state         protoimpl.MessageState
+	sizeCache     protoimpl.SizeCache
+	unknownFields protoimpl.UnknownFields
+
+	SessionId    int32  `protobuf:"varint,1,opt,name=session_id,json=sessionId,proto3" json:"session_id,omitempty"`
+	AnswerBase64 string `protobuf:"bytes,2,opt,name=answer_base64,json=answerBase64,proto3" json:"answer_base64,omitempty"`
+}
+
+func (x *RequestConfirmAnswer) Reset() {
+	*x = RequestConfirmAnswer{}
+	if protoimpl.UnsafeEnabled {
+		mi := &file_request_proto_msgTypes[5]
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		ms.StoreMessageInfo(mi)
+	}
+}
+
+func (x *RequestConfirmAnswer) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*RequestConfirmAnswer) ProtoMessage() {}
+
+func (x *RequestConfirmAnswer) ProtoReflect() protoreflect.Message {
+	mi := &file_request_proto_msgTypes[5]
+	if protoimpl.UnsafeEnabled && x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use RequestConfirmAnswer.ProtoReflect.Descriptor instead.
+func (*RequestConfirmAnswer) Descriptor() ([]byte, []int) {
+	return file_request_proto_rawDescGZIP(), []int{5}
+}
+
+func (x *RequestConfirmAnswer) GetSessionId() int32 {
+	if x != nil {
+		return x.SessionId
+	}
+	return 0
+}
+
+func (x *RequestConfirmAnswer) GetAnswerBase64() string {
+	if x != nil {
+		return x.AnswerBase64
+	}
+	return ""
+}
+
+type RequestSend struct {
+	state         protoimpl.MessageState
+	sizeCache     protoimpl.SizeCache
+	unknownFields protoimpl.UnknownFields
+
+	SessionId int32  `protobuf:"varint,1,opt,name=session_id,json=sessionId,proto3" json:"session_id,omitempty"`
+	DAtA      []byte `protobuf:"bytes,2,opt,name=dAtA,proto3" json:"dAtA,omitempty"`
+}
+
+func (x *RequestSend) Reset() {
+	*x = RequestSend{}
+	if protoimpl.UnsafeEnabled {
+		mi := &file_request_proto_msgTypes[6]
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		ms.StoreMessageInfo(mi)
+	}
+}
+
+func (x *RequestSend) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*RequestSend) ProtoMessage() {}
+
+func (x *RequestSend) ProtoReflect() protoreflect.Message {
+	mi := &file_request_proto_msgTypes[6]
+	if protoimpl.UnsafeEnabled && x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use RequestSend.ProtoReflect.Descriptor instead.
+func (*RequestSend) Descriptor() ([]byte, []int) {
+	return file_request_proto_rawDescGZIP(), []int{6}
+}
+
+func (x *RequestSend) GetSessionId() int32 {
+	if x != nil {
+		return x.SessionId
+	}
+	return 0
+}
+
+func (x *RequestSend) GetDAtA() []byte {
+	if x != nil {
+		return x.DAtA
+	}
+	return nil
+}
+
+type RequestReady struct {
+	state         protoimpl.MessageState
+	sizeCache     protoimpl.SizeCache
+	unknownFields protoimpl.UnknownFields
+}
+
+func (x *RequestReady) Reset() {
+	*x = RequestReady{}
+	if protoimpl.UnsafeEnabled {
+		mi := &file_request_proto_msgTypes[7]
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		ms.StoreMessageInfo(mi)
+	}
+}
+
+func (x *RequestReady) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*RequestReady) ProtoMessage() {}
+
+func (x *RequestReady) ProtoReflect() protoreflect.Message {
+	mi := &file_request_proto_msgTypes[7]
+	if protoimpl.UnsafeEnabled && x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use RequestReady.ProtoReflect.Descriptor instead.
+func (*RequestReady) Descriptor() ([]byte, []int) {
+	return file_request_proto_rawDescGZIP(), []int{7}
+}
+
+type RequestDropSession struct {
+	state         protoimpl.MessageState
+	sizeCache     protoimpl.SizeCache
+	unknownFields protoimpl.UnknownFields
+
+	SessionId int32 `protobuf:"varint,1,opt,name=session_id,json=sessionId,proto3" json:"session_id,omitempty"`
+}
+
+func (x *RequestDropSession) Reset() {
+	*x = RequestDropSession{}
+	if protoimpl.UnsafeEnabled {
+		mi := &file_request_proto_msgTypes[8]
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		ms.StoreMessageInfo(mi)
+	}
+}
+
+func (x *RequestDropSession) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*RequestDropSession) ProtoMessage() {}
+
+func (x *RequestDropSession) ProtoReflect() protoreflect.Message {
+	mi := &file_request_proto_msgTypes[8]
+	if protoimpl.UnsafeEnabled && x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use RequestDropSession.ProtoReflect.Descriptor instead.
+func (*RequestDropSession) Descriptor() ([]byte, []int) {
+	return file_request_proto_rawDescGZIP(), []int{8}
+}
+
+func (x *RequestDropSession) GetSessionId() int32 {
+	if x != nil {
+		return x.SessionId
+	}
+	return 0
+}
+
+type RequestReloadConfig struct {
+	state         protoimpl.MessageState
+	sizeCache     protoimpl.SizeCache
+	unknownFields protoimpl.UnknownFields
+
+	ConfPath string `protobuf:"bytes,1,opt,name=conf_path,json=confPath,proto3" json:"conf_path,omitempty"`
+}
+
+func (x *RequestReloadConfig) Reset() {
+	*x = RequestReloadConfig{}
+	if protoimpl.UnsafeEnabled {
+		mi := &file_request_proto_msgTypes[9]
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		ms.StoreMessageInfo(mi)
+	}
+}
+
+func (x *RequestReloadConfig) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*RequestReloadConfig) ProtoMessage() {}
+
+func (x *RequestReloadConfig) ProtoReflect() protoreflect.Message {
+	mi := &file_request_proto_msgTypes[9]
+	if protoimpl.UnsafeEnabled && x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use RequestReloadConfig.ProtoReflect.Descriptor instead.
+func (*RequestReloadConfig) Descriptor() ([]byte, []int) {
+	return file_request_proto_rawDescGZIP(), []int{9}
+}
+
+func (x *RequestReloadConfig) GetConfPath() string {
+	if x != nil {
+		return x.ConfPath
+	}
+	return ""
+}
+
+type RequestDiscard struct {
+	state         protoimpl.MessageState
+	sizeCache     protoimpl.SizeCache
+	unknownFields protoimpl.UnknownFields
+}
+
+func (x *RequestDiscard) Reset() {
+	*x = RequestDiscard{}
+	if protoimpl.UnsafeEnabled {
+		mi := &file_request_proto_msgTypes[10]
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		ms.StoreMessageInfo(mi)
+	}
+}
+
+func (x *RequestDiscard) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*RequestDiscard) ProtoMessage() {}
+
+func (x *RequestDiscard) ProtoReflect() protoreflect.Message {
+	mi := &file_request_proto_msgTypes[10]
+	if protoimpl.UnsafeEnabled && x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use RequestDiscard.ProtoReflect.Descriptor instead.
+func (*RequestDiscard) Descriptor() ([]byte, []int) {
+	return file_request_proto_rawDescGZIP(), []int{10}
+}
+
+var File_request_proto protoreflect.FileDescriptor
+
+var file_request_proto_rawDesc = []byte{
+	0x0a, 0x0d, 0x72, 0x65, 0x71, 0x75, 0x65, 0x73, 0x74, 0x2e, 0x70, 0x72, 0x6f, 0x74, 0x6f, 0x22,
+	0xff, 0x03, 0x0a, 0x07, 0x52, 0x65, 0x71, 0x75, 0x65, 0x73, 0x74, 0x12, 0x0e, 0x0a, 0x02, 0x69,
+	0x64, 0x18, 0x01, 0x20, 0x01, 0x28, 0x04, 0x52, 0x02, 0x69, 0x64, 0x12, 0x3c, 0x0a, 0x0e, 0x63,
+	0x72, 0x65, 0x61, 0x74, 0x65, 0x5f, 0x73, 0x65, 0x73, 0x73, 0x69, 0x6f, 0x6e, 0x18, 0x02, 0x20,
+	0x01, 0x28, 0x0b, 0x32, 0x15, 0x2e, 0x52, 0x65, 0x71, 0x75, 0x65, 0x73, 0x74, 0x43, 0x72, 0x65,
+	0x61, 0x74, 0x65, 0x53, 0x65, 0x73, 0x73, 0x69, 0x6f, 0x6e, 0x52, 0x0d, 0x63, 0x72, 0x65, 0x61,
+	0x74, 0x65, 0x53, 0x65, 0x73, 0x73, 0x69, 0x6f, 0x6e, 0x12, 0x23, 0x0a, 0x05, 0x6f, 0x66, 0x66,
+	0x65, 0x72, 0x18, 0x03, 0x20, 0x01, 0x28, 0x0b, 0x32, 0x0d, 0x2e, 0x52, 0x65, 0x71, 0x75, 0x65,
+	0x73, 0x74, 0x4f, 0x66, 0x66, 0x65, 0x72, 0x52, 0x05, 0x6f, 0x66, 0x66, 0x65, 0x72, 0x12, 0x36,
+	0x0a, 0x0c, 0x6a, 0x6f, 0x69, 0x6e, 0x5f, 0x73, 0x65, 0x73, 0x73, 0x69, 0x6f, 0x6e, 0x18, 0x04,
+	0x20, 0x01, 0x28, 0x0b, 0x32, 0x13, 0x2e, 0x52, 0x65, 0x71, 0x75, 0x65, 0x73, 0x74, 0x4a, 0x6f,
+	0x69, 0x6e, 0x53, 0x65, 0x73, 0x73, 0x69, 0x6f, 0x6e, 0x52, 0x0b, 0x6a, 0x6f, 0x69, 0x6e, 0x53,
+	0x65, 0x73, 0x73, 0x69, 0x6f, 0x6e, 0x12, 0x26, 0x0a, 0x06, 0x61, 0x6e, 0x73, 0x77, 0x65, 0x72,
+	0x18, 0x05, 0x20, 0x01, 0x28, 0x0b, 0x32, 0x0e, 0x2e, 0x52, 0x65, 0x71, 0x75, 0x65, 0x73, 0x74,
+	0x41, 0x6e, 0x73, 0x77, 0x65, 0x72, 0x52, 0x06, 0x61, 0x6e, 0x73, 0x77, 0x65, 0x72, 0x12, 0x3c,
+	0x0a, 0x0e, 0x63, 0x6f, 0x6e, 0x66, 0x69, 0x72, 0x6d, 0x5f, 0x61, 0x6e, 0x73, 0x77, 0x65, 0x72,
+	0x18, 0x06, 0x20, 0x01, 0x28, 0x0b, 0x32, 0x15, 0x2e, 0x52, 0x65, 0x71, 0x75, 0x65, 0x73, 0x74,
+	0x43, 0x6f, 0x6e, 0x66, 0x69, 0x72, 0x6d, 0x41, 0x6e, 0x73, 0x77, 0x65, 0x72, 0x52, 0x0d, 0x63,
+	0x6f, 0x6e, 0x66, 0x69, 0x72, 0x6d, 0x41, 0x6e, 0x73, 0x77, 0x65, 0x72, 0x12, 0x20, 0x0a, 0x04,
+	0x73, 0x65, 0x6e, 0x64, 0x18, 0x07, 0x20, 0x01, 0x28, 0x0b, 0x32, 0x0c, 0x2e, 0x52, 0x65, 0x71,
+	0x75, 0x65, 0x73, 0x74, 0x53, 0x65, 0x6e, 0x64, 0x52, 0x04, 0x73, 0x65, 0x6e, 0x64, 0x12, 0x23,
+	0x0a, 0x05, 0x72, 0x65, 0x61, 0x64, 0x79, 0x18, 0x08, 0x20, 0x01, 0x28, 0x0b, 0x32, 0x0d, 0x2e,
+	0x52, 0x65, 0x71, 0x75, 0x65, 0x73, 0x74, 0x52, 0x65, 0x61, 0x64, 0x79, 0x52, 0x05, 0x72, 0x65,
+	0x61, 0x64, 0x79, 0x12, 0x36, 0x0a, 0x0c, 0x64, 0x72, 0x6f, 0x70, 0x5f, 0x73, 0x65, 0x73, 0x73,
+	0x69, 0x6f, 0x6e, 0x18, 0x09, 0x20, 0x01, 0x28, 0x0b, 0x32, 0x13, 0x2e, 0x52, 0x65, 0x71, 0x75,
+	0x65, 0x73, 0x74, 0x44, 0x72, 0x6f, 0x70, 0x53, 0x65, 0x73, 0x73, 0x69, 0x6f, 0x6e, 0x52, 0x0b,
+	0x64, 0x72, 0x6f, 0x70, 0x53, 0x65, 0x73, 0x73, 0x69, 0x6f, 0x6e, 0x12, 0x39, 0x0a, 0x0d, 0x72,
+	0x65, 0x6c, 0x6f, 0x61, 0x64, 0x5f, 0x63, 0x6f, 0x6e, 0x66, 0x69, 0x67, 0x18, 0x0a, 0x20, 0x01,
+	0x28, 0x0b, 0x32, 0x14, 0x2e, 0x52, 0x65, 0x71, 0x75, 0x65, 0x73, 0x74, 0x52, 0x65, 0x6c, 0x6f,
+	0x61, 0x64, 0x43, 0x6f, 0x6e, 0x66, 0x69, 0x67, 0x52, 0x0c, 0x72, 0x65, 0x6c, 0x6f, 0x61, 0x64,
+	0x43, 0x6f, 0x6e, 0x66, 0x69, 0x67, 0x12, 0x29, 0x0a, 0x07, 0x64, 0x69, 0x73, 0x63, 0x61, 0x72,
+	0x64, 0x18, 0x0b, 0x20, 0x01, 0x28, 0x0b, 0x32, 0x0f, 0x2e, 0x52, 0x65, 0x71, 0x75, 0x65, 0x73,
+	0x74, 0x44, 0x69, 0x73, 0x63, 0x61, 0x72, 0x64, 0x52, 0x07, 0x64, 0x69, 0x73, 0x63, 0x61, 0x72,
+	0x64, 0x22, 0x35, 0x0a, 0x14, 0x52, 0x65, 0x71, 0x75, 0x65, 0x73, 0x74, 0x43, 0x72, 0x65, 0x61,
+	0x74, 0x65, 0x53, 0x65, 0x73, 0x73, 0x69, 0x6f, 0x6e, 0x12, 0x1d, 0x0a, 0x0a, 0x73, 0x65, 0x73,
+	0x73, 0x69, 0x6f, 0x6e, 0x5f, 0x69, 0x64, 0x18, 0x01, 0x20, 0x01, 0x28, 0x05, 0x52, 0x09, 0x73,
+	0x65, 0x73, 0x73, 0x69, 0x6f, 0x6e, 0x49, 0x64, 0x22, 0x2d, 0x0a, 0x0c, 0x52, 0x65, 0x71, 0x75,
+	0x65, 0x73, 0x74, 0x4f, 0x66, 0x66, 0x65, 0x72, 0x12, 0x1d, 0x0a, 0x0a, 0x73, 0x65, 0x73, 0x73,
+	0x69, 0x6f, 0x6e, 0x5f, 0x69, 0x64, 0x18, 0x01, 0x20, 0x01, 0x28, 0x05, 0x52, 0x09, 0x73, 0x65,
+	0x73, 0x73, 0x69, 0x6f, 0x6e, 0x49, 0x64, 0x22, 0x56, 0x0a, 0x12, 0x52, 0x65, 0x71, 0x75, 0x65,
+	0x73, 0x74, 0x4a, 0x6f, 0x69, 0x6e, 0x53, 0x65, 0x73, 0x73, 0x69, 0x6f, 0x6e, 0x12, 0x1d, 0x0a,
+	0x0a, 0x73, 0x65, 0x73, 0x73, 0x69, 0x6f, 0x6e, 0x5f, 0x69, 0x64, 0x18, 0x01, 0x20, 0x01, 0x28,
+	0x05, 0x52, 0x09, 0x73, 0x65, 0x73, 0x73, 0x69, 0x6f, 0x6e, 0x49, 0x64, 0x12, 0x21, 0x0a, 0x0c,
+	0x6f, 0x66, 0x66, 0x65, 0x72, 0x5f, 0x62, 0x61, 0x73, 0x65, 0x36, 0x34, 0x18, 0x02, 0x20, 0x01,
+	0x28, 0x09, 0x52, 0x0b, 0x6f, 0x66, 0x66, 0x65, 0x72, 0x42, 0x61, 0x73, 0x65, 0x36, 0x34, 0x22,
+	0x2e, 0x0a, 0x0d, 0x52, 0x65, 0x71, 0x75, 0x65, 0x73, 0x74, 0x41, 0x6e, 0x73, 0x77, 0x65, 0x72,
+	0x12, 0x1d, 0x0a, 0x0a, 0x73, 0x65, 0x73, 0x73, 0x69, 0x6f, 0x6e, 0x5f, 0x69, 0x64, 0x18, 0x01,
+	0x20, 0x01, 0x28, 0x05, 0x52, 0x09, 0x73, 0x65, 0x73, 0x73, 0x69, 0x6f, 0x6e, 0x49, 0x64, 0x22,
+	0x5a, 0x0a, 0x14, 0x52, 0x65, 0x71, 0x75, 0x65, 0x73, 0x74, 0x43, 0x6f, 0x6e, 0x66, 0x69, 0x72,
+	0x6d, 0x41, 0x6e, 0x73, 0x77, 0x65, 0x72, 0x12, 0x1d, 0x0a, 0x0a, 0x73, 0x65, 0x73, 0x73, 0x69,
+	0x6f, 0x6e, 0x5f, 0x69, 0x64, 0x18, 0x01, 0x20, 0x01, 0x28, 0x05, 0x52, 0x09, 0x73, 0x65, 0x73,
+	0x73, 0x69, 0x6f, 0x6e, 0x49, 0x64, 0x12, 0x23, 0x0a, 0x0d, 0x61, 0x6e, 0x73, 0x77, 0x65, 0x72,
+	0x5f, 0x62, 0x61, 0x73, 0x65, 0x36, 0x34, 0x18, 0x02, 0x20, 0x01, 0x28, 0x09, 0x52, 0x0c, 0x61,
+	0x6e, 0x73, 0x77, 0x65, 0x72, 0x42, 0x61, 0x73, 0x65, 0x36, 0x34, 0x22, 0x40, 0x0a, 0x0b, 0x52,
+	0x65, 0x71, 0x75, 0x65, 0x73, 0x74, 0x53, 0x65, 0x6e, 0x64, 0x12, 0x1d, 0x0a, 0x0a, 0x73, 0x65,
+	0x73, 0x73, 0x69, 0x6f, 0x6e, 0x5f, 0x69, 0x64, 0x18, 0x01, 0x20, 0x01, 0x28, 0x05, 0x52, 0x09,
+	0x73, 0x65, 0x73, 0x73, 0x69, 0x6f, 0x6e, 0x49, 0x64, 0x12, 0x12, 0x0a, 0x04, 0x64, 0x41, 0x74,
+	0x41, 0x18, 0x02, 0x20, 0x01, 0x28, 0x0c, 0x52, 0x04, 0x64, 0x41, 0x74, 0x41, 0x22, 0x0e, 0x0a,
+	0x0c, 0x52, 0x65, 0x71, 0x75, 0x65, 0x73, 0x74, 0x52, 0x65, 0x61, 0x64, 0x79, 0x22, 0x33, 0x0a,
+	0x12, 0x52, 0x65, 0x71, 0x75, 0x65, 0x73, 0x74, 0x44, 0x72, 0x6f, 0x70, 0x53, 0x65, 0x73, 0x73,
+	0x69, 0x6f, 0x6e, 0x12, 0x1d, 0x0a, 0x0a, 0x73, 0x65, 0x73, 0x73, 0x69, 0x6f, 0x6e, 0x5f, 0x69,
+	0x64, 0x18, 0x01, 0x20, 0x01, 0x28, 0x05, 0x52, 0x09, 0x73, 0x65, 0x73, 0x73, 0x69, 0x6f, 0x6e,
+	0x49, 0x64, 0x22, 0x32, 0x0a, 0x13, 0x52, 0x65, 0x71, 0x75, 0x65, 0x73, 0x74, 0x52, 0x65, 0x6c,
+	0x6f, 0x61, 0x64, 0x43, 0x6f, 0x6e, 0x66, 0x69, 0x67, 0x12, 0x1b, 0x0a, 0x09, 0x63, 0x6f, 0x6e,
+	0x66, 0x5f, 0x70, 0x61, 0x74, 0x68, 0x18, 0x01, 0x20, 0x01, 0x28, 0x09, 0x52, 0x08, 0x63, 0x6f,
+	0x6e, 0x66, 0x50, 0x61, 0x74, 0x68, 0x22, 0x10, 0x0a, 0x0e, 0x52, 0x65, 0x71, 0x75, 0x65, 0x73,
+	0x74, 0x44, 0x69, 0x73, 0x63, 0x61, 0x72, 0x64, 0x42, 0x21, 0x5a, 0x1f, 0x73, 0x65, 0x73, 0x73,
+	0x69, 0x6f, 0x6e, 0x6d, 0x67, 0x72, 0x2f, 0x70, 0x72, 0x6f, 0x74, 0x6f, 0x2f, 0x70, 0x6b, 0x67,
+	0x2f, 0x72, 0x65, 0x71, 0x75, 0x65, 0x73, 0x74, 0x5f, 0x70, 0x62, 0x62, 0x06, 0x70, 0x72, 0x6f,
+	0x74, 0x6f, 0x33,
+}
+
+var (
+	file_request_proto_rawDescOnce sync.Once
+	file_request_proto_rawDescData = file_request_proto_rawDesc
+)
+
+func file_request_proto_rawDescGZIP() []byte {
+	file_request_proto_rawDescOnce.Do(func() {
+		file_request_proto_rawDescData = protoimpl.X.CompressGZIP(file_request_proto_rawDescData)
+	})
+	return file_request_proto_rawDescData
+}
+
+var file_request_proto_msgTypes = make([]protoimpl.MessageInfo, 11)
+var file_request_proto_goTypes = []any{
+	(*Request)(nil),              // 0: Request
+	(*RequestCreateSession)(nil), // 1: RequestCreateSession
+	(*RequestOffer)(nil),         // 2: RequestOffer
+	(*RequestJoinSession)(nil),   // 3: RequestJoinSession
+	(*RequestAnswer)(nil),        // 4: RequestAnswer
+	(*RequestConfirmAnswer)(nil), // 5: RequestConfirmAnswer
+	(*RequestSend)(nil),          // 6: RequestSend
+	(*RequestReady)(nil),         // 7: RequestReady
+	(*RequestDropSession)(nil),   // 8: RequestDropSession
+	(*RequestReloadConfig)(nil),  // 9: RequestReloadConfig
+	(*RequestDiscard)(nil),       // 10: RequestDiscard
+}
+var file_request_proto_depIdxs = []int32{
+	1,  // 0: Request.create_session:type_name -> RequestCreateSession
+	2,  // 1: Request.offer:type_name -> RequestOffer
+	3,  // 2: Request.join_session:type_name -> RequestJoinSession
+	4,  // 3: Request.answer:type_name -> RequestAnswer
+	5,  // 4: Request.confirm_answer:type_name -> RequestConfirmAnswer
+	6,  // 5: Request.send:type_name -> RequestSend
+	7,  // 6: Request.ready:type_name -> RequestReady
+	8,  // 7: Request.drop_session:type_name -> RequestDropSession
+	9,  // 8: Request.reload_config:type_name -> RequestReloadConfig
+	10, // 9: Request.discard:type_name -> RequestDiscard
+	10, // [10:10] is the sub-list for method output_type
+	10, // [10:10] is the sub-list for method input_type
+	10, // [10:10] is the sub-list for extension type_name
+	10, // [10:10] is the sub-list for extension extendee
+	0,  // [0:10] is the sub-list for field type_name
+}
+
+func init() { file_request_proto_init() }
+func file_request_proto_init() {
+	if File_request_proto != nil {
+		return
+	}
+	if !protoimpl.UnsafeEnabled {
+		file_request_proto_msgTypes[0].Exporter = func(v any, i int) any {
+			switch v := v.(*Request); i {
+			case 0:
+				return &v.state
+			case 1:
+				return &v.sizeCache
+			case 2:
+				return &v.unknownFields
+			default:
+				return nil
+			}
+		}
+		file_request_proto_msgTypes[1].Exporter = func(v any, i int) any {
+			switch v := v.(*RequestCreateSession); i {
+			case 0:
+				return &v.state
+			case 1:
+				return &v.sizeCache
+			case 2:
+				return &v.unknownFields
+			default:
+				return nil
+			}
+		}
+		file_request_proto_msgTypes[2].Exporter = func(v any, i int) any {
+			switch v := v.(*RequestOffer); i {
+			case 0:
+				return &v.state
+			case 1:
+				return &v.sizeCache
+			case 2:
+				return &v.unknownFields
+			default:
+				return nil
+			}
+		}
+		file_request_proto_msgTypes[3].Exporter = func(v any, i int) any {
+			switch v := v.(*RequestJoinSession); i {
+			case 0:
+				return &v.state
+			case 1:
+				return &v.sizeCache
+			case 2:
+				return &v.unknownFields
+			default:
+				return nil
+			}
+		}
+		file_request_proto_msgTypes[4].Exporter = func(v any, i int) any {
+			switch v := v.(*RequestAnswer); i {
+			case 0:
+				return &v.state
+			case 1:
+				return &v.sizeCache
+			case 2:
+				return &v.unknownFields
+			default:
+				return nil
+			}
+		}
+		file_request_proto_msgTypes[5].Exporter = func(v any, i int) any {
+			switch v := v.(*RequestConfirmAnswer); i {
+			case 0:
+				return &v.state
+			case 1:
+				return &v.sizeCache
+			case 2:
+				return &v.unknownFields
+			default:
+				return nil
+			}
+		}
+		file_request_proto_msgTypes[6].Exporter = func(v any, i int) any {
+			switch v := v.(*RequestSend); i {
+			case 0:
+				return &v.state
+			case 1:
+				return &v.sizeCache
+			case 2:
+				return &v.unknownFields
+			default:
+				return nil
+			}
+		}
+		file_request_proto_msgTypes[7].Exporter = func(v any, i int) any {
+			switch v := v.(*RequestReady); i {
+			case 0:
+				return &v.state
+			case 1:
+				return &v.sizeCache
+			case 2:
+				return &v.unknownFields
+			default:
+				return nil
+			}
+		}
+		file_request_proto_msgTypes[8].Exporter = func(v any, i int) any {
+			switch v := v.(*RequestDropSession); i {
+			case 0:
+				return &v.state
+			case 1:
+				return &v.sizeCache
+			case 2:
+				return &v.unknownFields
+			default:
+				return nil
+			}
+		}
+		file_request_proto_msgTypes[9].Exporter = func(v any, i int) any {
+			switch v := v.(*RequestReloadConfig); i {
+			case 0:
+				return &v.state
+			case 1:
+				return &v.sizeCache
+			case 2:
+				return &v.unknownFields
+			default:
+				return nil
+			}
+		}
+		file_request_proto_msgTypes[10].Exporter = func(v any, i int) any {
+			switch v := v.(*RequestDiscard); i {
+			case 0:
+				return &v.state
+			case 1:
+				return &v.sizeCache
+			case 2:
+				return &v.unknownFields
+			default:
+				return nil
+			}
+		}
+	}
+	type x struct{}
+	out := protoimpl.TypeBuilder{
+		File: protoimpl.DescBuilder{
+			GoPackagePath: reflect.TypeOf(x{}).PkgPath(),
+			RawDescriptor: file_request_proto_rawDesc,
+			NumEnums:      0,
+			NumMessages:   11,
+			NumExtensions: 0,
+			NumServices:   0,
+		},
+		GoTypes:           file_request_proto_goTypes,
+		DependencyIndexes: file_request_proto_depIdxs,
+		MessageInfos:      file_request_proto_msgTypes,
+	}.Build()
+	File_request_proto = out.File
+	file_request_proto_rawDesc = nil
+	file_request_proto_goTypes = nil
+	file_request_proto_depIdxs = nil
+}
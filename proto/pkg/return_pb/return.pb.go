@@ -38,6 +38,7 @@ type Return struct {
 	DropSessionReturn   *ReturnDropSession   `protobuf:"bytes,9,opt,name=drop_session_return,json=dropSessionReturn,proto3" json:"drop_session_return,omitempty"`
 	ReloadConfigReturn  *ReturnReloadConfig  `protobuf:"bytes,10,opt,name=reload_config_return,json=reloadConfigReturn,proto3" json:"reload_config_return,omitempty"`
 	DiscardReturn       *ReturnDiscard       `protobuf:"bytes,11,opt,name=discard_return,json=discardReturn,proto3" json:"discard_return,omitempty"`
+	Id                  uint64               `protobuf:"varint,12,opt,name=id,proto3" json:"id,omitempty"`
 }
 
 func (x *Return) Reset() {
@@ -149,6 +150,13 @@ func (x *Return) GetDiscardReturn() *ReturnDiscard {
 	return nil
 }
 
+func (x *Return) GetId() uint64 {
+	if x != nil {
+		return x.Id
+	}
+	return 0
+}
+
 type ReturnCreateSession struct {
 	state         protoimpl.MessageState
 	sizeCache     protoimpl.SizeCache
@@ -561,7 +569,7 @@ var File_return_proto protoreflect.FileDescriptor
 var file_return_proto_rawDesc = []byte{
 	0x0a, 0x0c, 0x72, 0x65, 0x74, 0x75, 0x72, 0x6e, 0x2e, 0x70, 0x72, 0x6f, 0x74, 0x6f, 0x1a, 0x0b,
 	0x65, 0x72, 0x72, 0x6f, 0x72, 0x2e, 0x70, 0x72, 0x6f, 0x74, 0x6f, 0x1a, 0x0b, 0x72, 0x65, 0x61,
-	0x64, 0x79, 0x2e, 0x70, 0x72, 0x6f, 0x74, 0x6f, 0x22, 0x80, 0x05, 0x0a, 0x06, 0x52, 0x65, 0x74,
+	0x64, 0x79, 0x2e, 0x70, 0x72, 0x6f, 0x74, 0x6f, 0x22, 0x90, 0x05, 0x0a, 0x06, 0x52, 0x65, 0x74,
 	0x75, 0x72, 0x6e, 0x12, 0x18, 0x0a, 0x03, 0x65, 0x72, 0x72, 0x18, 0x01, 0x20, 0x01, 0x28, 0x0b,
 	0x32, 0x06, 0x2e, 0x45, 0x72, 0x72, 0x6f, 0x72, 0x52, 0x03, 0x65, 0x72, 0x72, 0x12, 0x48, 0x0a,
 	0x15, 0x63, 0x72, 0x65, 0x61, 0x74, 0x65, 0x5f, 0x73, 0x65, 0x73, 0x73, 0x69, 0x6f, 0x6e, 0x5f,
@@ -601,7 +609,8 @@ var file_return_proto_rawDesc = []byte{
 	0x74, 0x75, 0x72, 0x6e, 0x12, 0x35, 0x0a, 0x0e, 0x64, 0x69, 0x73, 0x63, 0x61, 0x72, 0x64, 0x5f,
 	0x72, 0x65, 0x74, 0x75, 0x72, 0x6e, 0x18, 0x0b, 0x20, 0x01, 0x28, 0x0b, 0x32, 0x0e, 0x2e, 0x52,
 	0x65, 0x74, 0x75, 0x72, 0x6e, 0x44, 0x69, 0x73, 0x63, 0x61, 0x72, 0x64, 0x52, 0x0d, 0x64, 0x69,
-	0x73, 0x63, 0x61, 0x72, 0x64, 0x52, 0x65, 0x74, 0x75, 0x72, 0x6e, 0x22, 0x15, 0x0a, 0x13, 0x52,
+	0x73, 0x63, 0x61, 0x72, 0x64, 0x52, 0x65, 0x74, 0x75, 0x72, 0x6e, 0x12, 0x0e, 0x0a, 0x02, 0x69,
+	0x64, 0x18, 0x0c, 0x20, 0x01, 0x28, 0x04, 0x52, 0x02, 0x69, 0x64, 0x22, 0x15, 0x0a, 0x13, 0x52,
 	0x65, 0x74, 0x75, 0x72, 0x6e, 0x43, 0x72, 0x65, 0x61, 0x74, 0x65, 0x53, 0x65, 0x73, 0x73, 0x69,
 	0x6f, 0x6e, 0x22, 0x30, 0x0a, 0x0b, 0x52, 0x65, 0x74, 0x75, 0x72, 0x6e, 0x4f, 0x66, 0x66, 0x65,
 	0x72, 0x12, 0x21, 0x0a, 0x0c, 0x6f, 0x66, 0x66, 0x65, 0x72, 0x5f, 0x62, 0x61, 0x73, 0x65, 0x36,
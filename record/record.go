@@ -0,0 +1,244 @@
+package record
+
+import (
+	"bufio"
+	"context"
+	"encoding/binary"
+	"errors"
+	"fmt"
+	"io"
+	"os"
+	"path/filepath"
+	"sync"
+	"time"
+)
+
+var ErrFormat = errors.New("not a recording")
+
+// magic start every recording, the last byte is the version of the format
+var magic = []byte("SMREC\x01")
+
+// maxField bound a channel or payload so a corrupt length does not allocate the memory it claims
+const maxField = 16 << 20
+
+// Direction tell whether a message was sent or received by the recording side
+type Direction byte
+
+const (
+	Outbound Direction = 'o'
+	Inbound  Direction = 'i'
+)
+
+func (d Direction) String() string {
+	switch d {
+	case Outbound:
+		return "out"
+	case Inbound:
+		return "in"
+	default:
+		return fmt.Sprintf("Direction(%d)", byte(d))
+	}
+}
+
+// ParseDirection accept out and in as printed by String
+func ParseDirection(s string) (Direction, error) {
+	switch s {
+	case "out":
+		return Outbound, nil
+	case "in":
+		return Inbound, nil
+	default:
+		return 0, fmt.Errorf("unknown direction %q, expected out or in", s)
+	}
+}
+
+// Message is one datachannel message of a recording
+type Message struct {
+	Time      time.Time
+	Direction Direction
+	Channel   string
+	Payload   []byte
+}
+
+// Writer append the messages of one session to a recording, a nil *Writer records nothing
+//
+// The file is the header "SMREC\x01", the session ID and the start time in unix nanoseconds,
+// followed by one record per message: the offset from the start in microseconds, the direction,
+// the channel and the payload, numbers are varints and strings are prefixed with their length.
+type Writer struct {
+	mu     sync.Mutex
+	w      io.Writer
+	closer io.Closer
+	start  time.Time
+}
+
+// NewWriter write the header of a recording of SessionID to w
+func NewWriter(w io.Writer, SessionID int32) (*Writer, error) {
+	start := time.Now()
+	header := append([]byte(nil), magic...)
+	header = binary.AppendVarint(header, int64(SessionID))
+	header = binary.AppendVarint(header, start.UnixNano())
+	if _, err := w.Write(header); err != nil {
+		return nil, err
+	}
+	return &Writer{w: w, start: start}, nil
+}
+
+// Create start a recording of SessionID in dir, the file is named after the session and the start time
+// so a reused ID does not overwrite an earlier recording
+func Create(dir string, SessionID int32) (*Writer, error) {
+	if err := os.MkdirAll(dir, 0755); err != nil {
+		return nil, err
+	}
+	name := fmt.Sprintf("session-%d-%d.rec", SessionID, time.Now().UnixNano())
+	file, err := os.OpenFile(filepath.Join(dir, name), os.O_WRONLY|os.O_CREATE|os.O_EXCL, 0600)
+	if err != nil {
+		return nil, err
+	}
+	w, err := NewWriter(file, SessionID)
+	if err != nil {
+		_ = file.Close()
+		return nil, err
+	}
+	w.closer = file
+	return w, nil
+}
+
+// Write append m, Time is set when zero
+func (w *Writer) Write(m Message) error {
+	if w == nil {
+		return nil
+	}
+	if m.Time.IsZero() {
+		m.Time = time.Now()
+	}
+	offset := m.Time.Sub(w.start).Microseconds()
+	if offset < 0 {
+		offset = 0
+	}
+	line := binary.AppendUvarint(nil, uint64(offset))
+	line = append(line, byte(m.Direction))
+	line = binary.AppendUvarint(line, uint64(len(m.Channel)))
+	line = append(line, m.Channel...)
+	line = binary.AppendUvarint(line, uint64(len(m.Payload)))
+	line = append(line, m.Payload...)
+	w.mu.Lock()
+	defer w.mu.Unlock()
+	// one write per message keeps a record whole when the process dies
+	_, err := w.w.Write(line)
+	return err
+}
+
+func (w *Writer) Close() error {
+	if w == nil || w.closer == nil {
+		return nil
+	}
+	w.mu.Lock()
+	defer w.mu.Unlock()
+	return w.closer.Close()
+}
+
+// Reader read back a recording
+type Reader struct {
+	r         *bufio.Reader
+	SessionID int32
+	Start     time.Time
+}
+
+// NewReader read the header of the recording in r
+func NewReader(r io.Reader) (*Reader, error) {
+	br := bufio.NewReader(r)
+	header := make([]byte, len(magic))
+	if _, err := io.ReadFull(br, header); err != nil || string(header) != string(magic) {
+		return nil, ErrFormat
+	}
+	SessionID, err := binary.ReadVarint(br)
+	if err != nil {
+		return nil, ErrFormat
+	}
+	start, err := binary.ReadVarint(br)
+	if err != nil {
+		return nil, ErrFormat
+	}
+	return &Reader{r: br, SessionID: int32(SessionID), Start: time.Unix(0, start)}, nil
+}
+
+// Next return the following message, io.EOF after the last one
+func (r *Reader) Next() (Message, error) {
+	offset, err := binary.ReadUvarint(r.r)
+	if err != nil {
+		// a clean end is only between two records
+		return Message{}, err
+	}
+	m := Message{Time: r.Start.Add(time.Duration(offset) * time.Microsecond)}
+	direction, err := r.r.ReadByte()
+	if err != nil {
+		return Message{}, io.ErrUnexpectedEOF
+	}
+	m.Direction = Direction(direction)
+	channel, err := r.bytes()
+	if err != nil {
+		return Message{}, err
+	}
+	m.Channel = string(channel)
+	if m.Payload, err = r.bytes(); err != nil {
+		return Message{}, err
+	}
+	return m, nil
+}
+
+// bytes read a string prefixed with its length
+func (r *Reader) bytes() ([]byte, error) {
+	size, err := binary.ReadUvarint(r.r)
+	if err != nil {
+		return nil, io.ErrUnexpectedEOF
+	}
+	if size > maxField {
+		return nil, ErrFormat
+	}
+	data := make([]byte, size)
+	if _, err = io.ReadFull(r.r, data); err != nil {
+		return nil, io.ErrUnexpectedEOF
+	}
+	return data, nil
+}
+
+// ReplayOptions select and pace the messages of Replay
+type ReplayOptions struct {
+	// Direction is the messages that are sent again, Outbound by default
+	Direction Direction
+	// Speed divide the original gaps between messages, 2 replays twice as fast, 0 or less sends without waiting
+	Speed float64
+}
+
+// Replay call send for every message of r in opts.Direction, keeping the gaps of the recording
+func Replay(ctx context.Context, r *Reader, opts ReplayOptions, send func(Message) error) error {
+	if opts.Direction == 0 {
+		opts.Direction = Outbound
+	}
+	var previous time.Time
+	for {
+		m, err := r.Next()
+		if errors.Is(err, io.EOF) {
+			return nil
+		}
+		if err != nil {
+			return err
+		}
+		if m.Direction != opts.Direction {
+			continue
+		}
+		if !previous.IsZero() && opts.Speed > 0 {
+			gap := time.Duration(float64(m.Time.Sub(previous)) / opts.Speed)
+			select {
+			case <-ctx.Done():
+				return ctx.Err()
+			case <-time.After(gap):
+			}
+		}
+		previous = m.Time
+		if err = send(m); err != nil {
+			return err
+		}
+	}
+}
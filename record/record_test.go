@@ -0,0 +1,133 @@
+package record
+
+import (
+	"bytes"
+	"context"
+	"errors"
+	"io"
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+)
+
+func TestRoundTrip(t *testing.T) {
+	var buf bytes.Buffer
+	w, err := NewWriter(&buf, 7)
+	if err != nil {
+		t.Fatal(err)
+	}
+	start := time.Now()
+	written := []Message{
+		{Time: start, Direction: Outbound, Channel: "data", Payload: []byte("hello")},
+		{Time: start.Add(1500 * time.Microsecond), Direction: Inbound, Channel: "data", Payload: nil},
+		{Time: start.Add(time.Second), Direction: Outbound, Channel: "data", Payload: bytes.Repeat([]byte{0}, 300)},
+	}
+	for _, m := range written {
+		if err = w.Write(m); err != nil {
+			t.Fatal(err)
+		}
+	}
+	// a nil Writer records nothing
+	if err = (*Writer)(nil).Write(Message{}); err != nil {
+		t.Fatal(err)
+	}
+
+	r, err := NewReader(bytes.NewReader(buf.Bytes()))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if r.SessionID != 7 {
+		t.Errorf("expected session 7, got %v", r.SessionID)
+	}
+	for i, want := range written {
+		m, err := r.Next()
+		if err != nil {
+			t.Fatal(err)
+		}
+		if m.Direction != want.Direction || m.Channel != want.Channel || !bytes.Equal(m.Payload, want.Payload) {
+			t.Errorf("message %d: expected %+v, got %+v", i, want, m)
+		}
+		if gap := m.Time.Sub(want.Time); gap < -time.Microsecond || gap > time.Microsecond {
+			t.Errorf("message %d: time off by %v", i, gap)
+		}
+	}
+	if _, err = r.Next(); err != io.EOF {
+		t.Errorf("expected io.EOF, got %v", err)
+	}
+
+	truncated, _ := NewReader(bytes.NewReader(buf.Bytes()[:buf.Len()-1]))
+	for err = nil; err == nil; _, err = truncated.Next() {
+	}
+	if err != io.ErrUnexpectedEOF {
+		t.Errorf("expected io.ErrUnexpectedEOF, got %v", err)
+	}
+	if _, err = NewReader(bytes.NewReader([]byte("garbage"))); !errors.Is(err, ErrFormat) {
+		t.Errorf("expected ErrFormat, got %v", err)
+	}
+}
+
+func TestCreate(t *testing.T) {
+	dir := filepath.Join(t.TempDir(), "records")
+	w, err := Create(dir, 3)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if err = w.Write(Message{Direction: Inbound, Channel: "data", Payload: []byte("hi")}); err != nil {
+		t.Fatal(err)
+	}
+	if err = w.Close(); err != nil {
+		t.Fatal(err)
+	}
+	paths, _ := filepath.Glob(filepath.Join(dir, "session-3-*.rec"))
+	if len(paths) != 1 {
+		t.Fatalf("expected one recording, got %v", paths)
+	}
+	file, err := os.Open(paths[0])
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer file.Close()
+	r, err := NewReader(file)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if m, err := r.Next(); err != nil || string(m.Payload) != "hi" {
+		t.Errorf("unexpected message %+v, %v", m, err)
+	}
+}
+
+func TestReplay(t *testing.T) {
+	var buf bytes.Buffer
+	w, _ := NewWriter(&buf, 1)
+	start := time.Now()
+	for i, d := range []Direction{Outbound, Inbound, Outbound} {
+		_ = w.Write(Message{Time: start.Add(time.Duration(i) * 100 * time.Millisecond), Direction: d, Payload: []byte{byte(i)}})
+	}
+
+	replay := func(opts ReplayOptions) ([]byte, time.Duration) {
+		r, err := NewReader(bytes.NewReader(buf.Bytes()))
+		if err != nil {
+			t.Fatal(err)
+		}
+		var sent []byte
+		begin := time.Now()
+		err = Replay(context.Background(), r, opts, func(m Message) error {
+			sent = append(sent, m.Payload...)
+			return nil
+		})
+		if err != nil {
+			t.Fatal(err)
+		}
+		return sent, time.Since(begin)
+	}
+	if sent, took := replay(ReplayOptions{Speed: 1}); !bytes.Equal(sent, []byte{0, 2}) || took < 200*time.Millisecond {
+		t.Errorf("unexpected replay %v in %v", sent, took)
+	}
+	if sent, took := replay(ReplayOptions{Speed: 4}); !bytes.Equal(sent, []byte{0, 2}) || took >= 200*time.Millisecond {
+		t.Errorf("unexpected fast replay %v in %v", sent, took)
+	}
+	if sent, _ := replay(ReplayOptions{Direction: Inbound}); !bytes.Equal(sent, []byte{1}) {
+		t.Errorf("unexpected inbound replay %v", sent)
+	}
+}
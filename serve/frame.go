@@ -0,0 +1,42 @@
+package serve
+
+import (
+	"encoding/binary"
+	"errors"
+	"io"
+)
+
+var ErrFrame = errors.New("frame too large")
+
+// MaxFrameSize bound a frame, an offer is far smaller
+const MaxFrameSize = 16 << 20
+
+// ReadFrame read a frame prefixed with its length as a big-endian uint32
+func ReadFrame(r io.Reader) ([]byte, error) {
+	var header [4]byte
+	if _, err := io.ReadFull(r, header[:]); err != nil {
+		return nil, err
+	}
+	size := binary.BigEndian.Uint32(header[:])
+	if size > MaxFrameSize {
+		return nil, ErrFrame
+	}
+	frame := make([]byte, size)
+	if _, err := io.ReadFull(r, frame); err != nil {
+		if errors.Is(err, io.EOF) {
+			return nil, io.ErrUnexpectedEOF
+		}
+		return nil, err
+	}
+	return frame, nil
+}
+
+// WriteFrame write frame prefixed with its length as a big-endian uint32
+func WriteFrame(w io.Writer, frame []byte) error {
+	if len(frame) > MaxFrameSize {
+		return ErrFrame
+	}
+	buf := binary.BigEndian.AppendUint32(make([]byte, 0, 4+len(frame)), uint32(len(frame)))
+	_, err := w.Write(append(buf, frame...))
+	return err
+}
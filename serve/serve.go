@@ -0,0 +1,178 @@
+package serve
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"google.golang.org/protobuf/encoding/protowire"
+	"google.golang.org/protobuf/proto"
+	"io"
+	"sessionmgr"
+	"sessionmgr/logs"
+	reqpb "sessionmgr/proto/pkg/request_pb"
+	pb "sessionmgr/proto/pkg/return_pb"
+	"sync"
+)
+
+var (
+	ErrRequest   = errors.New("request has no call")
+	ErrMalformed = errors.New("malformed request")
+)
+
+// Manager is what a Request can call
+type Manager interface {
+	sessionmgr.SessionManager
+	ReloadConfig(ConfPath string) error
+}
+
+// Serve answer the Request frames read from in with Return frames on out until in is closed
+// or a Discard request, received messages are pushed as Return frames with id 0 meanwhile.
+// mgr is discarded when Serve returns.
+func Serve(ctx context.Context, mgr *sessionmgr.SessionManagerImpl, in io.Reader, out io.Writer) error {
+	var wg sync.WaitGroup
+	defer wg.Wait()
+	ctx, cancel := context.WithCancel(ctx)
+	defer cancel()
+	w := &writer{out: out}
+
+	wg.Add(1)
+	go func() {
+		defer wg.Done()
+		push(ctx, mgr, w)
+	}()
+
+	for {
+		frame, err := ReadFrame(in)
+		if errors.Is(err, io.EOF) {
+			_ = mgr.Discard()
+			return nil
+		}
+		if err != nil {
+			_ = mgr.Discard()
+			return err
+		}
+		req := &reqpb.Request{}
+		if err = proto.Unmarshal(frame, req); err != nil {
+			// the frame boundary is intact, the next request can still be read
+			mgr.Logger().Warn(logs.MANAGER, "malformed request", "err", err)
+			if err = w.write(Malformed(frame, err)); err != nil {
+				_ = mgr.Discard()
+				return err
+			}
+			continue
+		}
+		ret := Handle(mgr, req)
+		ret.Id = req.GetId()
+		if err = w.write(ret); err != nil {
+			_ = mgr.Discard()
+			return err
+		}
+		if req.GetDiscard() != nil {
+			return nil
+		}
+	}
+}
+
+// Handle call the method named by req, a failure is carried in Return.err
+func Handle(mgr Manager, req *reqpb.Request) *pb.Return {
+	ret := &pb.Return{}
+	var err error
+	switch {
+	case req.GetCreateSession() != nil:
+		ret.CreateSessionReturn = &pb.ReturnCreateSession{}
+		err = mgr.CreateSession(req.GetCreateSession().GetSessionId())
+	case req.GetOffer() != nil:
+		ret.OfferReturn = &pb.ReturnOffer{}
+		ret.OfferReturn.OfferBase64, err = mgr.Offer(req.GetOffer().GetSessionId())
+	case req.GetJoinSession() != nil:
+		ret.JoinSessionReturn = &pb.ReturnJoinSession{}
+		err = mgr.JoinSession(req.GetJoinSession().GetSessionId(), req.GetJoinSession().GetOfferBase64())
+	case req.GetAnswer() != nil:
+		ret.AnswerReturn = &pb.ReturnAnswer{}
+		ret.AnswerReturn.AnswerBase64, err = mgr.Answer(req.GetAnswer().GetSessionId())
+	case req.GetConfirmAnswer() != nil:
+		ret.ConfirmAnswerReturn = &pb.ReturnConfirmAnswer{}
+		err = mgr.ConfirmAnswer(req.GetConfirmAnswer().GetSessionId(), req.GetConfirmAnswer().GetAnswerBase64())
+	case req.GetSend() != nil:
+		ret.SendReturn = &pb.ReturnSend{}
+		err = mgr.Send(req.GetSend().GetSessionId(), req.GetSend().GetDAtA())
+	case req.GetReady() != nil:
+		ret.ReadyReturn = &pb.ReturnReady{}
+		ret.ReadyReturn.ReadyList, err = mgr.Ready()
+	case req.GetDropSession() != nil:
+		ret.DropSessionReturn = &pb.ReturnDropSession{}
+		err = mgr.DropSession(req.GetDropSession().GetSessionId())
+	case req.GetReloadConfig() != nil:
+		ret.ReloadConfigReturn = &pb.ReturnReloadConfig{}
+		err = mgr.ReloadConfig(req.GetReloadConfig().GetConfPath())
+	case req.GetDiscard() != nil:
+		ret.DiscardReturn = &pb.ReturnDiscard{}
+		err = mgr.Discard()
+	default:
+		err = ErrRequest
+	}
+	if err != nil {
+		return &pb.Return{Err: sessionmgr.ToProto(err)}
+	}
+	return ret
+}
+
+// Malformed answer a frame that is not a Request, the id is kept when it can be read before the damage
+func Malformed(frame []byte, err error) *pb.Return {
+	return &pb.Return{Id: requestID(frame), Err: sessionmgr.ToProto(fmt.Errorf("%w: %v", ErrMalformed, err))}
+}
+
+// requestID scan the fields of frame for the id of a Request, 0 when it is missing or unreadable
+func requestID(frame []byte) uint64 {
+	var id uint64
+	for len(frame) > 0 {
+		num, typ, n := protowire.ConsumeTag(frame)
+		if n < 0 {
+			return id
+		}
+		frame = frame[n:]
+		if num == 1 && typ == protowire.VarintType {
+			v, n := protowire.ConsumeVarint(frame)
+			if n < 0 {
+				return id
+			}
+			id, frame = v, frame[n:]
+			continue
+		}
+		if n = protowire.ConsumeFieldValue(num, typ, frame); n < 0 {
+			return id
+		}
+		frame = frame[n:]
+	}
+	return id
+}
+
+// push write received messages as soon as they arrive
+func push(ctx context.Context, mgr *sessionmgr.SessionManagerImpl, w *writer) {
+	for {
+		rlist, err := mgr.WaitReady(ctx)
+		if err != nil {
+			return
+		}
+		if err = w.write(&pb.Return{ReadyReturn: &pb.ReturnReady{ReadyList: rlist}}); err != nil {
+			logs.Default().Warn(logs.READY, "push ready", "err", err)
+			return
+		}
+	}
+}
+
+// writer keep replies and pushes from interleaving
+type writer struct {
+	mu  sync.Mutex
+	out io.Writer
+}
+
+func (w *writer) write(ret *pb.Return) error {
+	frame, err := proto.Marshal(ret)
+	if err != nil {
+		return err
+	}
+	w.mu.Lock()
+	defer w.mu.Unlock()
+	return WriteFrame(w.out, frame)
+}
@@ -0,0 +1,211 @@
+package serve
+
+import (
+	"bytes"
+	"context"
+	"errors"
+	"google.golang.org/protobuf/encoding/protowire"
+	"google.golang.org/protobuf/proto"
+	"io"
+	"sessionmgr"
+	"sessionmgr/internal/testmgr"
+	readypb "sessionmgr/proto/pkg/ready_pb"
+	reqpb "sessionmgr/proto/pkg/request_pb"
+	pb "sessionmgr/proto/pkg/return_pb"
+	"strings"
+	"testing"
+	"time"
+)
+
+// host drive Serve like a foreign process would
+type host struct {
+	t      *testing.T
+	in     *io.PipeWriter
+	out    *io.PipeReader
+	nextID uint64
+	pushed []*readypb.Ready
+	done   chan error
+}
+
+func newHost(t *testing.T, mgr *sessionmgr.SessionManagerImpl) *host {
+	inR, inW := io.Pipe()
+	outR, outW := io.Pipe()
+	h := &host{t: t, in: inW, out: outR, done: make(chan error, 1)}
+	go func() {
+		h.done <- Serve(context.Background(), mgr, inR, outW)
+		_ = outW.Close()
+	}()
+	return h
+}
+
+// call send req and return its reply, pushes read meanwhile are kept
+func (h *host) call(req *reqpb.Request) *pb.Return {
+	h.nextID++
+	req.Id = h.nextID
+	frame, err := proto.Marshal(req)
+	if err != nil {
+		h.t.Fatal(err)
+	}
+	if err = WriteFrame(h.in, frame); err != nil {
+		h.t.Fatal(err)
+	}
+	for {
+		ret := h.read()
+		if ret.GetId() == req.Id {
+			return ret
+		}
+		if ret.GetId() != 0 || ret.GetReadyReturn() == nil {
+			h.t.Fatalf("unexpected frame %v", ret)
+		}
+		h.pushed = append(h.pushed, ret.GetReadyReturn().GetReadyList()...)
+	}
+}
+
+func (h *host) read() *pb.Return {
+	frame, err := ReadFrame(h.out)
+	if err != nil {
+		h.t.Fatal(err)
+	}
+	ret := &pb.Return{}
+	if err = proto.Unmarshal(frame, ret); err != nil {
+		h.t.Fatal(err)
+	}
+	return ret
+}
+
+// retry call until it stops failing with ErrWait
+func (h *host) retry(req func() *reqpb.Request) *pb.Return {
+	deadline := time.Now().Add(10 * time.Second)
+	for {
+		ret := h.call(req())
+		if ret.GetErr().GetErrWait() == nil || time.Now().After(deadline) {
+			return ret
+		}
+		time.Sleep(50 * time.Millisecond)
+	}
+}
+
+func TestServe(t *testing.T) {
+	h := newHost(t, testmgr.New(t, ""))
+	peer := testmgr.New(t, "")
+
+	if ret := h.call(&reqpb.Request{CreateSession: &reqpb.RequestCreateSession{SessionId: 1}}); ret.GetErr() != nil || ret.GetCreateSessionReturn() == nil {
+		t.Fatalf("create session: %v", ret)
+	}
+	if ret := h.call(&reqpb.Request{CreateSession: &reqpb.RequestCreateSession{SessionId: 1}}); ret.GetErr().GetErrId().GetID() != 1 {
+		t.Errorf("expected ErrID for session 1, got %v", ret)
+	}
+	ret := h.retry(func() *reqpb.Request { return &reqpb.Request{Offer: &reqpb.RequestOffer{SessionId: 1}} })
+	if ret.GetErr() != nil {
+		t.Fatal(sessionmgr.FromProto(ret.GetErr()))
+	}
+	if err := peer.JoinSession(2, ret.GetOfferReturn().GetOfferBase64()); err != nil {
+		t.Fatal(err)
+	}
+	var answer string
+	for deadline := time.Now().Add(10 * time.Second); ; {
+		var err error
+		if answer, err = peer.Answer(2); err == nil {
+			break
+		}
+		if !errors.Is(err, sessionmgr.ErrWait) || time.Now().After(deadline) {
+			t.Fatal(err)
+		}
+		time.Sleep(50 * time.Millisecond)
+	}
+	ret = h.call(&reqpb.Request{ConfirmAnswer: &reqpb.RequestConfirmAnswer{SessionId: 1, AnswerBase64: answer}})
+	if ret.GetErr() != nil {
+		t.Fatal(sessionmgr.FromProto(ret.GetErr()))
+	}
+	ret = h.retry(func() *reqpb.Request {
+		return &reqpb.Request{Send: &reqpb.RequestSend{SessionId: 1, DAtA: []byte("to peer")}}
+	})
+	if ret.GetErr() != nil {
+		t.Fatal(sessionmgr.FromProto(ret.GetErr()))
+	}
+
+	// the peer's message is pushed without a Ready request
+	for deadline := time.Now().Add(10 * time.Second); ; {
+		err := peer.Send(2, []byte("to host"))
+		if err == nil {
+			break
+		}
+		if !errors.Is(err, sessionmgr.ErrWait) || time.Now().After(deadline) {
+			t.Fatal(err)
+		}
+		time.Sleep(50 * time.Millisecond)
+	}
+	for len(h.pushed) == 0 {
+		ret := h.read()
+		if ret.GetId() != 0 {
+			t.Fatalf("unexpected reply %v", ret)
+		}
+		h.pushed = append(h.pushed, ret.GetReadyReturn().GetReadyList()...)
+	}
+	if h.pushed[0].GetSessionID() != 1 || !bytes.Equal(h.pushed[0].GetDAtA(), []byte("to host")) {
+		t.Errorf("unexpected push %v", h.pushed[0])
+	}
+
+	if ret := h.call(&reqpb.Request{}); ret.GetErr() == nil {
+		t.Error("expected a request without call to fail")
+	}
+	if ret := h.call(&reqpb.Request{Discard: &reqpb.RequestDiscard{}}); ret.GetErr() != nil || ret.GetDiscardReturn() == nil {
+		t.Errorf("discard: %v", ret)
+	}
+	if err := <-h.done; err != nil {
+		t.Errorf("serve: %v", err)
+	}
+}
+
+func TestServeMalformed(t *testing.T) {
+	h := newHost(t, testmgr.New(t, ""))
+	// id 42 then a bytes field claiming more than the frame holds
+	frame := protowire.AppendTag(nil, 1, protowire.VarintType)
+	frame = protowire.AppendVarint(frame, 42)
+	frame = append(frame, 0x12, 0x05, 0x01)
+	if err := WriteFrame(h.in, frame); err != nil {
+		t.Fatal(err)
+	}
+	ret := h.read()
+	if ret.GetId() != 42 || !strings.Contains(ret.GetErr().GetMessage(), ErrMalformed.Error()) {
+		t.Errorf("expected a malformed reply to 42, got %v", ret)
+	}
+	if err := WriteFrame(h.in, []byte{0xff}); err != nil {
+		t.Fatal(err)
+	}
+	if ret = h.read(); ret.GetId() != 0 || ret.GetErr() == nil {
+		t.Errorf("expected a malformed reply without id, got %v", ret)
+	}
+	// serving goes on after both
+	if ret = h.call(&reqpb.Request{CreateSession: &reqpb.RequestCreateSession{SessionId: 1}}); ret.GetErr() != nil {
+		t.Errorf("create session: %v", ret)
+	}
+	_ = h.in.Close()
+	if err := <-h.done; err != nil {
+		t.Errorf("serve: %v", err)
+	}
+}
+
+func TestFrame(t *testing.T) {
+	var buf bytes.Buffer
+	for _, frame := range [][]byte{{}, []byte("hello")} {
+		if err := WriteFrame(&buf, frame); err != nil {
+			t.Fatal(err)
+		}
+	}
+	for _, expected := range []string{"", "hello"} {
+		frame, err := ReadFrame(&buf)
+		if err != nil || string(frame) != expected {
+			t.Errorf("expected %q, got %q, %v", expected, frame, err)
+		}
+	}
+	if _, err := ReadFrame(&buf); !errors.Is(err, io.EOF) {
+		t.Errorf("expected EOF, got %v", err)
+	}
+	if _, err := ReadFrame(bytes.NewReader([]byte{0, 0, 0, 9, 'x'})); !errors.Is(err, io.ErrUnexpectedEOF) {
+		t.Errorf("expected truncated frame to fail, got %v", err)
+	}
+	if _, err := ReadFrame(bytes.NewReader([]byte{0xff, 0, 0, 0})); !errors.Is(err, ErrFrame) {
+		t.Errorf("expected oversized frame to fail, got %v", err)
+	}
+}
@@ -1,9 +1,15 @@
 package sessionmgr
 
 import (
+	"fmt"
 	"github.com/pion/webrtc/v4"
-	"sessionmgr/dbg"
+	"net"
+	"sessionmgr/logs"
+	"sessionmgr/record"
+	"sessionmgr/trace"
 	"sessionmgr/util"
+	"strconv"
+	"sync/atomic"
 	"time"
 )
 
@@ -12,11 +18,39 @@ type Session struct {
 	Connection *webrtc.PeerConnection
 	DataCh     *webrtc.DataChannel
 	LastUsed   time.Time
+	// Created and Role (offerer or answerer) are reported in the metrics
+	Created time.Time
+	Role    string
+	// Pins are the allowed remote fingerprints, empty allows any peer
+	Pins     []string
+	verified atomic.Bool
+	// Handshake authenticate the peer before its messages are delivered, nil when disabled
+	Handshake *Handshake
+	// Log tag its lines with the session, nil discards them
+	Log *logs.Logger
+	// sent and received are the bytes of the messages, for the audit log
+	sent     atomic.Uint64
+	received atomic.Uint64
+	// Recorder keep the messages sent and received, nil when Record is not configured
+	Recorder *record.Writer
+	// opening is the span from ConfirmAnswer to the open channel, nil when none is pending
+	opening atomic.Pointer[trace.Span]
+	// gathering is the span of the ICE gathering, nil when none is pending
+	gathering atomic.Pointer[trace.Span]
 }
 
-// NewSession create session
-func NewSession(config *webrtc.Configuration) (*Session, error) {
-	conn, err := webrtc.NewPeerConnection(*config)
+// candidateString format a candidate as "typ address:port"
+func candidateString(c *webrtc.ICECandidate) string {
+	if c == nil {
+		return ""
+	}
+	return fmt.Sprintf("%s %s", c.Typ, net.JoinHostPort(c.Address, strconv.Itoa(int(c.Port))))
+}
+
+// NewSession create session, the logs of pion for its connection go to log
+func NewSession(config *webrtc.Configuration, log *logs.Logger) (*Session, error) {
+	settings := webrtc.SettingEngine{LoggerFactory: log.PionFactory()}
+	conn, err := webrtc.NewAPI(webrtc.WithSettingEngine(settings)).NewPeerConnection(*config)
 	if err != nil {
 		return nil, err
 	}
@@ -24,6 +58,8 @@ func NewSession(config *webrtc.Configuration) (*Session, error) {
 		Connection: conn,
 		DataCh:     nil,
 		LastUsed:   time.Now(),
+		Created:    time.Now(),
+		Log:        log,
 	}
 	return s, nil
 }
@@ -35,12 +71,15 @@ func (s *Session) OfferReady() bool {
 	return false
 }
 
-func (s *Session) Offer() (string, error) {
+func (s *Session) Offer(format util.SDPFormat) (string, error) {
 	offer := s.Connection.LocalDescription()
-	dbg.Println(dbg.SESSION, "offer details:\n", offer)
-	sdpBase64, err := util.EncodeSDP(offer)
+	if offer == nil {
+		return "", ErrWait
+	}
+	s.Log.Debug(logs.SESSION, "local offer", s.Log.SDP("sdp", offer.SDP))
+	sdpBase64, err := util.EncodeSDPFormat(offer, format)
 	if err != nil {
-		dbg.Println(dbg.MANAGER, err)
+		s.Log.Error(logs.SESSION, "encode offer", "err", err)
 		return "", err
 	}
 	return sdpBase64, nil
@@ -53,25 +92,24 @@ func (s *Session) AnswerReady() bool {
 	return false
 }
 
-func (s *Session) Answer() (string, error) {
+func (s *Session) Answer(format util.SDPFormat) (string, error) {
 	answer := s.Connection.LocalDescription()
-	dbg.Println(dbg.SESSION, "answer details:\n", answer)
-	sdpBase64, err := util.EncodeSDP(answer)
+	if answer == nil {
+		return "", ErrWait
+	}
+	s.Log.Debug(logs.SESSION, "local answer", s.Log.SDP("sdp", answer.SDP))
+	sdpBase64, err := util.EncodeSDPFormat(answer, format)
 	if err != nil {
-		dbg.Println(dbg.MANAGER, err)
+		s.Log.Error(logs.SESSION, "encode answer", "err", err)
 		return "", err
 	}
 	return sdpBase64, nil
 }
 
-func (s *Session) ConfirmAnswer(sdpBase64 string) error {
-	if err := util.ValidateSDP(sdpBase64); err != nil {
-		dbg.Println(dbg.SESSION, err)
-		return err
-	}
-	answer, err := util.DecodeSDP(sdpBase64)
+func (s *Session) ConfirmAnswer(sdpBase64 string, limits util.Limits) error {
+	answer, err := util.ParseSDP(sdpBase64, webrtc.SDPTypeAnswer, limits)
 	if err != nil {
-		dbg.Println(dbg.SESSION, err)
+		s.Log.Warn(logs.SESSION, "parse answer", "err", err)
 		return err
 	}
 	if err = s.Connection.SetRemoteDescription(*answer); err != nil {
@@ -81,21 +119,69 @@ func (s *Session) ConfirmAnswer(sdpBase64 string) error {
 }
 
 func (s *Session) Send(dAtA []byte) error {
+	// the answer side gets its channel once the peer opened it
+	if s.DataCh == nil {
+		return ErrWait
+	}
 	if state := s.DataCh.ReadyState(); state != webrtc.DataChannelStateOpen {
 		return ErrWait
 	}
+	if err := s.Verify(); err != nil {
+		return err
+	}
+	if s.Handshake != nil && !s.Handshake.Passed() {
+		return ErrWait
+	}
 	if err := s.DataCh.Send(dAtA); err != nil {
 		return err
 	}
 	return nil
 }
 
+// Verify check the remote DTLS certificate against Pins, a peer that passed once is not checked again
+func (s *Session) Verify() error {
+	if len(s.Pins) == 0 || s.verified.Load() {
+		return nil
+	}
+	remote := s.Connection.SCTP().Transport().GetRemoteCertificate()
+	if len(remote) == 0 {
+		return ErrWait
+	}
+	for _, pin := range s.Pins {
+		if util.MatchFingerprint(pin, remote) {
+			s.verified.Store(true)
+			return nil
+		}
+	}
+	s.Log.Warn(logs.SESSION, "remote fingerprint not pinned", "fingerprint", util.Fingerprint(remote))
+	return ErrFingerprint
+}
+
+// endOpening end the pending channel open span with err
+func (s *Session) endOpening(err error) {
+	endPending(&s.opening, err)
+}
+
+// endGathering end the pending ICE gathering span with err
+func (s *Session) endGathering(err error) {
+	endPending(&s.gathering, err)
+}
+
+// endPending end the span in pending once, the callbacks of pion and dropSession may race for it
+func endPending(pending *atomic.Pointer[trace.Span], err error) {
+	if span := pending.Swap(nil); span != nil {
+		(*span).End(err)
+	}
+}
+
 func (s *Session) RecentActive() {
 	s.LastUsed = time.Now()
 }
 
 func (s *Session) ReportCandidate() {
 	s.Connection.OnICECandidate(func(c *webrtc.ICECandidate) {
-		dbg.Println(dbg.ICE, "candidate found:", c)
+		if c != nil {
+			s.Log.Debug(logs.ICE, "candidate found", "candidate", c.String())
+		}
 	})
 }
@@ -1,11 +1,21 @@
 package sessionmgr
 
 import (
+	"context"
+	"errors"
 	"github.com/pion/webrtc/v4"
+	"log/slog"
+	"os"
+	"reflect"
+	"sessionmgr/audit"
 	"sessionmgr/conf"
-	"sessionmgr/dbg"
+	"sessionmgr/logs"
 	pb "sessionmgr/proto/pkg/ready_pb"
+	"sessionmgr/record"
+	"sessionmgr/trace"
+	"sessionmgr/turnserver"
 	"sessionmgr/util"
+	"strings"
 	"sync"
 	"sync/atomic"
 	"time"
@@ -17,6 +27,26 @@ type SessionManagerImpl struct {
 	sessionBook  map[int32]*Session
 	readyChannel chan *pb.Ready
 	discarded    atomic.Bool // not protected by mu
+	turnServer   *turnserver.Server
+	certificate  *webrtc.Certificate
+	// authenticator is set by SetAuthenticator and takes precedence over Auth in config
+	authenticator Authenticator
+	// log is set by SetLogger, ownLog is the one built from Log in config and closed by Discard
+	log     atomic.Pointer[logs.Logger]
+	ownLog  *logs.Logger
+	metrics *managerMetrics
+	// audit is opened from Audit in config, nil when disabled
+	audit *audit.Log
+	// onDropped are added by OnSessionDropped, called in the order added
+	onDropped []*func(SessionID int32)
+	// secret is built from Encryption in config, nil when disabled
+	secret *util.Secret
+	// envelope is built from Envelope in config, nil when disabled
+	envelope *util.EnvelopeOptions
+	// tracer is set by SetTracer, trace.Nop without it
+	tracer atomic.Pointer[trace.Tracer]
+	// challenges are the handshake challenges of the sessions in progress
+	challenges challengeBook
 }
 
 func NewSessionManagerImpl(ConfPath string) (*SessionManagerImpl, error) {
@@ -31,36 +61,126 @@ func NewSessionManagerImpl(ConfPath string) (*SessionManagerImpl, error) {
 		readyChannel: make(chan *pb.Ready, config.CacheSize),
 		discarded:    atomic.Bool{},
 	}
+	s.metrics = newManagerMetrics(s)
+	logConfig := config.Log
+	if logConfig == nil && os.Getenv(logs.EnvVar) != "" {
+		// the levels of the environment apply without a Log section too, to stderr
+		logConfig = &logs.Config{}
+	}
+	if logConfig != nil {
+		if s.ownLog, err = logs.FromConfig(logConfig); err != nil {
+			logs.Default().Error(logs.CONFIG, "log config", "err", err)
+			return nil, err
+		}
+		s.log.Store(s.ownLog)
+	}
+	if _, err = newAuthenticator(&config.Auth); err != nil {
+		s.logger().Error(logs.CONFIG, "auth config", "err", err)
+		s.closeOutputs()
+		return nil, err
+	}
+	if s.certificate, err = s.loadCertificate(config); err != nil {
+		s.closeOutputs()
+		return nil, err
+	}
+	if s.secret, err = s.newSecret(&config.Encryption); err != nil {
+		s.closeOutputs()
+		return nil, err
+	}
+	if s.envelope, err = s.newEnvelope(&config.Envelope); err != nil {
+		s.closeOutputs()
+		return nil, err
+	}
+	if config.Audit != "" {
+		if s.audit, err = audit.Open(config.Audit); err != nil {
+			s.logger().Error(logs.CONFIG, "open audit log", "path", config.Audit, "err", err)
+			s.closeOutputs()
+			return nil, err
+		}
+	}
+	if config.TurnServer.Enable {
+		if s.turnServer, err = turnserver.Start(&config.TurnServer); err != nil {
+			s.closeOutputs()
+			return nil, err
+		}
+	}
 	s.enableLifeControl()
 	return s, nil
 }
 
-func (s *SessionManagerImpl) CreateSession(SessionID int32) error {
+func (s *SessionManagerImpl) CreateSession(SessionID int32) (err error) {
+	span := s.startSpan("CreateSession", SessionID)
+	defer func() { span.End(err) }()
+	defer wrapError(&err, "CreateSession", SessionID)
 	if s.discarded.Load() {
 		return ErrCall
 	}
 	s.mu.Lock()
 	defer s.mu.Unlock()
 	if _, existed := s.sessionBook[SessionID]; existed {
-		dbg.Println(dbg.MANAGER, "int repeated")
 		return ErrID
 	}
-	session, err := NewSession(&s.config.WebrtcConf)
+	webrtcConf, err := s.webrtcConf()
 	if err != nil {
 		return err
 	}
+	handshake, err := s.handshake(SessionID, roleOfferer)
+	if err != nil {
+		return err
+	}
+	log, err := s.logger().Session(SessionID)
+	if err != nil {
+		s.logger().Error(logs.MANAGER, "open session log", "session", SessionID, "err", err)
+		return err
+	}
+	recorder, err := s.recorder(SessionID)
+	if err != nil {
+		_ = log.Close()
+		return err
+	}
+	session, err := NewSession(webrtcConf, log)
+	if err != nil {
+		_ = recorder.Close()
+		_ = log.Close()
+		return err
+	}
+	session.Recorder = recorder
+	session.Role = roleOfferer
+	session.Pins = s.config.PinnedFingerprints
+	session.Handshake = handshake
 	session.RecentActive()
 	s.sessionBook[SessionID] = session
-	if err = s.initA(SessionID); err != nil {
+	if err = s.initA(SessionID, span); err != nil {
 		return err
 	}
-	dbg.Println(dbg.MANAGER, "create session: ", SessionID)
+	s.metrics.created.Inc(roleOfferer)
+	s.record(audit.Event{Event: audit.EventCreate, SessionID: SessionID, Role: roleOfferer})
+	s.logger().Info(logs.MANAGER, "create session", "session", SessionID)
 	return nil
 }
 
-func (s *SessionManagerImpl) Offer(SessionID int32) (string, error) {
+func (s *SessionManagerImpl) Offer(SessionID int32) (offer string, err error) {
+	span := s.startSpan("Offer", SessionID)
+	defer func() { span.End(err) }()
+	defer wrapError(&err, "Offer", SessionID)
+	format, err := s.sdpFormat()
+	if err != nil {
+		return "", err
+	}
+	return s.offer(SessionID, format)
+}
+
+// OfferAs is Offer with an explicit output format
+func (s *SessionManagerImpl) OfferAs(SessionID int32, format util.SDPFormat) (offer string, err error) {
+	span := s.startSpan("OfferAs", SessionID)
+	defer func() { span.End(err) }()
+	defer wrapError(&err, "OfferAs", SessionID)
+	return s.offer(SessionID, format)
+}
+
+// offer is the body of Offer, OfferAs and OfferChunks, errors are wrapped by the caller
+func (s *SessionManagerImpl) offer(SessionID int32, format util.SDPFormat) (string, error) {
 	if s.discarded.Load() {
-		dbg.Println(dbg.MANAGER, ErrCall)
 		return "", ErrCall
 	}
 	s.mu.Lock()
@@ -72,35 +192,68 @@ func (s *SessionManagerImpl) Offer(SessionID int32) (string, error) {
 	if ready := session.OfferReady(); !ready {
 		return "", ErrWait
 	}
-	sdpBase64, err := session.Offer()
+	sdpBase64, err := session.Offer(format)
 	if err != nil {
 		return "", err
 	}
-	dbg.Println(dbg.MANAGER, "get offer: ", sdpBase64)
+	if sdpBase64, err = s.encodeOutput(sdpBase64); err != nil {
+		return "", err
+	}
+	s.logger().Debug(logs.MANAGER, "offer", "session", SessionID, s.logger().SDP("sdp", sdpBase64))
+	s.record(audit.Event{Event: audit.EventOffer, SessionID: SessionID, Role: session.Role})
 	return sdpBase64, nil
 }
 
-func (s *SessionManagerImpl) JoinSession(SessionID int32, sdpBase64 string) error {
+func (s *SessionManagerImpl) JoinSession(SessionID int32, sdpBase64 string) (err error) {
+	span := s.startSpan("JoinSession", SessionID)
+	defer func() { span.End(err) }()
+	defer wrapError(&err, "JoinSession", SessionID)
+	return s.join(SessionID, sdpBase64, span)
+}
+
+// join is the body of JoinSession and JoinSessionChunks, the negotiation is traced under span
+func (s *SessionManagerImpl) join(SessionID int32, sdpBase64 string, span trace.Span) error {
 	if s.discarded.Load() {
-		dbg.Println(dbg.MANAGER, ErrCall)
 		return ErrCall
 	}
+	offer, err := s.decodeInput(sdpBase64)
+	if err != nil {
+		return err
+	}
 	s.mu.Lock()
 	defer s.mu.Unlock()
 	if _, existed := s.sessionBook[SessionID]; existed {
-		dbg.Println(dbg.MANAGER, "SessionID repeated")
 		return ErrID
 	}
-	if err := s.joinSession(SessionID, sdpBase64); err != nil {
+	if err := s.joinSession(SessionID, offer, span); err != nil {
 		return err
 	}
-	dbg.Println(dbg.MANAGER, "join session: ", SessionID)
+	s.logger().Info(logs.MANAGER, "join session", "session", SessionID)
 	return nil
 }
 
-func (s *SessionManagerImpl) Answer(SessionID int32) (string, error) {
+func (s *SessionManagerImpl) Answer(SessionID int32) (answer string, err error) {
+	span := s.startSpan("Answer", SessionID)
+	defer func() { span.End(err) }()
+	defer wrapError(&err, "Answer", SessionID)
+	format, err := s.sdpFormat()
+	if err != nil {
+		return "", err
+	}
+	return s.answer(SessionID, format)
+}
+
+// AnswerAs is Answer with an explicit output format
+func (s *SessionManagerImpl) AnswerAs(SessionID int32, format util.SDPFormat) (answer string, err error) {
+	span := s.startSpan("AnswerAs", SessionID)
+	defer func() { span.End(err) }()
+	defer wrapError(&err, "AnswerAs", SessionID)
+	return s.answer(SessionID, format)
+}
+
+// answer is the body of Answer, AnswerAs and AnswerChunks, errors are wrapped by the caller
+func (s *SessionManagerImpl) answer(SessionID int32, format util.SDPFormat) (string, error) {
 	if s.discarded.Load() {
-		dbg.Println(dbg.MANAGER, ErrCall)
 		return "", ErrCall
 	}
 	s.mu.Lock()
@@ -112,35 +265,113 @@ func (s *SessionManagerImpl) Answer(SessionID int32) (string, error) {
 	if ready := session.OfferReady(); !ready {
 		return "", ErrWait
 	}
-	sdpBase64, err := session.Answer()
+	sdpBase64, err := session.Answer(format)
 	if err != nil {
 		return "", err
 	}
-	dbg.Println(dbg.MANAGER, "get answer: ", sdpBase64)
+	if sdpBase64, err = s.encodeOutput(sdpBase64); err != nil {
+		return "", err
+	}
+	s.logger().Debug(logs.MANAGER, "answer", "session", SessionID, s.logger().SDP("sdp", sdpBase64))
+	s.record(audit.Event{Event: audit.EventAnswer, SessionID: SessionID, Role: session.Role})
 	return sdpBase64, nil
 }
 
-func (s *SessionManagerImpl) ConfirmAnswer(SessionID int32, sdpBase64 string) error {
+func (s *SessionManagerImpl) ConfirmAnswer(SessionID int32, sdpBase64 string) (err error) {
+	span := s.startSpan("ConfirmAnswer", SessionID)
+	defer func() { span.End(err) }()
+	defer wrapError(&err, "ConfirmAnswer", SessionID)
+	return s.confirm(SessionID, sdpBase64, span)
+}
+
+// confirm is the body of ConfirmAnswer and ConfirmAnswerChunks, the channel open is traced under span
+func (s *SessionManagerImpl) confirm(SessionID int32, sdpBase64 string, span trace.Span) error {
 	if s.discarded.Load() {
-		dbg.Println(dbg.MANAGER, ErrCall)
 		return ErrCall
 	}
+	answer, err := s.decodeInput(sdpBase64)
+	if err != nil {
+		return err
+	}
 	s.mu.Lock()
 	defer s.mu.Unlock()
 	session, err := s.session(SessionID)
 	if err != nil {
 		return err
 	}
-	if err := session.ConfirmAnswer(sdpBase64); err != nil {
+	if err := session.ConfirmAnswer(answer, s.sdpLimits()); err != nil {
 		return err
 	}
-	dbg.Println(dbg.MANAGER, "confirm answer: ", sdpBase64)
+	opening := s.startPhase(span, spanChannelOpen, session)
+	session.opening.Store(&opening)
+	s.logger().Info(logs.MANAGER, "confirm answer", "session", SessionID)
+	s.record(audit.Event{Event: audit.EventConfirm, SessionID: SessionID, Role: session.Role})
+	s.logger().Debug(logs.MANAGER, "remote answer", "session", SessionID, s.logger().SDP("sdp", sdpBase64))
 	return nil
 }
 
-func (s *SessionManagerImpl) Send(SessionID int32, dAtA []byte) error {
+// OfferChunks is Offer split into parts of at most ChunkSize for size-limited channels
+func (s *SessionManagerImpl) OfferChunks(SessionID int32) (parts []string, err error) {
+	span := s.startSpan("OfferChunks", SessionID)
+	defer func() { span.End(err) }()
+	defer wrapError(&err, "OfferChunks", SessionID)
+	format, err := s.sdpFormat()
+	if err != nil {
+		return nil, err
+	}
+	offer, err := s.offer(SessionID, format)
+	if err != nil {
+		return nil, err
+	}
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	return s.split(offer)
+}
+
+// AnswerChunks is Answer split into parts of at most ChunkSize for size-limited channels
+func (s *SessionManagerImpl) AnswerChunks(SessionID int32) (parts []string, err error) {
+	span := s.startSpan("AnswerChunks", SessionID)
+	defer func() { span.End(err) }()
+	defer wrapError(&err, "AnswerChunks", SessionID)
+	format, err := s.sdpFormat()
+	if err != nil {
+		return nil, err
+	}
+	answer, err := s.answer(SessionID, format)
+	if err != nil {
+		return nil, err
+	}
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	return s.split(answer)
+}
+
+// JoinSessionChunks is JoinSession with the offer given as parts in any order
+func (s *SessionManagerImpl) JoinSessionChunks(SessionID int32, parts []string) (err error) {
+	span := s.startSpan("JoinSessionChunks", SessionID, slog.Int("parts", len(parts)))
+	defer func() { span.End(err) }()
+	defer wrapError(&err, "JoinSessionChunks", SessionID)
+	return s.join(SessionID, strings.Join(parts, "\n"), span)
+}
+
+// ConfirmAnswerChunks is ConfirmAnswer with the answer given as parts in any order
+func (s *SessionManagerImpl) ConfirmAnswerChunks(SessionID int32, parts []string) (err error) {
+	span := s.startSpan("ConfirmAnswerChunks", SessionID, slog.Int("parts", len(parts)))
+	defer func() { span.End(err) }()
+	defer wrapError(&err, "ConfirmAnswerChunks", SessionID)
+	return s.confirm(SessionID, strings.Join(parts, "\n"), span)
+}
+
+func (s *SessionManagerImpl) Send(SessionID int32, dAtA []byte) (err error) {
+	span := s.startSpan("Send", SessionID, slog.Int("bytes", len(dAtA)))
+	defer func() { span.End(err) }()
+	defer wrapError(&err, "Send", SessionID)
+	defer func() {
+		if err != nil {
+			s.metrics.sendErrors.Inc(errorLabel(err))
+		}
+	}()
 	if s.discarded.Load() {
-		dbg.Println(dbg.MANAGER, ErrCall)
 		return ErrCall
 	}
 	s.mu.Lock()
@@ -152,34 +383,67 @@ func (s *SessionManagerImpl) Send(SessionID int32, dAtA []byte) error {
 	if err = session.Send(dAtA); err != nil {
 		return err
 	}
-	dbg.Println(dbg.MANAGER, "send data: ", string(dAtA))
+	s.metrics.sentMessages.Inc()
+	s.metrics.sentBytes.Add(float64(len(dAtA)))
+	session.sent.Add(uint64(len(dAtA)))
+	s.capture(SessionID, session, record.Outbound, session.DataCh.Label(), dAtA)
+	s.logger().Debug(logs.MANAGER, "send", "session", SessionID, s.logger().Payload("data", dAtA))
 	return nil
 }
 
 func (s *SessionManagerImpl) Ready() ([]*pb.Ready, error) {
+	span := s.tracing().Start(nil, "Ready")
 	rlist := make([]*pb.Ready, 0)
 	for len(s.readyChannel) > 0 {
 		rlist = append(rlist, <-s.readyChannel)
 	}
-	dbg.Println(dbg.MANAGER, "get ready list: ", rlist)
+	span.SetAttributes(slog.Int("messages", len(rlist)))
+	span.End(nil)
+	if len(rlist) > 0 {
+		s.logger().Debug(logs.READY, "ready", "messages", len(rlist))
+	}
 	return rlist, nil
 }
 
-func (s *SessionManagerImpl) DropSession(SessionID int32) error {
+// WaitReady is Ready blocking until at least one message arrived or ctx is done
+func (s *SessionManagerImpl) WaitReady(ctx context.Context) (rlist []*pb.Ready, err error) {
+	span := s.tracing().Start(nil, "WaitReady")
+	defer func() {
+		span.SetAttributes(slog.Int("messages", len(rlist)))
+		span.End(err)
+	}()
+	select {
+	case ready := <-s.readyChannel:
+		rlist = []*pb.Ready{ready}
+		for len(s.readyChannel) > 0 {
+			rlist = append(rlist, <-s.readyChannel)
+		}
+		s.logger().Debug(logs.READY, "ready", "messages", len(rlist))
+		return rlist, nil
+	case <-ctx.Done():
+		return nil, ctx.Err()
+	}
+}
+
+func (s *SessionManagerImpl) DropSession(SessionID int32) (err error) {
+	span := s.startSpan("DropSession", SessionID)
+	defer func() { span.End(err) }()
+	defer wrapError(&err, "DropSession", SessionID)
 	if s.discarded.Load() {
-		dbg.Println(dbg.MANAGER, ErrCall)
 		return ErrCall
 	}
 	s.mu.Lock()
 	defer s.mu.Unlock()
-	s.dropSession(SessionID)
-	dbg.Println(dbg.MANAGER, "drop session: ", SessionID)
+	s.dropSession(SessionID, dropRequested)
+	s.logger().Info(logs.MANAGER, "drop session", "session", SessionID)
 	return nil
 }
 
-func (s *SessionManagerImpl) ReloadConfig(ConfPath string) error {
+func (s *SessionManagerImpl) ReloadConfig(ConfPath string) (err error) {
+	span := s.tracing().Start(nil, "ReloadConfig")
+	defer func() { span.End(err) }()
+	defer func() { err = managerError("ReloadConfig", err) }()
 	if s.discarded.Load() {
-		dbg.Println(dbg.MANAGER, ErrCall)
 		return ErrCall
 	}
 
@@ -187,28 +451,86 @@ func (s *SessionManagerImpl) ReloadConfig(ConfPath string) error {
 	if err != nil {
 		return err
 	}
+	certificate, err := s.loadCertificate(config)
+	if err != nil {
+		return err
+	}
+	if _, err = newAuthenticator(&config.Auth); err != nil {
+		s.logger().Error(logs.CONFIG, "auth config", "err", err)
+		return err
+	}
+	secret, err := s.newSecret(&config.Encryption)
+	if err != nil {
+		return err
+	}
+	envelope, err := s.newEnvelope(&config.Envelope)
+	if err != nil {
+		return err
+	}
 
 	s.mu.Lock()
 	defer s.mu.Unlock()
-	dbg.Println(dbg.MANAGER, "config reloaded")
+	// the server, the logger and the audit log are set up once with the manager, a reload does not
+	// start, reopen or reconfigure them, and a recording directory stays the one they were set up with
+	if !reflect.DeepEqual(config.TurnServer, s.config.TurnServer) {
+		s.warnRestart("TurnServer", ConfPath)
+		config.TurnServer = s.config.TurnServer
+	}
+	if !reflect.DeepEqual(config.Log, s.config.Log) {
+		s.warnRestart("Log", ConfPath)
+		config.Log = s.config.Log
+	}
+	if config.Audit != s.config.Audit {
+		s.warnRestart("Audit", ConfPath)
+		config.Audit = s.config.Audit
+	}
+	if config.Record != s.config.Record {
+		s.warnRestart("Record", ConfPath)
+		config.Record = s.config.Record
+	}
+	s.logger().Info(logs.CONFIG, "config reloaded", "path", ConfPath)
 	s.config = config
+	s.certificate = certificate
+	s.secret = secret
+	s.envelope = envelope
 	return nil
 }
 
+// warnRestart tell that the section of config is kept as it was when the manager was created
+func (s *SessionManagerImpl) warnRestart(section, ConfPath string) {
+	s.logger().Warn(logs.CONFIG, section+" changes take effect on restart, the running settings are kept", "path", ConfPath)
+}
+
 func (s *SessionManagerImpl) Discard() error {
+	span := s.tracing().Start(nil, "Discard")
+	defer span.End(nil)
 	s.discarded.Store(true)
-	dbg.Println(dbg.MANAGER, "discard manager")
+	if s.turnServer != nil {
+		if err := s.turnServer.Close(); err != nil {
+			s.logger().Warn(logs.MANAGER, "close turn server", "err", err)
+		}
+	}
+	s.logger().Info(logs.MANAGER, "discard manager")
 	return nil
 }
 
 func (s *SessionManagerImpl) lifeControl() {
+	// ReloadConfig may replace config meanwhile
+	s.mu.Lock()
 	timeout := time.Second * time.Duration(s.config.SessionLifeCycle)
+	s.mu.Unlock()
 	ticker := time.NewTicker(timeout)
+	defer ticker.Stop()
 	for {
 		select {
 		case <-ticker.C:
-			dbg.Println(dbg.MANAGER, "life control triggered")
+			s.logger().Debug(logs.MANAGER, "life control triggered")
 			s.mu.Lock()
+			// a reload takes effect on the next pass
+			if reloaded := time.Second * time.Duration(s.config.SessionLifeCycle); reloaded > 0 && reloaded != timeout {
+				timeout = reloaded
+				ticker.Reset(timeout)
+			}
 			deadSession := make([]int32, 0)
 			for SessionID, session := range s.sessionBook {
 				if time.Since(session.LastUsed) > timeout {
@@ -216,8 +538,8 @@ func (s *SessionManagerImpl) lifeControl() {
 				}
 			}
 			for _, sessionID := range deadSession {
-				s.dropSession(sessionID)
-				dbg.Println(dbg.MANAGER, "actively drop session:", sessionID)
+				s.dropSession(sessionID, dropInactive)
+				s.logger().Info(logs.MANAGER, "drop inactive session", "session", sessionID)
 			}
 			s.mu.Unlock()
 		default:
@@ -228,34 +550,61 @@ func (s *SessionManagerImpl) lifeControl() {
 					deadSession = append(deadSession, SessionID)
 				}
 				for _, sessionID := range deadSession {
-					s.dropSession(sessionID)
-					dbg.Println(dbg.MANAGER, "actively drop session:", sessionID)
+					s.dropSession(sessionID, dropDiscarded)
 				}
 				s.mu.Unlock()
+				// the last line, the configured output is closed after it
+				s.logger().Info(logs.MANAGER, "manager discarded", "dropped", len(deadSession))
+				s.closeOutputs()
 				return
 			}
 		}
 	}
 }
 
-func (s *SessionManagerImpl) dropSession(SessionID int32) {
+// dropSession close a session, reason is counted in the metrics, caller must hold mu
+func (s *SessionManagerImpl) dropSession(SessionID int32, reason string) {
 	session := s.sessionBook[SessionID]
 	if session == nil {
 		return
 	}
+	s.metrics.dropped.Inc(reason)
+	session.endOpening(ErrLost)
+	session.endGathering(ErrLost)
+	if session.Handshake != nil {
+		session.Handshake.Release()
+	}
+	s.record(audit.Event{
+		Event:         audit.EventDrop,
+		SessionID:     SessionID,
+		Role:          session.Role,
+		Reason:        reason,
+		BytesSent:     session.sent.Load(),
+		BytesReceived: session.received.Load(),
+		Duration:      time.Since(session.Created).Seconds(),
+	})
 	err := session.Connection.Close()
 	if err != nil {
-		dbg.Println(dbg.MANAGER, "drop session error: ", err)
+		s.logger().Warn(logs.SESSION, "close connection", "session", SessionID, "err", err)
 	}
+	if err = session.Recorder.Close(); err != nil {
+		s.logger().Warn(logs.SESSION, "close recording", "session", SessionID, "err", err)
+	}
+	// lines pion still writes afterwards are lost with a session file
+	_ = session.Log.Close()
 	delete(s.sessionBook, SessionID)
+	for _, f := range s.onDropped {
+		(*f)(SessionID)
+	}
 }
 
-func (s *SessionManagerImpl) initA(SessionID int32) error {
+// initA set up an offering session, its negotiation phases are traced under span
+func (s *SessionManagerImpl) initA(SessionID int32, span trace.Span) error {
 	// 1. passively drop session
 	if err := s.moniterLost(SessionID); err != nil {
 		return err
 	}
-	if err := s.reportCandidate(SessionID); err != nil {
+	if err := s.reportCandidate(SessionID, span); err != nil {
 		return err
 	}
 	// 2. create dataCh
@@ -263,25 +612,30 @@ func (s *SessionManagerImpl) initA(SessionID int32) error {
 		return err
 	}
 	// 3. set local state
-	if err := s.prepareOffer(SessionID); err != nil {
+	if err := s.prepareOffer(SessionID, span); err != nil {
 		return err
 	}
 	return nil
 }
 
-func (s *SessionManagerImpl) prepareOffer(SessionID int32) error {
+func (s *SessionManagerImpl) prepareOffer(SessionID int32, span trace.Span) error {
 	session := s.sessionBook[SessionID]
 	if session == nil {
 		return ErrLost
 	}
 
+	phase := s.startPhase(span, spanCreateOffer, session)
 	initOffer, err := session.Connection.CreateOffer(nil)
+	phase.End(err)
 	if err != nil {
-		dbg.Println(dbg.MANAGER, err)
+		session.Log.Error(logs.SESSION, "create offer", "err", err)
 		return err
 	}
-	if err = session.Connection.SetLocalDescription(initOffer); err != nil {
-		dbg.Println(dbg.MANAGER, err)
+	phase = s.startPhase(span, spanSetLocalDescription, session)
+	err = session.Connection.SetLocalDescription(initOffer)
+	phase.End(err)
+	if err != nil {
+		session.Log.Error(logs.SESSION, "set local description", "err", err)
 		return err
 	}
 	return nil
@@ -294,19 +648,20 @@ func (s *SessionManagerImpl) createDataCh(SessionID int32) error {
 	}
 	dataCh, err := session.Connection.CreateDataChannel("data", nil)
 	if err != nil {
-		dbg.Println(dbg.MANAGER, err)
+		session.Log.Error(logs.SESSION, "create data channel", "err", err)
 		return err
 	}
 	session.DataCh = dataCh
+	dataCh.OnOpen(func() {
+		session.endOpening(nil)
+		s.startHandshake(SessionID, session, dataCh)
+	})
 	dataCh.OnMessage(func(msg webrtc.DataChannelMessage) {
-		dbg.Println(dbg.SESSION, "[A] receive:", string(msg.Data))
-		if s.discarded.Load() {
+		session.Log.Debug(logs.SESSION, "receive", session.Log.Payload("data", msg.Data))
+		if s.discarded.Load() || !s.accept(SessionID, session, dataCh, msg.Data) {
 			return
 		}
-		s.readyChannel <- &pb.Ready{
-			SessionID: SessionID,
-			DAtA:      msg.Data,
-		}
+		s.deliver(SessionID, session, dataCh.Label(), msg.Data)
 	})
 	return nil
 }
@@ -317,7 +672,7 @@ func (s *SessionManagerImpl) moniterLost(SessionID int32) error {
 		return ErrLost
 	}
 	session.Connection.OnConnectionStateChange(func(connectionState webrtc.PeerConnectionState) {
-		dbg.Println(dbg.SESSION, "connection state changed:", connectionState)
+		session.Log.Info(logs.SESSION, "connection state changed", "state", connectionState.String())
 		if s.discarded.Load() {
 			return
 		}
@@ -325,46 +680,79 @@ func (s *SessionManagerImpl) moniterLost(SessionID int32) error {
 		case webrtc.PeerConnectionStateClosed, webrtc.PeerConnectionStateDisconnected, webrtc.PeerConnectionStateFailed:
 			s.mu.Lock()
 			defer s.mu.Unlock()
-			s.dropSession(SessionID)
-			dbg.Println(dbg.SESSION, "passively drop session", SessionID)
+			// the state of a closed connection arrives after its ID may have been reused
+			if s.sessionBook[SessionID] != session {
+				return
+			}
+			session.Log.Info(logs.SESSION, "drop lost session")
+			s.dropSession(SessionID, dropLost)
+		case webrtc.PeerConnectionStateConnected:
+			s.metrics.connect.Observe(time.Since(session.Created).Seconds(), session.Role)
+			s.recordConnect(SessionID, session)
+			if err := session.Verify(); errors.Is(err, ErrFingerprint) {
+				s.dropPeer(SessionID, session, err)
+			}
 		default:
 		}
 	})
 	return nil
 }
 
-func (s *SessionManagerImpl) joinSession(SessionID int32, sdpBase64 string) error {
-	if err := util.ValidateSDP(sdpBase64); err != nil {
-		dbg.Println(dbg.SESSION, err)
-		return err
-	}
-	offer, err := util.DecodeSDP(sdpBase64)
+// joinSession answer an offer already unwrapped by decodeInput, caller must hold mu
+func (s *SessionManagerImpl) joinSession(SessionID int32, sdpBase64 string, span trace.Span) error {
+	offer, err := util.ParseSDP(sdpBase64, webrtc.SDPTypeOffer, s.sdpLimits())
 	if err != nil {
-		dbg.Println(dbg.SESSION, err)
+		s.logger().Warn(logs.SESSION, "parse offer", "session", SessionID, "err", err)
 		return err
 	}
 	if _, existed := s.sessionBook[SessionID]; existed {
-		dbg.Println(dbg.MANAGER, "int repeated")
 		return ErrID
 	}
 
-	session, err := NewSession(&s.config.WebrtcConf)
+	webrtcConf, err := s.webrtcConf()
+	if err != nil {
+		return err
+	}
+	handshake, err := s.handshake(SessionID, roleAnswerer)
 	if err != nil {
 		return err
 	}
+	log, err := s.logger().Session(SessionID)
+	if err != nil {
+		s.logger().Error(logs.MANAGER, "open session log", "session", SessionID, "err", err)
+		return err
+	}
+	recorder, err := s.recorder(SessionID)
+	if err != nil {
+		_ = log.Close()
+		return err
+	}
+	session, err := NewSession(webrtcConf, log)
+	if err != nil {
+		_ = recorder.Close()
+		_ = log.Close()
+		return err
+	}
+	session.Recorder = recorder
+	session.Role = roleAnswerer
+	session.Pins = s.config.PinnedFingerprints
+	session.Handshake = handshake
 	s.sessionBook[SessionID] = session
-	if err = s.initB(SessionID, offer); err != nil {
+	if err = s.initB(SessionID, offer, span); err != nil {
 		return err
 	}
+	s.metrics.created.Inc(roleAnswerer)
+	s.record(audit.Event{Event: audit.EventJoin, SessionID: SessionID, Role: roleAnswerer})
 	return nil
 }
 
-func (s *SessionManagerImpl) initB(SessionID int32, offer *webrtc.SessionDescription) error {
+// initB set up an answering session, its negotiation phases are traced under span
+func (s *SessionManagerImpl) initB(SessionID int32, offer *webrtc.SessionDescription, span trace.Span) error {
 	// 1. passively drop session
 	if err := s.moniterLost(SessionID); err != nil {
 		return err
 	}
-	if err := s.reportCandidate(SessionID); err != nil {
+	if err := s.reportCandidate(SessionID, span); err != nil {
 		return err
 	}
 	// 2. transmit
@@ -372,29 +760,36 @@ func (s *SessionManagerImpl) initB(SessionID int32, offer *webrtc.SessionDescrip
 		return err
 	}
 	// 3. set sdp
-	if err := s.prepareAnswer(SessionID, offer); err != nil {
+	if err := s.prepareAnswer(SessionID, offer, span); err != nil {
 		return err
 	}
 	return nil
 }
 
-func (s *SessionManagerImpl) prepareAnswer(SessionID int32, offer *webrtc.SessionDescription) error {
+func (s *SessionManagerImpl) prepareAnswer(SessionID int32, offer *webrtc.SessionDescription, span trace.Span) error {
 	session := s.sessionBook[SessionID]
 	if session == nil {
-		dbg.Println(dbg.MANAGER, ErrLost)
 		return ErrLost
 	}
-	if err := session.Connection.SetRemoteDescription(*offer); err != nil {
-		dbg.Println(dbg.SESSION, err)
+	phase := s.startPhase(span, spanSetRemoteDescription, session)
+	err := session.Connection.SetRemoteDescription(*offer)
+	phase.End(err)
+	if err != nil {
+		session.Log.Warn(logs.SESSION, "set remote description", "err", err)
 		return err
 	}
+	phase = s.startPhase(span, spanCreateAnswer, session)
 	initAnswer, err := session.Connection.CreateAnswer(nil)
+	phase.End(err)
 	if err != nil {
-		dbg.Println(dbg.SESSION, err)
+		session.Log.Error(logs.SESSION, "create answer", "err", err)
 		return err
 	}
-	if err = session.Connection.SetLocalDescription(initAnswer); err != nil {
-		dbg.Println(dbg.SESSION, err)
+	phase = s.startPhase(span, spanSetLocalDescription, session)
+	err = session.Connection.SetLocalDescription(initAnswer)
+	phase.End(err)
+	if err != nil {
+		session.Log.Error(logs.SESSION, "set local description", "err", err)
 		return err
 	}
 	return nil
@@ -403,23 +798,25 @@ func (s *SessionManagerImpl) prepareAnswer(SessionID int32, offer *webrtc.Sessio
 func (s *SessionManagerImpl) waitDataCh(SessionID int32) error {
 	session := s.sessionBook[SessionID]
 	if session == nil {
-		dbg.Println(dbg.MANAGER, ErrLost)
 		return ErrLost
 	}
 	session.Connection.OnDataChannel(func(channel *webrtc.DataChannel) {
-		dbg.Println(dbg.ICE, "[B] get dataCh")
+		session.Log.Debug(logs.SESSION, "data channel received", "label", channel.Label())
 		if s.discarded.Load() {
 			return
 		}
 		s.mu.Lock()
 		defer s.mu.Unlock()
 		session.DataCh = channel
+		session.DataCh.OnOpen(func() {
+			s.startHandshake(SessionID, session, channel)
+		})
 		session.DataCh.OnMessage(func(msg webrtc.DataChannelMessage) {
-			dbg.Println(dbg.SESSION, "[B] receive message:", string(msg.Data))
-			s.readyChannel <- &pb.Ready{
-				SessionID: SessionID,
-				DAtA:      msg.Data,
+			session.Log.Debug(logs.SESSION, "receive", session.Log.Payload("data", msg.Data))
+			if !s.accept(SessionID, session, channel, msg.Data) {
+				return
 			}
+			s.deliver(SessionID, session, channel.Label(), msg.Data)
 		})
 	})
 	return nil
@@ -428,7 +825,7 @@ func (s *SessionManagerImpl) waitDataCh(SessionID int32) error {
 func (s *SessionManagerImpl) session(SessionID int32) (*Session, error) {
 	session := s.sessionBook[SessionID]
 	if session == nil {
-		dbg.Println(dbg.SESSION, ErrLost)
+		s.logger().Debug(logs.MANAGER, "unknown session", "session", SessionID)
 		return nil, ErrLost
 	}
 	session.RecentActive()
@@ -439,12 +836,267 @@ func (s *SessionManagerImpl) enableLifeControl() {
 	go s.lifeControl()
 }
 
-func (s *SessionManagerImpl) reportCandidate(SessionID int32) error {
+// reportCandidate log the candidates of a session, its gathering is traced under span
+func (s *SessionManagerImpl) reportCandidate(SessionID int32, span trace.Span) error {
 	session := s.sessionBook[SessionID]
 	if session == nil {
-		dbg.Println(dbg.MANAGER, ErrLost)
 		return ErrLost
 	}
 	session.ReportCandidate()
+	// the states are reported from the goroutines of pion
+	session.Connection.OnICEGatheringStateChange(func(state webrtc.ICEGatheringState) {
+		switch state {
+		case webrtc.ICEGatheringStateGathering:
+			phase := s.startPhase(span, spanGathering, session)
+			session.gathering.Store(&phase)
+			// dropSession may have run before pion reported the state
+			if session.Connection.ConnectionState() == webrtc.PeerConnectionStateClosed {
+				session.endGathering(ErrLost)
+			}
+		case webrtc.ICEGatheringStateComplete:
+			s.metrics.gathering.Observe(time.Since(session.Created).Seconds(), session.Role)
+			session.endGathering(nil)
+		default:
+		}
+	})
+	return nil
+}
+
+// record append e to the audit log, a failure does not stop the manager
+func (s *SessionManagerImpl) record(e audit.Event) {
+	if err := s.audit.Record(e); err != nil {
+		s.logger().Warn(logs.MANAGER, "audit", "event", e.Event, "session", e.SessionID, "err", err)
+	}
+}
+
+// recordConnect audit the selected candidate pair and the remote certificate of a connected session
+func (s *SessionManagerImpl) recordConnect(SessionID int32, session *Session) {
+	if s.audit == nil {
+		return
+	}
+	e := audit.Event{Event: audit.EventConnect, SessionID: SessionID, Role: session.Role}
+	dtls := session.Connection.SCTP().Transport()
+	if pair, err := dtls.ICETransport().GetSelectedCandidatePair(); err == nil && pair != nil {
+		e.LocalCandidate = candidateString(pair.Local)
+		e.RemoteCandidate = candidateString(pair.Remote)
+	}
+	if remote := dtls.GetRemoteCertificate(); len(remote) > 0 {
+		e.Fingerprint = util.Fingerprint(remote)
+	}
+	s.record(e)
+}
+
+// recorder start the recording of a new session, nil when Record is not configured, caller must hold mu
+func (s *SessionManagerImpl) recorder(SessionID int32) (*record.Writer, error) {
+	if s.config.Record == "" {
+		return nil, nil
+	}
+	recorder, err := record.Create(s.config.Record, SessionID)
+	if err != nil {
+		s.logger().Error(logs.MANAGER, "open recording", "session", SessionID, "err", err)
+		return nil, err
+	}
+	return recorder, nil
+}
+
+// capture append a message to the recording of session, a failure does not stop the session
+func (s *SessionManagerImpl) capture(SessionID int32, session *Session, direction record.Direction, channel string, data []byte) {
+	m := record.Message{Direction: direction, Channel: channel, Payload: data}
+	if err := session.Recorder.Write(m); err != nil {
+		s.logger().Warn(logs.SESSION, "record message", "session", SessionID, "err", err)
+	}
+}
+
+// closeOutputs close the log and audit files opened from the config
+func (s *SessionManagerImpl) closeOutputs() {
+	_ = s.audit.Close()
+	_ = s.ownLog.Close()
+}
+
+// deliver queue a message of channel for Ready, it blocks while CacheSize messages are already waiting
+func (s *SessionManagerImpl) deliver(SessionID int32, session *Session, channel string, data []byte) {
+	s.metrics.receivedMessages.Inc()
+	s.metrics.receivedBytes.Add(float64(len(data)))
+	session.received.Add(uint64(len(data)))
+	s.capture(SessionID, session, record.Inbound, channel, data)
+	ready := &pb.Ready{SessionID: SessionID, DAtA: data}
+	select {
+	case s.readyChannel <- ready:
+	default:
+		// a full queue holds the channel of the peer until Ready catches up
+		s.metrics.readyFull.Inc()
+		s.readyChannel <- ready
+	}
+}
+
+// webrtcConf return the configuration for a new session, with the embedded turn server
+// and the persistent certificate injected
+func (s *SessionManagerImpl) webrtcConf() (*webrtc.Configuration, error) {
+	webrtcConf := s.config.WebrtcConf
+	if s.turnServer != nil {
+		iceServers, err := s.turnServer.ICEServers()
+		if err != nil {
+			s.logger().Error(logs.ICE, "turn server credentials", "err", err)
+			return nil, err
+		}
+		webrtcConf.ICEServers = append(iceServers, webrtcConf.ICEServers...)
+	}
+	if s.certificate != nil {
+		webrtcConf.Certificates = []webrtc.Certificate{*s.certificate}
+	}
+	return &webrtcConf, nil
+}
+
+// accept tell whether a message of session may be delivered, handshake messages are consumed here
+// and a peer that is not pinned or fails authentication is dropped
+func (s *SessionManagerImpl) accept(SessionID int32, session *Session, channel *webrtc.DataChannel, msg []byte) bool {
+	if err := session.Verify(); err != nil {
+		if errors.Is(err, ErrFingerprint) {
+			// closing the connection from its own read loop would block
+			go s.dropPeer(SessionID, session, err)
+		}
+		return false
+	}
+	if session.Handshake == nil || session.Handshake.Passed() {
+		return true
+	}
+	if err := session.Handshake.Handle(msg, channel.Send); err != nil {
+		session.Handshake.Reject(err, channel.Send)
+		go s.dropPeer(SessionID, session, err)
+	}
+	return false
+}
+
+// startHandshake send our challenge when the channel opens, a peer that does not pass in time is dropped
+func (s *SessionManagerImpl) startHandshake(SessionID int32, session *Session, channel *webrtc.DataChannel) {
+	if session.Handshake == nil {
+		return
+	}
+	if err := session.Handshake.Start(channel.Send); err != nil {
+		go s.dropPeer(SessionID, session, err)
+		return
+	}
+	// a silent peer would keep the session waiting until the life control drops it
+	time.AfterFunc(session.Handshake.timeout, func() {
+		if !session.Handshake.Passed() {
+			s.dropPeer(SessionID, session, errHandshakeTimeout)
+		}
+	})
+}
+
+// dropPeer drop session for reason unless SessionID was reused in the meantime
+func (s *SessionManagerImpl) dropPeer(SessionID int32, session *Session, reason error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	if s.sessionBook[SessionID] != session {
+		return
+	}
+	session.Log.Warn(logs.SESSION, "peer rejected, dropping session", "reason", reason)
+	s.dropSession(SessionID, dropRejected)
+}
+
+// OnSessionDropped call f with the ID of every session the manager drops, requested or not,
+// f runs with the manager locked and must not call it, remove takes f out again
+func (s *SessionManagerImpl) OnSessionDropped(f func(SessionID int32)) (remove func()) {
+	handle := &f
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.onDropped = append(s.onDropped, handle)
+	return func() {
+		s.mu.Lock()
+		defer s.mu.Unlock()
+		for i, added := range s.onDropped {
+			if added == handle {
+				s.onDropped = append(s.onDropped[:i:i], s.onDropped[i+1:]...)
+				return
+			}
+		}
+	}
+}
+
+// SetAuthenticator run auth on every new session instead of the one configured in Auth,
+// nil restores the configured one
+func (s *SessionManagerImpl) SetAuthenticator(auth Authenticator) (err error) {
+	defer func() { err = managerError("SetAuthenticator", err) }()
+	if s.discarded.Load() {
+		return ErrCall
+	}
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.authenticator = auth
 	return nil
 }
+
+// SetLogger write the lines of the manager and of sessions created afterwards to l,
+// nil restores the one configured in Log
+func (s *SessionManagerImpl) SetLogger(l *logs.Logger) (err error) {
+	defer func() { err = managerError("SetLogger", err) }()
+	if s.discarded.Load() {
+		return ErrCall
+	}
+	if l == nil {
+		l = s.ownLog
+	}
+	s.log.Store(l)
+	return nil
+}
+
+// logger return the injected or configured Logger, logs.Default without either
+func (s *SessionManagerImpl) logger() *logs.Logger {
+	if l := s.log.Load(); l != nil {
+		return l
+	}
+	return logs.Default()
+}
+
+// handshake return the authentication state of a new session of role, nil when disabled, caller must hold mu
+func (s *SessionManagerImpl) handshake(SessionID int32, role string) (*Handshake, error) {
+	auth := s.authenticator
+	if auth == nil {
+		var err error
+		if auth, err = newAuthenticator(&s.config.Auth); err != nil {
+			s.logger().Error(logs.CONFIG, "auth config", "err", err)
+			return nil, err
+		}
+	}
+	if auth == nil {
+		return nil, nil
+	}
+	return newHandshake(SessionID, role, auth, &s.challenges, time.Duration(s.config.Auth.Timeout)*time.Second), nil
+}
+
+// loadCertificate return nil when no certificate is configured
+func (s *SessionManagerImpl) loadCertificate(config *conf.Configuration) (*webrtc.Certificate, error) {
+	if config.Certificate == "" {
+		return nil, nil
+	}
+	certificate, err := util.LoadCertificate(config.Certificate)
+	if err != nil {
+		s.logger().Error(logs.CONFIG, "load certificate", "path", config.Certificate, "err", err)
+		return nil, err
+	}
+	return certificate, nil
+}
+
+// sdpFormat return the configured output format of Offer and Answer
+func (s *SessionManagerImpl) sdpFormat() (util.SDPFormat, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	format, err := util.ParseSDPFormat(s.config.SDPFormat)
+	if err != nil {
+		s.logger().Error(logs.CONFIG, "sdp format", "format", s.config.SDPFormat, "err", err)
+	}
+	return format, err
+}
+
+// sdpLimits return the configured size limits of remote descriptions, caller must hold mu
+func (s *SessionManagerImpl) sdpLimits() util.Limits {
+	limits := util.DefaultLimits
+	if s.config.MaxEncodedSDP > 0 {
+		limits.MaxEncodedSize = s.config.MaxEncodedSDP
+	}
+	if s.config.MaxDecodedSDP > 0 {
+		limits.MaxDecodedSize = s.config.MaxDecodedSDP
+	}
+	return limits
+}
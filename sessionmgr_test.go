@@ -1,13 +1,56 @@
 package sessionmgr
 
 import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"errors"
 	"fmt"
+	"io"
+	"log/slog"
 	"math/rand"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"sessionmgr/audit"
 	"sessionmgr/dbg"
+	"sessionmgr/internal/testconf"
+	"sessionmgr/logs"
+	"sessionmgr/record"
+	"sessionmgr/trace"
+	"sessionmgr/util"
+	"strings"
+	"sync"
 	"testing"
 	"time"
 )
 
+// newTestManager create a manager from a temporary config, extra is merged into the top level JSON object
+func newTestManager(t *testing.T, extra string) *SessionManagerImpl {
+	// testmgr imports this package, only the config is shared
+	mgr, err := NewSessionManagerImpl(testconf.Write(t, extra))
+	if err != nil {
+		t.Fatal(err)
+	}
+	t.Cleanup(func() { _ = mgr.Discard() })
+	return mgr
+}
+
+// waitSDP retry Offer or Answer until ICE gathering is complete
+func waitSDP(t *testing.T, fn func() (string, error)) string {
+	deadline := time.Now().Add(10 * time.Second)
+	for {
+		sdp, err := fn()
+		if err == nil {
+			return sdp
+		}
+		if !errors.Is(err, ErrWait) || time.Now().After(deadline) {
+			t.Fatal(err)
+		}
+		time.Sleep(50 * time.Millisecond)
+	}
+}
+
 func TestLifeControl(t *testing.T) {
 	err := dbg.Init(dbg.STDOUT)
 	if err != nil {
@@ -61,6 +104,23 @@ func TestLifeControl(t *testing.T) {
 	}
 }
 
+func TestLifeControlReload(t *testing.T) {
+	mgr := newTestManager(t, `,"SessionLifeCycle":1`)
+	// let lifeControl start with the first lifetime
+	time.Sleep(100 * time.Millisecond)
+	if err := mgr.CreateSession(1); err != nil {
+		t.Fatal(err)
+	}
+	// a longer lifetime from a reload applies from the next pass
+	mgr.mu.Lock()
+	mgr.config.SessionLifeCycle = 3600
+	mgr.mu.Unlock()
+	time.Sleep(2500 * time.Millisecond)
+	if _, err := mgr.Inspect(1); err != nil {
+		t.Fatalf("expected session 1 to outlive the old lifetime, got %v", err)
+	}
+}
+
 func TestDiscard(t *testing.T) {
 	err := dbg.Init(dbg.STDOUT)
 	if err != nil {
@@ -86,3 +146,640 @@ func TestDiscard(t *testing.T) {
 		t.Errorf("expected %d sessions, got %d", 0, len(mgr.sessionBook))
 	}
 }
+
+func TestEnvelopeExchange(t *testing.T) {
+	envelope := `,"Envelope":{"Enable":true,"HMACKey":"secret","TTL":60,"Token":"t1"}`
+	offerer := newTestManager(t, envelope)
+	answerer := newTestManager(t, envelope)
+	plain := newTestManager(t, "")
+
+	if err := offerer.CreateSession(1); err != nil {
+		t.Fatal(err)
+	}
+	offer := waitSDP(t, func() (string, error) { return offerer.Offer(1) })
+	if !strings.HasPrefix(offer, "SME1.") {
+		t.Fatalf("offer is not signed: %v", offer)
+	}
+	if err := plain.JoinSession(1, offer); !errors.Is(err, ErrSdp) {
+		t.Errorf("expected manager without envelopes to reject, got %v", err)
+	}
+	tampered := offer[:len(offer)-4] + "AAAA"
+	if err := answerer.JoinSession(2, tampered); !errors.Is(err, ErrSdp) {
+		t.Errorf("expected tampered offer to be rejected, got %v", err)
+	}
+	if err := answerer.JoinSession(3, offer); err != nil {
+		t.Fatal(err)
+	}
+	answer := waitSDP(t, func() (string, error) { return answerer.Answer(3) })
+	if err := offerer.ConfirmAnswer(1, answer); err != nil {
+		t.Fatal(err)
+	}
+}
+
+func TestEncryptedExchange(t *testing.T) {
+	secured := `,"Encryption":{"Enable":true,"Passphrase":"pass"},"Envelope":{"Enable":true,"HMACKey":"secret"}`
+	offerer := newTestManager(t, secured)
+	answerer := newTestManager(t, secured)
+	plain := newTestManager(t, "")
+
+	if err := offerer.CreateSession(1); err != nil {
+		t.Fatal(err)
+	}
+	offer := waitSDP(t, func() (string, error) { return offerer.Offer(1) })
+	if !strings.HasPrefix(offer, "SMX1.") {
+		t.Fatalf("offer is not encrypted: %v", offer)
+	}
+	if err := plain.JoinSession(1, offer); !errors.Is(err, ErrSdp) {
+		t.Errorf("expected manager without encryption to reject, got %v", err)
+	}
+	if err := answerer.JoinSession(2, offer); err != nil {
+		t.Fatal(err)
+	}
+	answer := waitSDP(t, func() (string, error) { return answerer.Answer(2) })
+	if err := offerer.ConfirmAnswer(1, answer); err != nil {
+		t.Fatal(err)
+	}
+}
+
+// waitDelivery send until the channel is open and wait for the message on the other side
+func waitDelivery(t *testing.T, sender *SessionManagerImpl, senderID int32, receiver *SessionManagerImpl, receiverID int32) {
+	deadline := time.Now().Add(10 * time.Second)
+	for {
+		err := sender.Send(senderID, []byte("hello"))
+		if err == nil {
+			break
+		}
+		if !errors.Is(err, ErrWait) || time.Now().After(deadline) {
+			t.Fatal(err)
+		}
+		time.Sleep(50 * time.Millisecond)
+	}
+	for time.Now().Before(deadline) {
+		readys, _ := receiver.Ready()
+		for _, ready := range readys {
+			if ready.SessionID == receiverID && string(ready.DAtA) == "hello" {
+				return
+			}
+		}
+		time.Sleep(50 * time.Millisecond)
+	}
+	t.Fatal("message not delivered")
+}
+
+func TestSendBeforeChannel(t *testing.T) {
+	offerer := newTestManager(t, "")
+	answerer := newTestManager(t, "")
+	if err := offerer.CreateSession(1); err != nil {
+		t.Fatal(err)
+	}
+	offer := waitSDP(t, func() (string, error) { return offerer.Offer(1) })
+	if err := answerer.JoinSession(2, offer); err != nil {
+		t.Fatal(err)
+	}
+	// the answer side has no channel until the offerer connected and opened it
+	if err := answerer.Send(2, []byte("early")); !errors.Is(err, ErrWait) {
+		t.Errorf("expected ErrWait, got %v", err)
+	}
+}
+
+func TestCompactExchange(t *testing.T) {
+	offerer := newTestManager(t, `,"SDPFormat":"compact"`)
+	answerer := newTestManager(t, "")
+
+	if err := offerer.CreateSession(1); err != nil {
+		t.Fatal(err)
+	}
+	offer := waitSDP(t, func() (string, error) { return offerer.Offer(1) })
+	if !strings.HasPrefix(offer, "SMC1.") {
+		t.Fatalf("offer is not compact: %v", offer)
+	}
+	if err := answerer.JoinSession(2, offer); err != nil {
+		t.Fatal(err)
+	}
+	answer := waitSDP(t, func() (string, error) { return answerer.AnswerAs(2, util.FormatCompact) })
+	if err := offerer.ConfirmAnswer(1, answer); err != nil {
+		t.Fatal(err)
+	}
+	waitDelivery(t, offerer, 1, answerer, 2)
+}
+
+func TestChunkedExchange(t *testing.T) {
+	offerer := newTestManager(t, `,"ChunkSize":160`)
+	answerer := newTestManager(t, `,"ChunkSize":160`)
+
+	if err := offerer.CreateSession(1); err != nil {
+		t.Fatal(err)
+	}
+	var parts []string
+	waitSDP(t, func() (string, error) {
+		var err error
+		parts, err = offerer.OfferChunks(1)
+		return "", err
+	})
+	if len(parts) < 2 {
+		t.Fatalf("expected offer to be split, got %d parts", len(parts))
+	}
+	rand.Shuffle(len(parts), func(i, j int) { parts[i], parts[j] = parts[j], parts[i] })
+	if err := answerer.JoinSessionChunks(2, parts[1:]); !errors.Is(err, ErrSdp) {
+		t.Errorf("expected missing part to be rejected, got %v", err)
+	}
+	if err := answerer.JoinSessionChunks(2, parts); err != nil {
+		t.Fatal(err)
+	}
+	var answerParts []string
+	waitSDP(t, func() (string, error) {
+		var err error
+		answerParts, err = answerer.AnswerChunks(2)
+		return "", err
+	})
+	// parts pasted as one block are accepted as well
+	if err := offerer.ConfirmAnswer(1, strings.Join(answerParts, "\n")); err != nil {
+		t.Fatal(err)
+	}
+	waitDelivery(t, offerer, 1, answerer, 2)
+}
+
+func TestPinnedExchange(t *testing.T) {
+	dir := t.TempDir()
+	fingerprints := make([]string, 3)
+	paths := make([]string, 3)
+	for i := range paths {
+		paths[i] = filepath.Join(dir, fmt.Sprintf("cert%d.pem", i))
+		certificate, err := util.GenerateCertificate(paths[i])
+		if err != nil {
+			t.Fatal(err)
+		}
+		if fingerprints[i], err = util.CertificateFingerprint(certificate); err != nil {
+			t.Fatal(err)
+		}
+	}
+	pinned := func(cert int, pin int) string {
+		return fmt.Sprintf(`,"SDPFormat":"raw","Certificate":%q,"PinnedFingerprints":[%q]`, paths[cert], fingerprints[pin])
+	}
+	offerer := newTestManager(t, pinned(0, 1))
+	answerer := newTestManager(t, pinned(1, 0))
+	stranger := newTestManager(t, pinned(2, 0))
+
+	if err := offerer.CreateSession(1); err != nil {
+		t.Fatal(err)
+	}
+	offer := waitSDP(t, func() (string, error) { return offerer.Offer(1) })
+	if !strings.Contains(offer, "a=fingerprint:"+fingerprints[0]) {
+		t.Fatalf("offer does not use the persistent certificate: %v", offer)
+	}
+	if err := answerer.JoinSession(2, offer); err != nil {
+		t.Fatal(err)
+	}
+	answer := waitSDP(t, func() (string, error) { return answerer.Answer(2) })
+	if err := offerer.ConfirmAnswer(1, answer); err != nil {
+		t.Fatal(err)
+	}
+	waitDelivery(t, offerer, 1, answerer, 2)
+
+	// the stranger trusts the offerer, but the offerer has not pinned the stranger
+	if err := offerer.CreateSession(3); err != nil {
+		t.Fatal(err)
+	}
+	offer = waitSDP(t, func() (string, error) { return offerer.Offer(3) })
+	if err := stranger.JoinSession(4, offer); err != nil {
+		t.Fatal(err)
+	}
+	answer = waitSDP(t, func() (string, error) { return stranger.Answer(4) })
+	if err := offerer.ConfirmAnswer(3, answer); err != nil {
+		t.Fatal(err)
+	}
+	deadline := time.Now().Add(10 * time.Second)
+	for {
+		offerer.mu.Lock()
+		_, alive := offerer.sessionBook[3]
+		offerer.mu.Unlock()
+		if !alive {
+			break
+		}
+		if time.Now().After(deadline) {
+			t.Fatal("session with unpinned peer was not dropped")
+		}
+		time.Sleep(50 * time.Millisecond)
+	}
+}
+
+// syncBuffer is a bytes.Buffer safe for the goroutines of pion
+type syncBuffer struct {
+	mu  sync.Mutex
+	buf bytes.Buffer
+}
+
+func (b *syncBuffer) Write(p []byte) (int, error) {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+	return b.buf.Write(p)
+}
+
+func (b *syncBuffer) String() string {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+	return b.buf.String()
+}
+
+func TestInjectedLogger(t *testing.T) {
+	offerer := newTestManager(t, "")
+	answerer := newTestManager(t, "")
+	out := &syncBuffer{}
+	if err := answerer.SetLogger(logs.New(out, logs.Options{Level: slog.LevelDebug})); err != nil {
+		t.Fatal(err)
+	}
+
+	if err := offerer.CreateSession(1); err != nil {
+		t.Fatal(err)
+	}
+	offer := waitSDP(t, func() (string, error) { return offerer.Offer(1) })
+	if err := answerer.JoinSession(2, offer); err != nil {
+		t.Fatal(err)
+	}
+	answer := waitSDP(t, func() (string, error) { return answerer.Answer(2) })
+	if err := offerer.ConfirmAnswer(1, answer); err != nil {
+		t.Fatal(err)
+	}
+	waitDelivery(t, offerer, 1, answerer, 2)
+
+	lines := out.String()
+	for _, want := range []string{"topic=MANAGER", "msg=\"join session\"", "session=2", "bytes redacted", "scope=ice"} {
+		if !strings.Contains(lines, want) {
+			t.Errorf("expected %q in %q", want, lines)
+		}
+	}
+	if strings.Contains(lines, "hello") || strings.Contains(lines, "a=ice-pwd") {
+		t.Errorf("payload leaked in %q", lines)
+	}
+}
+
+func TestReloadKeepsStartupSettings(t *testing.T) {
+	mgr := newTestManager(t, "")
+	out := &syncBuffer{}
+	if err := mgr.SetLogger(logs.New(out, logs.Options{})); err != nil {
+		t.Fatal(err)
+	}
+	dir := t.TempDir()
+	path := testconf.Write(t, `,"SessionLifeCycle":300,"TurnServer":{"Enable":true,"ListenAddr":"127.0.0.1:0"},`+
+		`"Log":{"Level":"debug"},"Audit":`+fmt.Sprintf("%q", filepath.Join(dir, "audit.jsonl"))+`,"Record":`+fmt.Sprintf("%q", dir))
+	if err := mgr.ReloadConfig(path); err != nil {
+		t.Fatal(err)
+	}
+	for _, section := range []string{"TurnServer", "Log", "Audit", "Record"} {
+		if !strings.Contains(out.String(), section+" changes take effect on restart") {
+			t.Errorf("expected a warning for %v in %q", section, out.String())
+		}
+	}
+	mgr.mu.Lock()
+	defer mgr.mu.Unlock()
+	if mgr.config.TurnServer.Enable || mgr.config.Log != nil || mgr.config.Audit != "" || mgr.config.Record != "" ||
+		mgr.config.SessionLifeCycle != 300 {
+		t.Errorf("expected the other settings only, got %+v", mgr.config)
+	}
+}
+
+func TestEnvelopeConfigChecked(t *testing.T) {
+	mgr := newTestManager(t, "")
+	for _, envelope := range []string{
+		`{"Enable":true}`,
+		`{"Enable":true,"Ed25519PrivateKey":"not a key"}`,
+		`{"Enable":true,"HMACKey":"secret","Ed25519PublicKeys":["not a key"]}`,
+	} {
+		path := testconf.Write(t, `,"Envelope":`+envelope)
+		if _, err := NewSessionManagerImpl(path); err == nil {
+			t.Errorf("expected %s to be refused at load", envelope)
+		}
+		if err := mgr.ReloadConfig(path); err == nil {
+			t.Errorf("expected %s to be refused at reload", envelope)
+		}
+	}
+	mgr.mu.Lock()
+	defer mgr.mu.Unlock()
+	if mgr.config.Envelope.Enable || mgr.envelope != nil {
+		t.Errorf("expected the running envelope settings to be kept")
+	}
+}
+
+func TestOnSessionDropped(t *testing.T) {
+	mgr := newTestManager(t, "")
+	var first, second []int32
+	remove := mgr.OnSessionDropped(func(SessionID int32) { first = append(first, SessionID) })
+	mgr.OnSessionDropped(func(SessionID int32) { second = append(second, SessionID) })
+	for _, SessionID := range []int32{1, 2} {
+		if err := mgr.CreateSession(SessionID); err != nil {
+			t.Fatal(err)
+		}
+		if err := mgr.DropSession(SessionID); err != nil {
+			t.Fatal(err)
+		}
+		if SessionID == 1 {
+			remove()
+		}
+	}
+	if fmt.Sprint(first) != "[1]" || fmt.Sprint(second) != "[1 2]" {
+		t.Errorf("expected [1] and [1 2], got %v and %v", first, second)
+	}
+}
+
+func TestEnvLevelsWithoutLogConfig(t *testing.T) {
+	t.Setenv(logs.EnvVar, "warn,ICE=debug")
+	mgr := newTestManager(t, "")
+	level, topics := mgr.Logger().Levels()
+	if level != slog.LevelWarn || topics[logs.ICE] != slog.LevelDebug {
+		t.Errorf("expected the levels of %v, got %v %v", logs.EnvVar, level, topics)
+	}
+}
+
+func TestMetrics(t *testing.T) {
+	offerer := newTestManager(t, "")
+	answerer := newTestManager(t, "")
+	if err := offerer.Send(1, []byte("nobody")); !errors.Is(err, ErrLost) {
+		t.Fatalf("expected ErrLost, got %v", err)
+	}
+	if err := offerer.CreateSession(1); err != nil {
+		t.Fatal(err)
+	}
+	offer := waitSDP(t, func() (string, error) { return offerer.Offer(1) })
+	if err := answerer.JoinSession(2, offer); err != nil {
+		t.Fatal(err)
+	}
+	answer := waitSDP(t, func() (string, error) { return answerer.Answer(2) })
+	if err := offerer.ConfirmAnswer(1, answer); err != nil {
+		t.Fatal(err)
+	}
+	waitDelivery(t, offerer, 1, answerer, 2)
+	if err := offerer.DropSession(1); err != nil {
+		t.Fatal(err)
+	}
+
+	scrape := func(mgr *SessionManagerImpl) string {
+		rec := httptest.NewRecorder()
+		mgr.MetricsHandler().ServeHTTP(rec, httptest.NewRequest("GET", "/metrics", nil))
+		return rec.Body.String()
+	}
+	offererMetrics, answererMetrics := scrape(offerer), scrape(answerer)
+	for _, want := range []string{
+		`sessionmgr_sessions_created_total{role="offerer"} 1`,
+		`sessionmgr_sessions_dropped_total{reason="requested"} 1`,
+		`sessionmgr_send_errors_total{error="lost"} 1`,
+		`sessionmgr_sent_bytes_total 5`,
+		`sessionmgr_ice_gathering_seconds_count{role="offerer"} 1`,
+	} {
+		if !strings.Contains(offererMetrics, want) {
+			t.Errorf("expected %q in\n%s", want, offererMetrics)
+		}
+	}
+	for _, want := range []string{
+		`sessionmgr_sessions{role="answerer",state="connected"} 1`,
+		`sessionmgr_received_messages_total 1`,
+		`sessionmgr_ready_queue_depth 0`,
+		`sessionmgr_connect_seconds_count{role="answerer"} 1`,
+	} {
+		if !strings.Contains(answererMetrics, want) {
+			t.Errorf("expected %q in\n%s", want, answererMetrics)
+		}
+	}
+}
+
+func TestReadyQueueFull(t *testing.T) {
+	mgr := newTestManager(t, `,"CacheSize":1`)
+	session := &Session{}
+	mgr.deliver(1, session, "data", []byte("first"))
+	delivered := make(chan struct{})
+	go func() {
+		mgr.deliver(1, session, "data", []byte("second"))
+		close(delivered)
+	}()
+	scrape := func() string {
+		rec := httptest.NewRecorder()
+		mgr.MetricsHandler().ServeHTTP(rec, httptest.NewRequest("GET", "/metrics", nil))
+		return rec.Body.String()
+	}
+	deadline := time.Now().Add(5 * time.Second)
+	for !strings.Contains(scrape(), "sessionmgr_ready_queue_full_total 1") {
+		if time.Now().After(deadline) {
+			t.Fatalf("expected a full queue to be counted in\n%s", scrape())
+		}
+		time.Sleep(10 * time.Millisecond)
+	}
+	select {
+	case <-delivered:
+		t.Fatal("delivery did not wait for room")
+	default:
+	}
+	// the blocked message is queued once Ready makes room, nothing is lost
+	var got []string
+	for len(got) < 2 {
+		rlist, err := mgr.WaitReady(context.Background())
+		if err != nil {
+			t.Fatal(err)
+		}
+		for _, ready := range rlist {
+			got = append(got, string(ready.DAtA))
+		}
+	}
+	<-delivered
+	if got[0] != "first" || got[1] != "second" {
+		t.Errorf("unexpected messages %v", got)
+	}
+}
+
+func TestAudit(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "audit.jsonl")
+	offerer := newTestManager(t, fmt.Sprintf(`,"Audit":%q`, path))
+	answerer := newTestManager(t, "")
+	if err := offerer.CreateSession(1); err != nil {
+		t.Fatal(err)
+	}
+	offer := waitSDP(t, func() (string, error) { return offerer.Offer(1) })
+	if err := answerer.JoinSession(2, offer); err != nil {
+		t.Fatal(err)
+	}
+	answer := waitSDP(t, func() (string, error) { return answerer.Answer(2) })
+	if err := offerer.ConfirmAnswer(1, answer); err != nil {
+		t.Fatal(err)
+	}
+	waitDelivery(t, offerer, 1, answerer, 2)
+	if err := offerer.DropSession(1); err != nil {
+		t.Fatal(err)
+	}
+
+	data, err := os.ReadFile(path)
+	if err != nil {
+		t.Fatal(err)
+	}
+	events := make(map[string]audit.Event)
+	for _, line := range strings.Split(strings.TrimSpace(string(data)), "\n") {
+		var e audit.Event
+		if err = json.Unmarshal([]byte(line), &e); err != nil {
+			t.Fatal(err)
+		}
+		events[e.Event] = e
+	}
+	for _, kind := range []string{audit.EventCreate, audit.EventOffer, audit.EventConfirm, audit.EventConnect, audit.EventDrop} {
+		if events[kind].SessionID != 1 {
+			t.Errorf("missing %v event in %s", kind, data)
+		}
+	}
+	if connect := events[audit.EventConnect]; connect.RemoteCandidate == "" || !strings.HasPrefix(connect.Fingerprint, "sha-256 ") {
+		t.Errorf("unexpected connect event %+v", connect)
+	}
+	if drop := events[audit.EventDrop]; drop.Reason != "requested" || drop.BytesSent != 5 || drop.Duration <= 0 {
+		t.Errorf("unexpected drop event %+v", drop)
+	}
+}
+
+func TestTracer(t *testing.T) {
+	offerer := newTestManager(t, "")
+	answerer := newTestManager(t, "")
+	recorder := trace.NewRecorder()
+	if err := offerer.SetTracer(recorder); err != nil {
+		t.Fatal(err)
+	}
+	if err := offerer.CreateSession(1); err != nil {
+		t.Fatal(err)
+	}
+	offer := waitSDP(t, func() (string, error) { return offerer.Offer(1) })
+	if err := answerer.JoinSession(2, offer); err != nil {
+		t.Fatal(err)
+	}
+	answer := waitSDP(t, func() (string, error) { return answerer.Answer(2) })
+	if err := offerer.ConfirmAnswer(1, answer); err != nil {
+		t.Fatal(err)
+	}
+	waitDelivery(t, offerer, 1, answerer, 2)
+
+	created := recorder.Find("CreateSession")
+	if len(created) != 1 || created[0].Err != nil || created[0].Attr("session") != int64(1) {
+		t.Fatalf("unexpected CreateSession spans %+v", created)
+	}
+	for _, phase := range []string{spanCreateOffer, spanSetLocalDescription, spanGathering} {
+		spans := recorder.Find(phase)
+		if len(spans) != 1 || spans[0].ParentID != created[0].ID || spans[0].Attr("role") != roleOfferer {
+			t.Errorf("unexpected %v spans %+v", phase, spans)
+		}
+	}
+	confirmed := recorder.Find("ConfirmAnswer")
+	if len(confirmed) != 1 {
+		t.Fatalf("unexpected ConfirmAnswer spans %+v", confirmed)
+	}
+	// OnOpen may still be running when the first message is sent
+	deadline := time.Now().Add(time.Second)
+	for len(recorder.Find(spanChannelOpen)) == 0 && time.Now().Before(deadline) {
+		time.Sleep(10 * time.Millisecond)
+	}
+	if opened := recorder.Find(spanChannelOpen); len(opened) != 1 || opened[0].ParentID != confirmed[0].ID || opened[0].Err != nil {
+		t.Errorf("unexpected channel open spans %+v", opened)
+	}
+	sent := recorder.Find("Send")
+	if last := sent[len(sent)-1]; last.Err != nil || last.Attr("bytes") != int64(5) {
+		t.Errorf("unexpected Send span %+v", last)
+	}
+	if err := offerer.Send(3, nil); err == nil {
+		t.Fatal("expected an error")
+	}
+	if failed := recorder.Find("Send"); !errors.Is(failed[len(failed)-1].Err, ErrLost) {
+		t.Errorf("expected ErrLost, got %+v", failed[len(failed)-1])
+	}
+	if len(recorder.Find("Offer")) == 0 {
+		t.Error("expected Offer spans")
+	}
+	if _, err := offerer.Ready(); err != nil {
+		t.Fatal(err)
+	}
+	if ready := recorder.Find("Ready"); len(ready) != 1 || ready[0].Attr("messages") != int64(0) {
+		t.Errorf("unexpected Ready spans %+v", ready)
+	}
+
+	// the public method is the Op even when it shares its body with another
+	for op, call := range map[string]func() error{
+		"Offer":        func() error { _, err := offerer.Offer(3); return err },
+		"OfferChunks":  func() error { _, err := offerer.OfferChunks(3); return err },
+		"Answer":       func() error { _, err := offerer.Answer(3); return err },
+		"AnswerChunks": func() error { _, err := offerer.AnswerChunks(3); return err },
+	} {
+		var e *Error
+		if err := call(); !errors.As(err, &e) || e.Op != op || !errors.Is(err, ErrLost) {
+			t.Errorf("expected ErrLost from %v, got %v", op, err)
+		}
+		if spans := recorder.Find(op); !errors.Is(spans[len(spans)-1].Err, ErrLost) {
+			t.Errorf("expected ErrLost span for %v, got %+v", op, spans[len(spans)-1])
+		}
+	}
+	if _, err := offerer.Sessions(); err != nil {
+		t.Fatal(err)
+	}
+	if listed := recorder.Find("Sessions"); len(listed) != 1 || listed[0].Attr("sessions") != int64(1) {
+		t.Errorf("unexpected Sessions spans %+v", listed)
+	}
+	_, _ = offerer.Inspect(3)
+	_, _ = offerer.SessionStats(3)
+	for _, op := range []string{"Inspect", "SessionStats"} {
+		if spans := recorder.Find(op); len(spans) != 1 || !errors.Is(spans[0].Err, ErrLost) || spans[0].Attr("session") != int64(3) {
+			t.Errorf("unexpected %v spans %+v", op, spans)
+		}
+	}
+	if err := offerer.Discard(); err != nil {
+		t.Fatal(err)
+	}
+	if len(recorder.Find("Discard")) != 1 {
+		t.Error("expected a Discard span")
+	}
+}
+
+func TestRecord(t *testing.T) {
+	dir := t.TempDir()
+	offerer := newTestManager(t, fmt.Sprintf(`,"Record":%q`, dir))
+	answerer := newTestManager(t, fmt.Sprintf(`,"Record":%q`, dir))
+	if err := offerer.CreateSession(1); err != nil {
+		t.Fatal(err)
+	}
+	offer := waitSDP(t, func() (string, error) { return offerer.Offer(1) })
+	if err := answerer.JoinSession(2, offer); err != nil {
+		t.Fatal(err)
+	}
+	answer := waitSDP(t, func() (string, error) { return answerer.Answer(2) })
+	if err := offerer.ConfirmAnswer(1, answer); err != nil {
+		t.Fatal(err)
+	}
+	waitDelivery(t, offerer, 1, answerer, 2)
+	if err := offerer.DropSession(1); err != nil {
+		t.Fatal(err)
+	}
+	if err := answerer.DropSession(2); err != nil {
+		t.Fatal(err)
+	}
+
+	messages := func(SessionID int32) []record.Message {
+		paths, _ := filepath.Glob(filepath.Join(dir, fmt.Sprintf("session-%d-*.rec", SessionID)))
+		if len(paths) != 1 {
+			t.Fatalf("expected one recording of session %d, got %v", SessionID, paths)
+		}
+		file, err := os.Open(paths[0])
+		if err != nil {
+			t.Fatal(err)
+		}
+		defer file.Close()
+		r, err := record.NewReader(file)
+		if err != nil {
+			t.Fatal(err)
+		}
+		var messages []record.Message
+		for {
+			m, err := r.Next()
+			if errors.Is(err, io.EOF) {
+				return messages
+			}
+			if err != nil {
+				t.Fatal(err)
+			}
+			messages = append(messages, m)
+		}
+	}
+	if sent := messages(1); len(sent) != 1 || sent[0].Direction != record.Outbound || sent[0].Channel != "data" || string(sent[0].Payload) != "hello" {
+		t.Errorf("unexpected offerer recording %+v", sent)
+	}
+	if received := messages(2); len(received) != 1 || received[0].Direction != record.Inbound || string(received[0].Payload) != "hello" {
+		t.Errorf("unexpected answerer recording %+v", received)
+	}
+}
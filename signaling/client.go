@@ -0,0 +1,122 @@
+package signaling
+
+import (
+	"context"
+	"golang.org/x/net/websocket"
+	"net/url"
+	"sessionmgr"
+	"strings"
+)
+
+// Client drives a SessionManager through a signaling Server
+type Client struct {
+	// URL is the base address of the server, e.g. "http://127.0.0.1:8080"
+	URL string
+}
+
+func NewClient(URL string) *Client {
+	return &Client{URL: strings.TrimSuffix(URL, "/")}
+}
+
+// Offer create a session, publish its offer in room and confirm the answer coming back
+func (c *Client) Offer(ctx context.Context, mgr sessionmgr.SessionManager, SessionID int32, room string) error {
+	signaler := c.Signaler(room)
+	defer signaler.Close()
+	return Connect(ctx, mgr, signaler, Offerer, SessionID)
+}
+
+// Answer wait for an offer in room, join the session and publish the answer
+func (c *Client) Answer(ctx context.Context, mgr sessionmgr.SessionManager, SessionID int32, room string) error {
+	signaler := c.Signaler(room)
+	defer signaler.Close()
+	return Connect(ctx, mgr, signaler, Answerer, SessionID)
+}
+
+// Signaler return a Signaler bound to room, it must be closed after use
+func (c *Client) Signaler(room string) *WebSocketSignaler {
+	return &WebSocketSignaler{client: c, room: room}
+}
+
+// WebSocketSignaler exchange descriptions through the websocket of a room
+type WebSocketSignaler struct {
+	client *Client
+	room   string
+	ws     *websocket.Conn
+}
+
+func (s *WebSocketSignaler) PublishOffer(ctx context.Context, offer string) error {
+	if err := s.dial(ctx, "offerer"); err != nil {
+		return err
+	}
+	return websocket.JSON.Send(s.ws, Message{Type: TypeOffer, SDP: offer})
+}
+
+func (s *WebSocketSignaler) AwaitAnswer(ctx context.Context) (string, error) {
+	if s.ws == nil {
+		return "", ErrRole
+	}
+	return receive(ctx, s.ws, TypeAnswer)
+}
+
+func (s *WebSocketSignaler) AwaitOffer(ctx context.Context) (string, error) {
+	if err := s.dial(ctx, "answerer"); err != nil {
+		return "", err
+	}
+	return receive(ctx, s.ws, TypeOffer)
+}
+
+func (s *WebSocketSignaler) PublishAnswer(ctx context.Context, answer string) error {
+	if s.ws == nil {
+		return ErrRole
+	}
+	return websocket.JSON.Send(s.ws, Message{Type: TypeAnswer, SDP: answer})
+}
+
+func (s *WebSocketSignaler) Close() error {
+	if s.ws == nil {
+		return nil
+	}
+	return s.ws.Close()
+}
+
+func (s *WebSocketSignaler) dial(ctx context.Context, role string) error {
+	if s.ws != nil {
+		return ErrRole
+	}
+	u, err := url.Parse(s.client.URL)
+	if err != nil {
+		return err
+	}
+	origin := u.String()
+	switch u.Scheme {
+	case "https":
+		u.Scheme = "wss"
+	default:
+		u.Scheme = "ws"
+	}
+	u = u.JoinPath("rooms", s.room, "ws")
+	u.RawQuery = url.Values{"role": {role}}.Encode()
+	config, err := websocket.NewConfig(u.String(), origin)
+	if err != nil {
+		return err
+	}
+	s.ws, err = config.DialContext(ctx)
+	return err
+}
+
+// receive wait for a message of type kind, the connection is closed when ctx is done
+func receive(ctx context.Context, ws *websocket.Conn, kind string) (string, error) {
+	stop := context.AfterFunc(ctx, func() { _ = ws.Close() })
+	defer stop()
+	var msg Message
+	if err := websocket.JSON.Receive(ws, &msg); err != nil {
+		if ctx.Err() != nil {
+			return "", ctx.Err()
+		}
+		return "", err
+	}
+	if msg.Type != kind {
+		return "", ErrRoom
+	}
+	return msg.SDP, nil
+}
@@ -0,0 +1,88 @@
+package signaling
+
+import (
+	"context"
+	"errors"
+	"os"
+	"path/filepath"
+	"strings"
+	"time"
+)
+
+// FileSignaler drop descriptions as files in a shared directory
+//
+// The offer is written to <Dir>/<Name>.offer and the answer to <Dir>/<Name>.answer,
+// a file is removed once it has been picked up.
+type FileSignaler struct {
+	Dir  string
+	Name string
+	// PollInterval is how often the directory is checked, default 50ms
+	PollInterval time.Duration
+}
+
+func NewFileSignaler(Dir, Name string) *FileSignaler {
+	return &FileSignaler{Dir: Dir, Name: Name, PollInterval: pollInterval}
+}
+
+func (s *FileSignaler) PublishOffer(ctx context.Context, offer string) error {
+	return s.publish(TypeOffer, offer)
+}
+
+func (s *FileSignaler) AwaitAnswer(ctx context.Context) (string, error) {
+	return s.await(ctx, TypeAnswer)
+}
+
+func (s *FileSignaler) AwaitOffer(ctx context.Context) (string, error) {
+	return s.await(ctx, TypeOffer)
+}
+
+func (s *FileSignaler) PublishAnswer(ctx context.Context, answer string) error {
+	return s.publish(TypeAnswer, answer)
+}
+
+func (s *FileSignaler) path(kind string) string {
+	return filepath.Join(s.Dir, s.Name+"."+kind)
+}
+
+// publish write to a temporary file first so the reader never sees a partial description
+func (s *FileSignaler) publish(kind, sdp string) error {
+	tmp, err := os.CreateTemp(s.Dir, s.Name+"."+kind+".tmp*")
+	if err != nil {
+		return err
+	}
+	if _, err = tmp.WriteString(sdp); err != nil {
+		_ = tmp.Close()
+		_ = os.Remove(tmp.Name())
+		return err
+	}
+	if err = tmp.Close(); err != nil {
+		_ = os.Remove(tmp.Name())
+		return err
+	}
+	return os.Rename(tmp.Name(), s.path(kind))
+}
+
+func (s *FileSignaler) await(ctx context.Context, kind string) (string, error) {
+	interval := s.PollInterval
+	if interval <= 0 {
+		interval = pollInterval
+	}
+	path := s.path(kind)
+	for {
+		data, err := os.ReadFile(path)
+		if err == nil {
+			if err = os.Remove(path); err != nil {
+				return "", err
+			}
+			return strings.TrimSpace(string(data)), nil
+		}
+		if !errors.Is(err, os.ErrNotExist) {
+			return "", err
+		}
+		select {
+		case <-ctx.Done():
+			return "", ctx.Err()
+		case <-time.After(interval):
+		}
+	}
+}
@@ -0,0 +1,50 @@
+package signaling
+
+import "context"
+
+// MemorySignaler connect two sides in the same process, both sides share one instance
+type MemorySignaler struct {
+	offers  chan string
+	answers chan string
+}
+
+func NewMemorySignaler() *MemorySignaler {
+	return &MemorySignaler{
+		offers:  make(chan string, 1),
+		answers: make(chan string, 1),
+	}
+}
+
+func (s *MemorySignaler) PublishOffer(ctx context.Context, offer string) error {
+	return send(ctx, s.offers, offer)
+}
+
+func (s *MemorySignaler) AwaitAnswer(ctx context.Context) (string, error) {
+	return recv(ctx, s.answers)
+}
+
+func (s *MemorySignaler) AwaitOffer(ctx context.Context) (string, error) {
+	return recv(ctx, s.offers)
+}
+
+func (s *MemorySignaler) PublishAnswer(ctx context.Context, answer string) error {
+	return send(ctx, s.answers, answer)
+}
+
+func send(ctx context.Context, ch chan<- string, sdp string) error {
+	select {
+	case ch <- sdp:
+		return nil
+	case <-ctx.Done():
+		return ctx.Err()
+	}
+}
+
+func recv(ctx context.Context, ch <-chan string) (string, error) {
+	select {
+	case sdp := <-ch:
+		return sdp, nil
+	case <-ctx.Done():
+		return "", ctx.Err()
+	}
+}
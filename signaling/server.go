@@ -0,0 +1,239 @@
+package signaling
+
+import (
+	"errors"
+	"golang.org/x/net/websocket"
+	"io"
+	"net/http"
+	"sessionmgr/logs"
+	"sync"
+	"time"
+)
+
+var ErrRoom = errors.New("room invalid")
+var ErrPosted = errors.New("description already posted")
+
+const (
+	TypeOffer  = "offer"
+	TypeAnswer = "answer"
+)
+
+const defaultRoomTTL = 5 * time.Minute
+const maxBodySize = 1 << 20
+
+// Message is exchanged over the websocket
+type Message struct {
+	Type string `json:"type"`
+	SDP  string `json:"sdp"`
+}
+
+// room holds the descriptions of a single offer/answer exchange
+type room struct {
+	offer       string
+	answer      string
+	offerReady  chan struct{}
+	answerReady chan struct{}
+	created     time.Time
+}
+
+func newRoom() *room {
+	return &room{
+		offerReady:  make(chan struct{}),
+		answerReady: make(chan struct{}),
+		created:     time.Now(),
+	}
+}
+
+// Server is an HTTP/WebSocket rendezvous for offers and answers
+//
+//	POST /rooms/{room}/offer    store the offer
+//	GET  /rooms/{room}/offer    wait for the offer
+//	POST /rooms/{room}/answer   store the answer
+//	GET  /rooms/{room}/answer   wait for the answer
+//	GET  /rooms/{room}/ws?role=offerer|answerer
+type Server struct {
+	// RoomTTL is how long an unfinished room is kept
+	RoomTTL time.Duration
+	// WaitTimeout bounds a single GET long poll
+	WaitTimeout time.Duration
+
+	mu    sync.Mutex
+	rooms map[string]*room
+	mux   *http.ServeMux
+}
+
+func NewServer() *Server {
+	s := &Server{
+		RoomTTL:     defaultRoomTTL,
+		WaitTimeout: 30 * time.Second,
+		rooms:       make(map[string]*room),
+		mux:         http.NewServeMux(),
+	}
+	s.mux.HandleFunc("POST /rooms/{room}/{kind}", s.handlePost)
+	s.mux.HandleFunc("GET /rooms/{room}/ws", s.handleWebSocket)
+	s.mux.HandleFunc("GET /rooms/{room}/{kind}", s.handleGet)
+	return s
+}
+
+func (s *Server) ServeHTTP(w http.ResponseWriter, r *http.Request) {
+	s.mux.ServeHTTP(w, r)
+}
+
+func (s *Server) handlePost(w http.ResponseWriter, r *http.Request) {
+	kind := r.PathValue("kind")
+	if kind != TypeOffer && kind != TypeAnswer {
+		http.NotFound(w, r)
+		return
+	}
+	body, err := io.ReadAll(http.MaxBytesReader(w, r.Body, maxBodySize))
+	if err != nil {
+		http.Error(w, err.Error(), http.StatusRequestEntityTooLarge)
+		return
+	}
+	if err = s.post(r.PathValue("room"), kind, string(body)); err != nil {
+		http.Error(w, err.Error(), http.StatusConflict)
+		return
+	}
+	w.WriteHeader(http.StatusCreated)
+}
+
+func (s *Server) handleGet(w http.ResponseWriter, r *http.Request) {
+	kind := r.PathValue("kind")
+	if kind != TypeOffer && kind != TypeAnswer {
+		http.NotFound(w, r)
+		return
+	}
+	sdp, err := s.wait(r, r.PathValue("room"), kind, s.WaitTimeout)
+	if err != nil {
+		// nothing yet, the client should poll again
+		w.WriteHeader(http.StatusNoContent)
+		return
+	}
+	w.Header().Set("Content-Type", "text/plain")
+	_, _ = io.WriteString(w, sdp)
+}
+
+func (s *Server) handleWebSocket(w http.ResponseWriter, r *http.Request) {
+	name := r.PathValue("room")
+	role := r.URL.Query().Get("role")
+	if role != "offerer" && role != "answerer" {
+		http.Error(w, "role must be offerer or answerer", http.StatusBadRequest)
+		return
+	}
+	websocket.Server{Handler: func(ws *websocket.Conn) {
+		defer ws.Close()
+		var err error
+		if role == "offerer" {
+			err = s.serveOfferer(ws, r, name)
+		} else {
+			err = s.serveAnswerer(ws, r, name)
+		}
+		if err != nil {
+			logs.Default().Warn(logs.ELSE, "signaling room", "room", name, "role", role, "err", err)
+		}
+	}}.ServeHTTP(w, r)
+}
+
+func (s *Server) serveOfferer(ws *websocket.Conn, r *http.Request, name string) error {
+	var msg Message
+	if err := websocket.JSON.Receive(ws, &msg); err != nil {
+		return err
+	}
+	if msg.Type != TypeOffer {
+		return ErrRoom
+	}
+	if err := s.post(name, TypeOffer, msg.SDP); err != nil {
+		return err
+	}
+	answer, err := s.wait(r, name, TypeAnswer, s.RoomTTL)
+	if err != nil {
+		return err
+	}
+	return websocket.JSON.Send(ws, Message{Type: TypeAnswer, SDP: answer})
+}
+
+func (s *Server) serveAnswerer(ws *websocket.Conn, r *http.Request, name string) error {
+	offer, err := s.wait(r, name, TypeOffer, s.RoomTTL)
+	if err != nil {
+		return err
+	}
+	if err = websocket.JSON.Send(ws, Message{Type: TypeOffer, SDP: offer}); err != nil {
+		return err
+	}
+	var msg Message
+	if err = websocket.JSON.Receive(ws, &msg); err != nil {
+		return err
+	}
+	if msg.Type != TypeAnswer {
+		return ErrRoom
+	}
+	return s.post(name, TypeAnswer, msg.SDP)
+}
+
+// room return the room named name, expired rooms are swept on the way
+func (s *Server) room(name string) *room {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	for key, rm := range s.rooms {
+		if time.Since(rm.created) > s.RoomTTL {
+			delete(s.rooms, key)
+		}
+	}
+	rm := s.rooms[name]
+	if rm == nil {
+		rm = newRoom()
+		s.rooms[name] = rm
+	}
+	return rm
+}
+
+func (s *Server) post(name, kind, sdp string) error {
+	if name == "" || sdp == "" {
+		return ErrRoom
+	}
+	rm := s.room(name)
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	switch kind {
+	case TypeOffer:
+		if rm.offer != "" {
+			return ErrPosted
+		}
+		rm.offer = sdp
+		close(rm.offerReady)
+	case TypeAnswer:
+		if rm.answer != "" {
+			return ErrPosted
+		}
+		rm.answer = sdp
+		close(rm.answerReady)
+	}
+	return nil
+}
+
+func (s *Server) wait(r *http.Request, name, kind string, timeout time.Duration) (string, error) {
+	rm := s.room(name)
+	ready := rm.offerReady
+	if kind == TypeAnswer {
+		ready = rm.answerReady
+	}
+	timer := time.NewTimer(timeout)
+	defer timer.Stop()
+	select {
+	case <-ready:
+	case <-timer.C:
+		return "", ErrRoom
+	case <-r.Context().Done():
+		return "", r.Context().Err()
+	}
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	if kind == TypeAnswer {
+		// the exchange is finished once the answer is delivered
+		if s.rooms[name] == rm {
+			delete(s.rooms, name)
+		}
+		return rm.answer, nil
+	}
+	return rm.offer, nil
+}
@@ -0,0 +1,78 @@
+package signaling
+
+import (
+	"context"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"sessionmgr/internal/testmgr"
+	"strings"
+	"testing"
+	"time"
+)
+
+func TestClientExchange(t *testing.T) {
+	server := httptest.NewServer(NewServer())
+	defer server.Close()
+	ctx, cancel := context.WithTimeout(context.Background(), 20*time.Second)
+	defer cancel()
+
+	offerer, answerer := testmgr.New(t, ""), testmgr.New(t, "")
+	errs := make(chan error, 1)
+	go func() {
+		errs <- NewClient(server.URL).Answer(ctx, answerer, 2, "room")
+	}()
+	if err := NewClient(server.URL).Offer(ctx, offerer, 1, "room"); err != nil {
+		t.Fatal(err)
+	}
+	if err := <-errs; err != nil {
+		t.Fatal(err)
+	}
+
+	checkDelivery(t, ctx, offerer, 1, answerer, 2)
+}
+
+func TestHTTPExchange(t *testing.T) {
+	server := httptest.NewServer(NewServer())
+	defer server.Close()
+
+	resp, err := http.Post(server.URL+"/rooms/r1/offer", "text/plain", strings.NewReader("OFFER"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	resp.Body.Close()
+	if resp.StatusCode != http.StatusCreated {
+		t.Fatalf("unexpected status %d", resp.StatusCode)
+	}
+	resp, err = http.Post(server.URL+"/rooms/r1/offer", "text/plain", strings.NewReader("OFFER"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	resp.Body.Close()
+	if resp.StatusCode != http.StatusConflict {
+		t.Fatalf("expected conflict on second offer, got %d", resp.StatusCode)
+	}
+
+	resp, err = http.Get(server.URL + "/rooms/r1/offer")
+	if err != nil {
+		t.Fatal(err)
+	}
+	body, _ := io.ReadAll(resp.Body)
+	resp.Body.Close()
+	if string(body) != "OFFER" {
+		t.Fatalf("unexpected offer %q", body)
+	}
+
+	if _, err = http.Post(server.URL+"/rooms/r1/answer", "text/plain", strings.NewReader("ANSWER")); err != nil {
+		t.Fatal(err)
+	}
+	resp, err = http.Get(server.URL + "/rooms/r1/answer")
+	if err != nil {
+		t.Fatal(err)
+	}
+	body, _ = io.ReadAll(resp.Body)
+	resp.Body.Close()
+	if string(body) != "ANSWER" {
+		t.Fatalf("unexpected answer %q", body)
+	}
+}
@@ -0,0 +1,106 @@
+package signaling
+
+import (
+	"context"
+	"errors"
+	"sessionmgr"
+	"time"
+)
+
+var ErrRole = errors.New("role invalid")
+
+const pollInterval = 50 * time.Millisecond
+
+// Role tells Connect which side of the exchange to run
+type Role int
+
+const (
+	Offerer Role = iota
+	Answerer
+)
+
+// Signaler carries descriptions between the two sides
+type Signaler interface {
+	// PublishOffer hand the local offer to the remote side
+	PublishOffer(ctx context.Context, offer string) error
+	// AwaitAnswer block until the remote answer arrives
+	AwaitAnswer(ctx context.Context) (string, error)
+	// AwaitOffer block until a remote offer arrives
+	AwaitOffer(ctx context.Context) (string, error)
+	// PublishAnswer hand the local answer to the remote side
+	PublishAnswer(ctx context.Context, answer string) error
+}
+
+// Connect run the whole exchange for SessionID
+//
+//	Offerer:  CreateSession -> Offer -> PublishOffer -> AwaitAnswer -> ConfirmAnswer
+//	Answerer: AwaitOffer -> JoinSession -> Answer -> PublishAnswer
+func Connect(ctx context.Context, mgr sessionmgr.SessionManager, signaler Signaler, role Role, SessionID int32) error {
+	switch role {
+	case Offerer:
+		return connectOfferer(ctx, mgr, signaler, SessionID)
+	case Answerer:
+		return connectAnswerer(ctx, mgr, signaler, SessionID)
+	default:
+		return ErrRole
+	}
+}
+
+func connectOfferer(ctx context.Context, mgr sessionmgr.SessionManager, signaler Signaler, SessionID int32) (err error) {
+	if err = mgr.CreateSession(SessionID); err != nil {
+		return err
+	}
+	// a failed exchange does not leave its session behind
+	defer func() {
+		if err != nil {
+			_ = mgr.DropSession(SessionID)
+		}
+	}()
+	offer, err := poll(ctx, func() (string, error) { return mgr.Offer(SessionID) })
+	if err != nil {
+		return err
+	}
+	if err = signaler.PublishOffer(ctx, offer); err != nil {
+		return err
+	}
+	answer, err := signaler.AwaitAnswer(ctx)
+	if err != nil {
+		return err
+	}
+	return mgr.ConfirmAnswer(SessionID, answer)
+}
+
+func connectAnswerer(ctx context.Context, mgr sessionmgr.SessionManager, signaler Signaler, SessionID int32) (err error) {
+	offer, err := signaler.AwaitOffer(ctx)
+	if err != nil {
+		return err
+	}
+	if err = mgr.JoinSession(SessionID, offer); err != nil {
+		return err
+	}
+	defer func() {
+		if err != nil {
+			_ = mgr.DropSession(SessionID)
+		}
+	}()
+	answer, err := poll(ctx, func() (string, error) { return mgr.Answer(SessionID) })
+	if err != nil {
+		return err
+	}
+	return signaler.PublishAnswer(ctx, answer)
+}
+
+// poll retry fn while the manager answers ErrWait
+func poll(ctx context.Context, fn func() (string, error)) (string, error) {
+	for {
+		sdp, err := fn()
+		if !errors.Is(err, sessionmgr.ErrWait) {
+			return sdp, err
+		}
+		select {
+		case <-ctx.Done():
+			return "", ctx.Err()
+		case <-time.After(pollInterval):
+		}
+	}
+}
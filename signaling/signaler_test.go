@@ -0,0 +1,121 @@
+package signaling
+
+import (
+	"context"
+	"errors"
+	"io"
+	"os"
+	"sessionmgr"
+	"sessionmgr/internal/testmgr"
+	"testing"
+	"time"
+)
+
+// checkDelivery wait for the channel to open and a message to go through
+func checkDelivery(t *testing.T, ctx context.Context, sender *sessionmgr.SessionManagerImpl, senderID int32, receiver *sessionmgr.SessionManagerImpl, receiverID int32) {
+	for {
+		err := sender.Send(senderID, []byte("hello"))
+		if err == nil {
+			break
+		}
+		if !errors.Is(err, sessionmgr.ErrWait) || ctx.Err() != nil {
+			t.Fatal(err)
+		}
+		time.Sleep(50 * time.Millisecond)
+	}
+	for {
+		readys, _ := receiver.Ready()
+		if len(readys) > 0 {
+			if readys[0].SessionID != receiverID || string(readys[0].DAtA) != "hello" {
+				t.Fatalf("unexpected ready: %v", readys[0])
+			}
+			return
+		}
+		if ctx.Err() != nil {
+			t.Fatal(ctx.Err())
+		}
+		time.Sleep(50 * time.Millisecond)
+	}
+}
+
+func connectPair(t *testing.T, offerSide, answerSide Signaler) {
+	ctx, cancel := context.WithTimeout(context.Background(), 20*time.Second)
+	defer cancel()
+	offerer, answerer := testmgr.New(t, ""), testmgr.New(t, "")
+	errs := make(chan error, 1)
+	go func() {
+		errs <- Connect(ctx, answerer, answerSide, Answerer, 2)
+	}()
+	if err := Connect(ctx, offerer, offerSide, Offerer, 1); err != nil {
+		t.Fatal(err)
+	}
+	if err := <-errs; err != nil {
+		t.Fatal(err)
+	}
+	checkDelivery(t, ctx, offerer, 1, answerer, 2)
+}
+
+func TestMemorySignaler(t *testing.T) {
+	signaler := NewMemorySignaler()
+	connectPair(t, signaler, signaler)
+}
+
+func TestFileSignaler(t *testing.T) {
+	dir := t.TempDir()
+	connectPair(t, NewFileSignaler(dir, "peer"), NewFileSignaler(dir, "peer"))
+	if entries, _ := os.ReadDir(dir); len(entries) != 0 {
+		t.Errorf("expected dropped files to be consumed, got %d left", len(entries))
+	}
+}
+
+func TestStreamSignaler(t *testing.T) {
+	offerR, offerW := io.Pipe()
+	answerR, answerW := io.Pipe()
+	defer offerW.Close()
+	defer answerW.Close()
+	connectPair(t, NewStreamSignaler(answerR, offerW), NewStreamSignaler(offerR, answerW))
+}
+
+func TestStreamSignalerCancel(t *testing.T) {
+	r, w := io.Pipe()
+	signaler := NewStreamSignaler(r, io.Discard)
+	ctx, cancel := context.WithTimeout(context.Background(), 50*time.Millisecond)
+	defer cancel()
+	if _, err := signaler.AwaitOffer(ctx); !errors.Is(err, context.DeadlineExceeded) {
+		t.Fatalf("expected deadline exceeded, got %v", err)
+	}
+	// the offer written after the cancelled await goes to the next one
+	go func() {
+		_, _ = w.Write([]byte(`{"type":"offer","sdp":"abc"}` + "\n"))
+		_ = w.Close()
+	}()
+	offer, err := signaler.AwaitOffer(context.Background())
+	if err != nil || offer != "abc" {
+		t.Fatalf("expected offer abc, got %q, %v", offer, err)
+	}
+	if _, err = signaler.AwaitOffer(context.Background()); !errors.Is(err, io.EOF) {
+		t.Errorf("expected EOF after the stream ended, got %v", err)
+	}
+}
+
+func TestConnectCancel(t *testing.T) {
+	ctx, cancel := context.WithTimeout(context.Background(), 200*time.Millisecond)
+	defer cancel()
+	err := Connect(ctx, testmgr.New(t, ""), NewMemorySignaler(), Answerer, 1)
+	if !errors.Is(err, context.DeadlineExceeded) {
+		t.Errorf("expected deadline exceeded, got %v", err)
+	}
+}
+
+func TestConnectDropsFailedSession(t *testing.T) {
+	ctx, cancel := context.WithTimeout(context.Background(), 200*time.Millisecond)
+	defer cancel()
+	mgr := testmgr.New(t, "")
+	// nobody answers, the offerer gives up waiting
+	if err := Connect(ctx, mgr, NewMemorySignaler(), Offerer, 1); !errors.Is(err, context.DeadlineExceeded) {
+		t.Fatalf("expected deadline exceeded, got %v", err)
+	}
+	if _, err := mgr.Inspect(1); !errors.Is(err, sessionmgr.ErrLost) {
+		t.Errorf("expected the session to be dropped, got %v", err)
+	}
+}
@@ -0,0 +1,92 @@
+package signaling
+
+import (
+	"bufio"
+	"context"
+	"encoding/json"
+	"io"
+	"strings"
+	"sync"
+)
+
+// StreamSignaler exchange descriptions over an io.Reader and io.Writer,
+// such as stdin/stdout or a pair of named pipes
+//
+// Every description is written as one JSON Message per line. Lines that are
+// not JSON are taken as a bare description, so a human can paste one in.
+type StreamSignaler struct {
+	r *bufio.Reader
+	w io.Writer
+	// lines is fed by one reader for the life of the signaler, so a cancelled await loses nothing
+	start sync.Once
+	lines chan string
+	// err ends the stream, it is set before lines is closed
+	err error
+}
+
+func NewStreamSignaler(r io.Reader, w io.Writer) *StreamSignaler {
+	return &StreamSignaler{r: bufio.NewReader(r), w: w}
+}
+
+func (s *StreamSignaler) PublishOffer(ctx context.Context, offer string) error {
+	return s.publish(TypeOffer, offer)
+}
+
+func (s *StreamSignaler) AwaitAnswer(ctx context.Context) (string, error) {
+	return s.await(ctx, TypeAnswer)
+}
+
+func (s *StreamSignaler) AwaitOffer(ctx context.Context) (string, error) {
+	return s.await(ctx, TypeOffer)
+}
+
+func (s *StreamSignaler) PublishAnswer(ctx context.Context, answer string) error {
+	return s.publish(TypeAnswer, answer)
+}
+
+func (s *StreamSignaler) publish(kind, sdp string) error {
+	line, err := json.Marshal(Message{Type: kind, SDP: sdp})
+	if err != nil {
+		return err
+	}
+	_, err = s.w.Write(append(line, '\n'))
+	return err
+}
+
+func (s *StreamSignaler) await(ctx context.Context, kind string) (string, error) {
+	s.start.Do(func() {
+		s.lines = make(chan string)
+		go s.read()
+	})
+	select {
+	case <-ctx.Done():
+		return "", ctx.Err()
+	case line, ok := <-s.lines:
+		if !ok {
+			return "", s.err
+		}
+		var msg Message
+		if json.Unmarshal([]byte(line), &msg) != nil {
+			return line, nil
+		}
+		if msg.Type != kind {
+			return "", ErrRoom
+		}
+		return msg.SDP, nil
+	}
+}
+
+// read hand every non-empty line to await until the stream ends
+func (s *StreamSignaler) read() {
+	for {
+		line, err := s.r.ReadString('\n')
+		if line = strings.TrimSpace(line); line != "" {
+			s.lines <- line
+		}
+		if err != nil {
+			s.err = err
+			close(s.lines)
+			return
+		}
+	}
+}
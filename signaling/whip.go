@@ -0,0 +1,145 @@
+package signaling
+
+import (
+	"context"
+	"errors"
+	"io"
+	"math/rand"
+	"mime"
+	"net/http"
+	"path"
+	"sessionmgr"
+	"sessionmgr/logs"
+	"sessionmgr/util"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+)
+
+const sdpContentType = "application/sdp"
+
+// WHIPHandler answer data-only sessions in a single HTTP exchange
+//
+//	POST   <base>       body is the SDP offer, response is the SDP answer with Location <base>/<SessionID>
+//	DELETE <base>/<id>  drop the session, only the sessions this handler answered can be dropped
+//
+// A session the manager drops on its own is forgotten as well, its ID may be reused by others.
+//
+// Descriptions are plain SDP, offers are refused while Envelope or Encryption is enabled.
+type WHIPHandler struct {
+	mgr *sessionmgr.SessionManagerImpl
+	// AnswerTimeout bounds the wait for ICE gathering
+	AnswerTimeout time.Duration
+	mu            sync.Mutex
+	// issued are the sessions handed out in a Location and not dropped since
+	issued map[int32]struct{}
+}
+
+func NewWHIPHandler(mgr *sessionmgr.SessionManagerImpl) *WHIPHandler {
+	h := &WHIPHandler{mgr: mgr, AnswerTimeout: 10 * time.Second, issued: make(map[int32]struct{})}
+	mgr.OnSessionDropped(h.dropped)
+	return h
+}
+
+// dropped forget a session the manager dropped, it runs with the manager locked
+func (h *WHIPHandler) dropped(SessionID int32) {
+	h.mu.Lock()
+	defer h.mu.Unlock()
+	delete(h.issued, SessionID)
+}
+
+func (h *WHIPHandler) ServeHTTP(w http.ResponseWriter, r *http.Request) {
+	switch r.Method {
+	case http.MethodPost:
+		h.handleOffer(w, r)
+	case http.MethodDelete:
+		h.handleDelete(w, r)
+	case http.MethodOptions:
+		w.Header().Set("Allow", "OPTIONS, POST, DELETE")
+		w.WriteHeader(http.StatusNoContent)
+	default:
+		w.Header().Set("Allow", "OPTIONS, POST, DELETE")
+		http.Error(w, "method not allowed", http.StatusMethodNotAllowed)
+	}
+}
+
+func (h *WHIPHandler) handleOffer(w http.ResponseWriter, r *http.Request) {
+	if mediaType, _, _ := mime.ParseMediaType(r.Header.Get("Content-Type")); mediaType != sdpContentType {
+		http.Error(w, "content type must be "+sdpContentType, http.StatusUnsupportedMediaType)
+		return
+	}
+	if config := h.mgr.Config(); config.Envelope.Enable || config.Encryption.Enable {
+		h.mgr.Logger().Warn(logs.SESSION, "whip offer refused, envelope or encryption enabled")
+		http.Error(w, "whip does not carry envelopes or encryption", http.StatusNotImplemented)
+		return
+	}
+	body, err := io.ReadAll(http.MaxBytesReader(w, r.Body, maxBodySize))
+	if err != nil {
+		http.Error(w, err.Error(), http.StatusRequestEntityTooLarge)
+		return
+	}
+	SessionID, err := h.join(string(body))
+	if err != nil {
+		h.mgr.Logger().Warn(logs.SESSION, "whip join failed", "session", SessionID, "err", err)
+		_ = h.mgr.DropSession(SessionID)
+		http.Error(w, err.Error(), http.StatusBadRequest)
+		return
+	}
+	// recorded before anything can drop it, so dropped never misses it
+	h.mu.Lock()
+	h.issued[SessionID] = struct{}{}
+	h.mu.Unlock()
+	ctx, cancel := context.WithTimeout(r.Context(), h.AnswerTimeout)
+	defer cancel()
+	answer, err := poll(ctx, func() (string, error) { return h.mgr.AnswerAs(SessionID, util.FormatRaw) })
+	if err != nil {
+		_ = h.mgr.DropSession(SessionID)
+		http.Error(w, err.Error(), http.StatusInternalServerError)
+		return
+	}
+
+	w.Header().Set("Content-Type", sdpContentType)
+	w.Header().Set("Location", path.Join(r.URL.Path, strconv.Itoa(int(SessionID))))
+	w.WriteHeader(http.StatusCreated)
+	_, _ = io.WriteString(w, answer)
+}
+
+func (h *WHIPHandler) handleDelete(w http.ResponseWriter, r *http.Request) {
+	id, err := strconv.ParseInt(path.Base(strings.TrimSuffix(r.URL.Path, "/")), 10, 32)
+	if err != nil {
+		http.NotFound(w, r)
+		return
+	}
+	SessionID := int32(id)
+	// the manager is not called under mu, dropped takes mu with the manager locked
+	h.mu.Lock()
+	_, ok := h.issued[SessionID]
+	delete(h.issued, SessionID)
+	h.mu.Unlock()
+	if !ok {
+		http.NotFound(w, r)
+		return
+	}
+	// the manager may have dropped it already, on timeout or when the peer left
+	if _, err = h.mgr.Inspect(SessionID); errors.Is(err, sessionmgr.ErrLost) {
+		http.NotFound(w, r)
+		return
+	}
+	if err = h.mgr.DropSession(SessionID); err != nil {
+		http.Error(w, err.Error(), http.StatusInternalServerError)
+		return
+	}
+	w.WriteHeader(http.StatusOK)
+}
+
+// join pick a free SessionID for the offer
+func (h *WHIPHandler) join(offer string) (int32, error) {
+	for {
+		SessionID := rand.Int31()
+		err := h.mgr.JoinSession(SessionID, offer)
+		if !errors.Is(err, sessionmgr.ErrID) {
+			return SessionID, err
+		}
+	}
+}
@@ -0,0 +1,185 @@
+package signaling
+
+import (
+	"context"
+	"errors"
+	"github.com/pion/webrtc/v4"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"sessionmgr"
+	"sessionmgr/internal/testmgr"
+	"strconv"
+	"strings"
+	"testing"
+	"time"
+)
+
+func TestWHIPHandler(t *testing.T) {
+	mgr := testmgr.New(t, "")
+	server := httptest.NewServer(NewWHIPHandler(mgr))
+	defer server.Close()
+	ctx, cancel := context.WithTimeout(context.Background(), 20*time.Second)
+	defer cancel()
+
+	pc, err := webrtc.NewPeerConnection(webrtc.Configuration{})
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer pc.Close()
+	dataCh, err := pc.CreateDataChannel("data", nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+	opened := make(chan struct{})
+	dataCh.OnOpen(func() { close(opened) })
+	offer, err := pc.CreateOffer(nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+	gathered := webrtc.GatheringCompletePromise(pc)
+	if err = pc.SetLocalDescription(offer); err != nil {
+		t.Fatal(err)
+	}
+	<-gathered
+
+	resp, err := http.Post(server.URL+"/whip", "text/plain", strings.NewReader(pc.LocalDescription().SDP))
+	if err != nil {
+		t.Fatal(err)
+	}
+	resp.Body.Close()
+	if resp.StatusCode != http.StatusUnsupportedMediaType {
+		t.Fatalf("expected unsupported media type, got %d", resp.StatusCode)
+	}
+
+	resp, err = http.Post(server.URL+"/whip", "application/sdp", strings.NewReader(pc.LocalDescription().SDP))
+	if err != nil {
+		t.Fatal(err)
+	}
+	answer, _ := io.ReadAll(resp.Body)
+	resp.Body.Close()
+	if resp.StatusCode != http.StatusCreated {
+		t.Fatalf("unexpected status %d: %s", resp.StatusCode, answer)
+	}
+	location := resp.Header.Get("Location")
+	if !strings.HasPrefix(location, "/whip/") {
+		t.Fatalf("unexpected location %q", location)
+	}
+	if err = pc.SetRemoteDescription(webrtc.SessionDescription{Type: webrtc.SDPTypeAnswer, SDP: string(answer)}); err != nil {
+		t.Fatal(err)
+	}
+
+	select {
+	case <-opened:
+	case <-ctx.Done():
+		t.Fatal(ctx.Err())
+	}
+	if err = dataCh.SendText("hello"); err != nil {
+		t.Fatal(err)
+	}
+	var SessionID int32
+	for SessionID == 0 {
+		readys, _ := mgr.Ready()
+		for _, ready := range readys {
+			if string(ready.DAtA) == "hello" {
+				SessionID = ready.SessionID
+			}
+		}
+		if ctx.Err() != nil {
+			t.Fatal(ctx.Err())
+		}
+		time.Sleep(50 * time.Millisecond)
+	}
+	if location != "/whip/"+strconv.Itoa(int(SessionID)) {
+		t.Fatalf("location %q does not match session %d", location, SessionID)
+	}
+
+	// sessions the handler did not answer are not its to drop
+	if err = mgr.CreateSession(SessionID + 1); err != nil {
+		t.Fatal(err)
+	}
+	del := func(location string) int {
+		req, _ := http.NewRequest(http.MethodDelete, server.URL+location, nil)
+		resp, err := http.DefaultClient.Do(req)
+		if err != nil {
+			t.Fatal(err)
+		}
+		resp.Body.Close()
+		return resp.StatusCode
+	}
+	if status := del("/whip/" + strconv.Itoa(int(SessionID+1))); status != http.StatusNotFound {
+		t.Fatalf("expected not found for a foreign session, got %d", status)
+	}
+	if _, err = mgr.Inspect(SessionID + 1); err != nil {
+		t.Fatalf("foreign session dropped: %v", err)
+	}
+
+	req, _ := http.NewRequest(http.MethodDelete, server.URL+location, nil)
+	resp, err = http.DefaultClient.Do(req)
+	if err != nil {
+		t.Fatal(err)
+	}
+	resp.Body.Close()
+	if resp.StatusCode != http.StatusOK {
+		t.Fatalf("unexpected status %d", resp.StatusCode)
+	}
+	if err = mgr.Send(SessionID, []byte("bye")); !errors.Is(err, sessionmgr.ErrLost) {
+		t.Errorf("expected session to be dropped, got %v", err)
+	}
+	if status := del(location); status != http.StatusNotFound {
+		t.Errorf("expected not found for a dropped session, got %d", status)
+	}
+}
+
+func TestWHIPHandlerWrapped(t *testing.T) {
+	mgr := testmgr.New(t, `,"Encryption":{"Enable":true,"Passphrase":"secret"}`)
+	server := httptest.NewServer(NewWHIPHandler(mgr))
+	defer server.Close()
+
+	resp, err := http.Post(server.URL+"/whip", "application/sdp", strings.NewReader("v=0\r\n"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	resp.Body.Close()
+	if resp.StatusCode != http.StatusNotImplemented {
+		t.Fatalf("expected not implemented, got %d", resp.StatusCode)
+	}
+	if sessions, _ := mgr.Sessions(); len(sessions) != 0 {
+		t.Errorf("expected no session, got %+v", sessions)
+	}
+}
+
+func TestWHIPHandlerForgetsDropped(t *testing.T) {
+	mgr := testmgr.New(t, "")
+	h := NewWHIPHandler(mgr)
+	server := httptest.NewServer(h)
+	defer server.Close()
+
+	// a session answered here, then dropped by the manager and its ID reused elsewhere
+	const SessionID = 5
+	if err := mgr.CreateSession(SessionID); err != nil {
+		t.Fatal(err)
+	}
+	h.mu.Lock()
+	h.issued[SessionID] = struct{}{}
+	h.mu.Unlock()
+	if err := mgr.DropSession(SessionID); err != nil {
+		t.Fatal(err)
+	}
+	if err := mgr.CreateSession(SessionID); err != nil {
+		t.Fatal(err)
+	}
+
+	req, _ := http.NewRequest(http.MethodDelete, server.URL+"/whip/"+strconv.Itoa(SessionID), nil)
+	resp, err := http.DefaultClient.Do(req)
+	if err != nil {
+		t.Fatal(err)
+	}
+	resp.Body.Close()
+	if resp.StatusCode != http.StatusNotFound {
+		t.Fatalf("expected not found for a reused ID, got %d", resp.StatusCode)
+	}
+	if _, err = mgr.Inspect(SessionID); err != nil {
+		t.Errorf("reused session dropped: %v", err)
+	}
+}
@@ -0,0 +1,127 @@
+package trace
+
+import (
+	"log/slog"
+	"sync"
+	"time"
+)
+
+// Tracer start spans, the manager calls it around its public methods and negotiation phases
+type Tracer interface {
+	// Start begin a span, parent is nil for a root span
+	Start(parent Span, name string, attrs ...slog.Attr) Span
+}
+
+// Span is ended once, with the error of its operation or nil
+type Span interface {
+	SetAttributes(attrs ...slog.Attr)
+	End(err error)
+}
+
+// Nop discard every span, it is the default of the manager
+type Nop struct{}
+
+func (Nop) Start(Span, string, ...slog.Attr) Span {
+	return nopSpan{}
+}
+
+type nopSpan struct{}
+
+func (nopSpan) SetAttributes(...slog.Attr) {}
+func (nopSpan) End(error)                  {}
+
+// RecordedSpan is a span ended in a Recorder
+type RecordedSpan struct {
+	// ID is unique in its Recorder, ParentID is 0 for a root span
+	ID       uint64
+	ParentID uint64
+	Name     string
+	Attrs    []slog.Attr
+	Start    time.Time
+	End      time.Time
+	Err      error
+}
+
+func (s RecordedSpan) Duration() time.Duration {
+	return s.End.Sub(s.Start)
+}
+
+// Attr return the value of the attribute key, nil when missing
+func (s RecordedSpan) Attr(key string) any {
+	for _, attr := range s.Attrs {
+		if attr.Key == key {
+			return attr.Value.Any()
+		}
+	}
+	return nil
+}
+
+// Recorder keep ended spans in memory, for tests
+type Recorder struct {
+	mu     sync.Mutex
+	nextID uint64
+	spans  []RecordedSpan
+}
+
+func NewRecorder() *Recorder {
+	return &Recorder{}
+}
+
+func (r *Recorder) Start(parent Span, name string, attrs ...slog.Attr) Span {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	r.nextID++
+	span := &recordedSpan{r: r, span: RecordedSpan{ID: r.nextID, Name: name, Attrs: attrs, Start: time.Now()}}
+	if p, ok := parent.(*recordedSpan); ok && p.r == r {
+		span.span.ParentID = p.span.ID
+	}
+	return span
+}
+
+// Spans return the ended spans in the order they ended
+func (r *Recorder) Spans() []RecordedSpan {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	return append([]RecordedSpan(nil), r.spans...)
+}
+
+// Find return the ended spans called name
+func (r *Recorder) Find(name string) []RecordedSpan {
+	found := make([]RecordedSpan, 0)
+	for _, span := range r.Spans() {
+		if span.Name == name {
+			found = append(found, span)
+		}
+	}
+	return found
+}
+
+type recordedSpan struct {
+	r     *Recorder
+	mu    sync.Mutex
+	span  RecordedSpan
+	ended bool
+}
+
+func (s *recordedSpan) SetAttributes(attrs ...slog.Attr) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.span.Attrs = append(s.span.Attrs, attrs...)
+}
+
+// End record the span, later calls are ignored
+func (s *recordedSpan) End(err error) {
+	s.mu.Lock()
+	if s.ended {
+		s.mu.Unlock()
+		return
+	}
+	s.ended = true
+	s.span.End, s.span.Err = time.Now(), err
+	span := s.span
+	s.mu.Unlock()
+
+	s.r.mu.Lock()
+	defer s.r.mu.Unlock()
+	s.r.spans = append(s.r.spans, span)
+}
@@ -0,0 +1,41 @@
+package trace
+
+import (
+	"errors"
+	"log/slog"
+	"testing"
+)
+
+func TestRecorder(t *testing.T) {
+	r := NewRecorder()
+	root := r.Start(nil, "CreateSession", slog.Int("session", 1))
+	child := r.Start(root, "CreateOffer")
+	child.End(nil)
+	root.SetAttributes(slog.String("role", "offerer"))
+	failed := errors.New("failed")
+	root.End(failed)
+	// a span ends once
+	root.End(nil)
+	// spans of another tracer are not parents
+	Nop{}.Start(nil, "ignored").End(nil)
+	orphan := r.Start(Nop{}.Start(nil, "ignored"), "orphan")
+	orphan.End(nil)
+
+	spans := r.Spans()
+	if len(spans) != 3 {
+		t.Fatalf("expected 3 spans, got %+v", spans)
+	}
+	if spans[0].Name != "CreateOffer" || spans[0].ParentID != spans[1].ID || spans[0].Duration() < 0 {
+		t.Errorf("unexpected child %+v", spans[0])
+	}
+	created := r.Find("CreateSession")
+	if len(created) != 1 || created[0].ParentID != 0 || created[0].Err != failed {
+		t.Fatalf("unexpected root %+v", created)
+	}
+	if created[0].Attr("session") != int64(1) || created[0].Attr("role") != "offerer" || created[0].Attr("missing") != nil {
+		t.Errorf("unexpected attributes %+v", created[0].Attrs)
+	}
+	if spans[2].ParentID != 0 {
+		t.Errorf("unexpected parent of %+v", spans[2])
+	}
+}
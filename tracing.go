@@ -0,0 +1,48 @@
+package sessionmgr
+
+import (
+	"log/slog"
+	"sessionmgr/trace"
+)
+
+// negotiation phases traced inside the spans of CreateSession, JoinSession and ConfirmAnswer
+const (
+	spanCreateOffer          = "CreateOffer"
+	spanCreateAnswer         = "CreateAnswer"
+	spanSetLocalDescription  = "SetLocalDescription"
+	spanSetRemoteDescription = "SetRemoteDescription"
+	spanGathering            = "gathering"
+	spanChannelOpen          = "channel open"
+)
+
+// SetTracer trace the public methods of the manager and the negotiation phases of its sessions with t,
+// nil restores trace.Nop
+func (s *SessionManagerImpl) SetTracer(t trace.Tracer) (err error) {
+	defer func() { err = managerError("SetTracer", err) }()
+	if s.discarded.Load() {
+		return ErrCall
+	}
+	if t == nil {
+		t = trace.Nop{}
+	}
+	s.tracer.Store(&t)
+	return nil
+}
+
+// startSpan start the span of a public method for SessionID
+func (s *SessionManagerImpl) startSpan(name string, SessionID int32, attrs ...slog.Attr) trace.Span {
+	return s.tracing().Start(nil, name, append([]slog.Attr{slog.Int("session", int(SessionID))}, attrs...)...)
+}
+
+// startPhase start a negotiation phase of session under parent
+func (s *SessionManagerImpl) startPhase(parent trace.Span, name string, session *Session) trace.Span {
+	return s.tracing().Start(parent, name, slog.String("role", session.Role))
+}
+
+// tracing return the injected Tracer, trace.Nop without one
+func (s *SessionManagerImpl) tracing() trace.Tracer {
+	if t := s.tracer.Load(); t != nil {
+		return *t
+	}
+	return trace.Nop{}
+}
@@ -0,0 +1,140 @@
+package turnserver
+
+import (
+	"errors"
+	"github.com/pion/turn/v4"
+	"github.com/pion/webrtc/v4"
+	"net"
+	"sessionmgr/conf"
+	"sessionmgr/logs"
+	"sort"
+	"strconv"
+	"time"
+)
+
+var ErrPublicIP = errors.New("turn server public ip invalid")
+
+const defaultCredentialTTL = 24 * time.Hour
+
+// Server is an embedded STUN/TURN server
+type Server struct {
+	config   conf.TurnServerConf
+	conn     net.PacketConn
+	server   *turn.Server
+	hmacAuth turn.AuthHandler
+}
+
+// Start listen on ListenAddr and serve STUN/TURN requests
+func Start(config *conf.TurnServerConf) (*Server, error) {
+	publicIP := net.ParseIP(config.PublicIP)
+	if publicIP == nil {
+		return nil, ErrPublicIP
+	}
+	conn, err := net.ListenPacket("udp4", config.ListenAddr)
+	if err != nil {
+		logs.Default().Error(logs.ICE, "turn server listen", "addr", config.ListenAddr, "err", err)
+		return nil, err
+	}
+	s := &Server{
+		config: *config,
+		conn:   conn,
+	}
+	if config.AuthSecret != "" {
+		s.hmacAuth = turn.NewLongTermAuthHandler(config.AuthSecret, nil)
+	}
+
+	var generator turn.RelayAddressGenerator = &turn.RelayAddressGeneratorStatic{
+		RelayAddress: publicIP,
+		Address:      "0.0.0.0",
+	}
+	if config.RelayPortMin != 0 && config.RelayPortMax != 0 {
+		generator = &turn.RelayAddressGeneratorPortRange{
+			RelayAddress: publicIP,
+			Address:      "0.0.0.0",
+			MinPort:      config.RelayPortMin,
+			MaxPort:      config.RelayPortMax,
+		}
+	}
+	s.server, err = turn.NewServer(turn.ServerConfig{
+		Realm:         config.Realm,
+		AuthHandler:   s.authenticate,
+		LoggerFactory: logs.Default().PionFactory(),
+		PacketConnConfigs: []turn.PacketConnConfig{
+			{
+				PacketConn:            conn,
+				RelayAddressGenerator: generator,
+			},
+		},
+	})
+	if err != nil {
+		logs.Default().Error(logs.ICE, "turn server", "err", err)
+		_ = conn.Close()
+		return nil, err
+	}
+	logs.Default().Info(logs.ICE, "turn server listening", "addr", conn.LocalAddr().String())
+	return s, nil
+}
+
+// Addr return the address the server is listening on
+func (s *Server) Addr() net.Addr {
+	return s.conn.LocalAddr()
+}
+
+// ICEServers return the ICE servers pointing to this server, HMAC credentials are generated on every call
+func (s *Server) ICEServers() ([]webrtc.ICEServer, error) {
+	port := s.conn.LocalAddr().(*net.UDPAddr).Port
+	host := net.JoinHostPort(s.config.PublicIP, strconv.Itoa(port))
+	servers := []webrtc.ICEServer{
+		{URLs: []string{"stun:" + host}},
+	}
+
+	username, credential, err := s.credential()
+	if err != nil {
+		return nil, err
+	}
+	if username != "" {
+		servers = append(servers, webrtc.ICEServer{
+			URLs:       []string{"turn:" + host + "?transport=udp"},
+			Username:   username,
+			Credential: credential,
+		})
+	}
+	return servers, nil
+}
+
+// Close stop serving and release the listener
+func (s *Server) Close() error {
+	return s.server.Close()
+}
+
+func (s *Server) credential() (string, string, error) {
+	if s.config.AuthSecret != "" {
+		ttl := time.Duration(s.config.CredentialTTL) * time.Second
+		if ttl <= 0 {
+			ttl = defaultCredentialTTL
+		}
+		return turn.GenerateLongTermCredentials(s.config.AuthSecret, ttl)
+	}
+	if len(s.config.Users) == 0 {
+		return "", "", nil
+	}
+	users := make([]string, 0, len(s.config.Users))
+	for user := range s.config.Users {
+		users = append(users, user)
+	}
+	sort.Strings(users)
+	return users[0], s.config.Users[users[0]], nil
+}
+
+func (s *Server) authenticate(username, realm string, srcAddr net.Addr) ([]byte, bool) {
+	if password, ok := s.config.Users[username]; ok {
+		return turn.GenerateAuthKey(username, realm, password), true
+	}
+	if s.hmacAuth != nil {
+		if key, ok := s.hmacAuth(username, realm, srcAddr); ok {
+			return key, true
+		}
+	}
+	logs.Default().Warn(logs.ICE, "turn auth rejected", "username", username, "addr", srcAddr.String())
+	return nil, false
+}
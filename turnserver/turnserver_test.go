@@ -0,0 +1,92 @@
+package turnserver
+
+import (
+	"github.com/pion/turn/v4"
+	"net"
+	"sessionmgr/conf"
+	"testing"
+)
+
+func allocate(t *testing.T, server *Server, username, password string) error {
+	conn, err := net.ListenPacket("udp4", "127.0.0.1:0")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer conn.Close()
+	client, err := turn.NewClient(&turn.ClientConfig{
+		STUNServerAddr: server.Addr().String(),
+		TURNServerAddr: server.Addr().String(),
+		Username:       username,
+		Password:       password,
+		Realm:          "sessionmgr",
+		Conn:           conn,
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer client.Close()
+	if err = client.Listen(); err != nil {
+		t.Fatal(err)
+	}
+	if _, err = client.SendBindingRequest(); err != nil {
+		t.Fatal(err)
+	}
+	relayConn, err := client.Allocate()
+	if err != nil {
+		return err
+	}
+	return relayConn.Close()
+}
+
+func TestStaticCredential(t *testing.T) {
+	server, err := Start(&conf.TurnServerConf{
+		ListenAddr: "127.0.0.1:0",
+		PublicIP:   "127.0.0.1",
+		Realm:      "sessionmgr",
+		Users:      map[string]string{"andy": "000000"},
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer server.Close()
+
+	iceServers, err := server.ICEServers()
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(iceServers) != 2 || iceServers[1].Username != "andy" {
+		t.Fatalf("unexpected ice servers: %v", iceServers)
+	}
+	if err = allocate(t, server, "andy", "000000"); err != nil {
+		t.Error(err)
+	}
+	if err = allocate(t, server, "andy", "wrong"); err == nil {
+		t.Error("expected allocation with wrong password to fail")
+	}
+}
+
+func TestHMACCredential(t *testing.T) {
+	server, err := Start(&conf.TurnServerConf{
+		ListenAddr:    "127.0.0.1:0",
+		PublicIP:      "127.0.0.1",
+		Realm:         "sessionmgr",
+		AuthSecret:    "secret",
+		CredentialTTL: 60,
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer server.Close()
+
+	iceServers, err := server.ICEServers()
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(iceServers) != 2 {
+		t.Fatalf("unexpected ice servers: %v", iceServers)
+	}
+	username, password := iceServers[1].Username, iceServers[1].Credential.(string)
+	if err = allocate(t, server, username, password); err != nil {
+		t.Error(err)
+	}
+}
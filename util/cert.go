@@ -0,0 +1,123 @@
+package util
+
+import (
+	"crypto/ecdsa"
+	"crypto/elliptic"
+	"crypto/rand"
+	"crypto/sha256"
+	"crypto/x509"
+	"crypto/x509/pkix"
+	"encoding/pem"
+	"errors"
+	"github.com/pion/webrtc/v4"
+	"math/big"
+	"os"
+	"strings"
+	"time"
+)
+
+var ErrCertificate = errors.New("dtls certificate invalid")
+
+// certificateLifetime is the validity of a generated certificate, peers pin it so it should outlive them
+const certificateLifetime = 10 * 365 * 24 * time.Hour
+
+// GenerateCertificate create an ECDSA P-256 certificate and write it to path as PEM,
+// an existing file is never overwritten
+func GenerateCertificate(path string) (*webrtc.Certificate, error) {
+	key, err := ecdsa.GenerateKey(elliptic.P256(), rand.Reader)
+	if err != nil {
+		return nil, err
+	}
+	serial, err := rand.Int(rand.Reader, new(big.Int).Lsh(big.NewInt(1), 128))
+	if err != nil {
+		return nil, err
+	}
+	now := time.Now()
+	template := &x509.Certificate{
+		SerialNumber: serial,
+		Subject:      pkix.Name{CommonName: "sessionmgr"},
+		NotBefore:    now.Add(-24 * time.Hour),
+		NotAfter:     now.Add(certificateLifetime),
+	}
+	der, err := x509.CreateCertificate(rand.Reader, template, template, &key.PublicKey, key)
+	if err != nil {
+		return nil, err
+	}
+	keyDER, err := x509.MarshalPKCS8PrivateKey(key)
+	if err != nil {
+		return nil, err
+	}
+
+	file, err := os.OpenFile(path, os.O_WRONLY|os.O_CREATE|os.O_EXCL, 0600)
+	if err != nil {
+		return nil, err
+	}
+	err = errors.Join(
+		pem.Encode(file, &pem.Block{Type: "CERTIFICATE", Bytes: der}),
+		pem.Encode(file, &pem.Block{Type: "PRIVATE KEY", Bytes: keyDER}),
+		file.Close(),
+	)
+	if err != nil {
+		return nil, err
+	}
+	return LoadCertificate(path)
+}
+
+// LoadCertificate read a PEM file holding a CERTIFICATE and a PKCS#8 PRIVATE KEY block in any order
+func LoadCertificate(path string) (*webrtc.Certificate, error) {
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return nil, err
+	}
+	var cert *x509.Certificate
+	var key any
+	for block, rest := pem.Decode(data); block != nil; block, rest = pem.Decode(rest) {
+		switch block.Type {
+		case "CERTIFICATE":
+			if cert, err = x509.ParseCertificate(block.Bytes); err != nil {
+				return nil, err
+			}
+		case "PRIVATE KEY":
+			if key, err = x509.ParsePKCS8PrivateKey(block.Bytes); err != nil {
+				return nil, err
+			}
+		}
+	}
+	if cert == nil || key == nil {
+		return nil, ErrCertificate
+	}
+	if time.Now().After(cert.NotAfter) {
+		return nil, errors.Join(ErrCertificate, errors.New("certificate expired"))
+	}
+	certificate := webrtc.CertificateFromX509(key, cert)
+	return &certificate, nil
+}
+
+// Fingerprint format the sha-256 fingerprint of a DER certificate like the SDP attribute:
+// "sha-256 AB:CD:..."
+func Fingerprint(der []byte) string {
+	digest := sha256.Sum256(der)
+	return "sha-256 " + formatDigest(digest[:])
+}
+
+// CertificateFingerprint return the Fingerprint of a loaded certificate
+func CertificateFingerprint(certificate *webrtc.Certificate) (string, error) {
+	fingerprints, err := certificate.GetFingerprints()
+	if err != nil {
+		return "", err
+	}
+	return fingerprints[0].Algorithm + " " + strings.ToUpper(fingerprints[0].Value), nil
+}
+
+// MatchFingerprint tell whether the DER certificate has the pinned fingerprint,
+// pin is "sha-256 AB:CD:..." or the bare digest, case is ignored
+func MatchFingerprint(pin string, der []byte) bool {
+	pin = strings.TrimSpace(pin)
+	if fields := strings.Fields(pin); len(fields) == 2 {
+		if !strings.EqualFold(fields[0], "sha-256") {
+			return false
+		}
+		pin = fields[1]
+	}
+	return strings.EqualFold(pin, strings.TrimPrefix(Fingerprint(der), "sha-256 "))
+}
@@ -0,0 +1,48 @@
+package util
+
+import (
+	"path/filepath"
+	"strings"
+	"testing"
+)
+
+func TestGenerateCertificate(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "cert.pem")
+	generated, err := GenerateCertificate(path)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if _, err = GenerateCertificate(path); err == nil {
+		t.Error("expected an existing certificate not to be overwritten")
+	}
+	loaded, err := LoadCertificate(path)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !loaded.Equals(*generated) {
+		t.Error("loaded certificate differs from the generated one")
+	}
+	fingerprint, err := CertificateFingerprint(loaded)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !strings.HasPrefix(fingerprint, "sha-256 ") {
+		t.Errorf("unexpected fingerprint %v", fingerprint)
+	}
+}
+
+func TestMatchFingerprint(t *testing.T) {
+	der := []byte("not really a certificate")
+	fingerprint := Fingerprint(der)
+	digest := strings.TrimPrefix(fingerprint, "sha-256 ")
+	for _, pin := range []string{fingerprint, digest, strings.ToLower(fingerprint), " " + fingerprint + "\n"} {
+		if !MatchFingerprint(pin, der) {
+			t.Errorf("expected %q to match", pin)
+		}
+	}
+	for _, pin := range []string{"", "sha-1 " + digest, Fingerprint([]byte("other"))} {
+		if MatchFingerprint(pin, der) {
+			t.Errorf("expected %q not to match", pin)
+		}
+	}
+}
@@ -0,0 +1,147 @@
+package util
+
+import (
+	"encoding/base64"
+	"errors"
+	"fmt"
+	"hash/crc32"
+	"sort"
+	"strconv"
+	"strings"
+)
+
+var ErrChunk = errors.New("sdp chunks invalid")
+
+// ChunkPrefix starts every part
+//
+//	SMP1.<index>.<total>.<payload crc32>.<part crc32>.<data>
+const ChunkPrefix = "SMP1."
+
+// maxMissing is how many missing parts an error names
+const maxMissing = 8
+
+// escapedPrefix marks a payload that was base64 encoded because it held whitespace
+const escapedPrefix = "SMB1."
+
+// IsChunked tell whether in holds one or more parts
+func IsChunked(in string) bool {
+	return strings.HasPrefix(strings.TrimSpace(in), ChunkPrefix)
+}
+
+// SplitSDP split an encoded description into numbered, checksummed parts no longer than maxLen
+func SplitSDP(encoded string, maxLen int) ([]string, error) {
+	if strings.ContainsAny(encoded, " \t\r\n") {
+		// parts are separated by whitespace, keep the payload free of it
+		encoded = escapedPrefix + base64.RawURLEncoding.EncodeToString([]byte(encoded))
+	}
+	id := crc32.ChecksumIEEE([]byte(encoded))
+
+	// the header grows with the number of parts, settle on a count that fits
+	total := 1
+	for {
+		size := maxLen - len(chunkHeader(total, total, id, 0))
+		if size <= 0 {
+			return nil, chunkError(ErrChunk, "part length %d leaves no room for data", maxLen)
+		}
+		need := (len(encoded) + size - 1) / size
+		if need <= total {
+			break
+		}
+		total = need
+	}
+
+	size := (len(encoded) + total - 1) / total
+	parts := make([]string, 0, total)
+	for i := 0; i < total; i++ {
+		data := encoded[min(i*size, len(encoded)):min((i+1)*size, len(encoded))]
+		parts = append(parts, chunkHeader(i+1, total, id, crc32.ChecksumIEEE([]byte(data)))+data)
+	}
+	return parts, nil
+}
+
+// JoinSDP reassemble parts given in any order, duplicates are ignored
+func JoinSDP(parts []string) (string, error) {
+	var total int
+	var id uint32
+	received := make(map[int]string)
+	for _, part := range parts {
+		part = strings.TrimSpace(part)
+		if part == "" {
+			continue
+		}
+		if !strings.HasPrefix(part, ChunkPrefix) {
+			return "", chunkError(ErrChunk, "not a part: %.16q", part)
+		}
+		fields := strings.SplitN(part[len(ChunkPrefix):], ".", 5)
+		if len(fields) != 5 {
+			return "", chunkError(ErrChunk, "malformed header: %.32q", part)
+		}
+		index, err1 := strconv.Atoi(fields[0])
+		count, err2 := strconv.Atoi(fields[1])
+		partID, err3 := strconv.ParseUint(fields[2], 16, 32)
+		sum, err4 := strconv.ParseUint(fields[3], 16, 32)
+		if err := errors.Join(err1, err2, err3, err4); err != nil {
+			return "", chunkError(err, "malformed header: %.32q", part)
+		}
+		if count < 1 || index < 1 || index > count {
+			return "", chunkError(ErrChunk, "part %d of %d out of range", index, count)
+		}
+		if total == 0 {
+			total, id = count, uint32(partID)
+		}
+		if count != total || uint32(partID) != id {
+			return "", chunkError(ErrChunk, "part %d/%d belongs to another description", index, count)
+		}
+		data := fields[4]
+		if crc32.ChecksumIEEE([]byte(data)) != uint32(sum) {
+			return "", chunkError(ErrChunk, "part %d/%d corrupt", index, count)
+		}
+		received[index] = data
+	}
+	if total == 0 {
+		return "", chunkError(ErrChunk, "no parts")
+	}
+
+	if absent := total - len(received); absent > 0 {
+		missing := make([]string, 0, maxMissing)
+		for i := 1; i <= total && len(missing) < maxMissing; i++ {
+			if _, ok := received[i]; !ok {
+				missing = append(missing, strconv.Itoa(i))
+			}
+		}
+		if absent > len(missing) {
+			missing = append(missing, "...")
+		}
+		return "", chunkError(ErrChunk, "missing %d parts (%v) of %d", absent, strings.Join(missing, ", "), total)
+	}
+
+	indexes := make([]int, 0, total)
+	for i := range received {
+		indexes = append(indexes, i)
+	}
+	sort.Ints(indexes)
+	var sb strings.Builder
+	for _, i := range indexes {
+		sb.WriteString(received[i])
+	}
+	encoded := sb.String()
+	if crc32.ChecksumIEEE([]byte(encoded)) != id {
+		return "", chunkError(ErrChunk, "reassembled description corrupt")
+	}
+	if strings.HasPrefix(encoded, escapedPrefix) {
+		raw, err := base64.RawURLEncoding.DecodeString(encoded[len(escapedPrefix):])
+		if err != nil {
+			return "", chunkError(err, "malformed payload")
+		}
+		encoded = string(raw)
+	}
+	return encoded, nil
+}
+
+func chunkHeader(index, total int, id, sum uint32) string {
+	return fmt.Sprintf("%s%d.%d.%08x.%08x.", ChunkPrefix, index, total, id, sum)
+}
+
+func chunkError(err error, format string, a ...interface{}) *SDPError {
+	return sdpError(CheckChunk, err, format, a...)
+}
@@ -0,0 +1,82 @@
+package util
+
+import (
+	"errors"
+	"math/rand"
+	"strings"
+	"testing"
+	"time"
+)
+
+func TestSplitJoinSDP(t *testing.T) {
+	encoded := strings.Repeat("SME1.abcdefghijklmnopqrstuvwxyz0123456789", 20)
+	for _, payload := range []string{encoded, testSDP} {
+		parts, err := SplitSDP(payload, 120)
+		if err != nil {
+			t.Fatal(err)
+		}
+		for _, part := range parts {
+			if len(part) > 120 || strings.ContainsAny(part, " \r\n") {
+				t.Fatalf("bad part %q", part)
+			}
+		}
+		rand.Shuffle(len(parts), func(i, j int) { parts[i], parts[j] = parts[j], parts[i] })
+		// a part delivered twice is harmless
+		joined, err := JoinSDP(append(parts, parts[0]))
+		if err != nil {
+			t.Fatal(err)
+		}
+		if joined != payload {
+			t.Errorf("reassembled %q", joined)
+		}
+	}
+}
+
+func TestJoinSDPErrors(t *testing.T) {
+	parts, err := SplitSDP(strings.Repeat("0123456789", 30), 80)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(parts) < 3 {
+		t.Fatalf("expected several parts, got %d", len(parts))
+	}
+	other, err := SplitSDP(strings.Repeat("abcdefghij", 30), 80)
+	if err != nil {
+		t.Fatal(err)
+	}
+	corrupt := append([]string{}, parts...)
+	corrupt[1] = corrupt[1][:len(corrupt[1])-1] + "x"
+
+	cases := map[string][]string{
+		"missing":  parts[1:],
+		"corrupt":  corrupt,
+		"mixed":    append(append([]string{}, parts[:1]...), other[1:]...),
+		"not part": {"hello"},
+		"empty":    {},
+	}
+	for name, input := range cases {
+		_, err := JoinSDP(input)
+		var sdpErr *SDPError
+		if !errors.As(err, &sdpErr) || sdpErr.Check != CheckChunk || !errors.Is(err, ErrSdp) {
+			t.Errorf("%v: expected chunk error, got %v", name, err)
+		}
+	}
+	if _, err = JoinSDP(parts[1:]); !strings.Contains(err.Error(), "missing 1 parts (1) of") {
+		t.Errorf("missing part not named: %v", err)
+	}
+	// a duplicate fills the count but not the gap
+	if _, err = JoinSDP(append([]string{parts[1]}, parts[1:]...)); !strings.Contains(err.Error(), "missing 1 parts (1) of") {
+		t.Errorf("missing part not named: %v", err)
+	}
+	forged := "SMP1.1.50000000.00000000.00000000."
+	begin := time.Now()
+	if _, err = JoinSDP([]string{forged}); !errors.Is(err, ErrChunk) || !strings.Contains(err.Error(), "missing 49999999 parts (2, 3,") {
+		t.Errorf("expected forged total to be rejected, got %v", err)
+	}
+	if took := time.Since(begin); took > 100*time.Millisecond {
+		t.Errorf("forged total took %v", took)
+	}
+	if _, err = SplitSDP("payload", 20); !errors.Is(err, ErrChunk) {
+		t.Errorf("expected part length to be rejected, got %v", err)
+	}
+}
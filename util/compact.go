@@ -0,0 +1,285 @@
+package util
+
+import (
+	"encoding/base64"
+	"encoding/binary"
+	"errors"
+	"fmt"
+	"github.com/pion/sdp/v3"
+	"github.com/pion/webrtc/v4"
+	"math/rand"
+	"net"
+	"strconv"
+	"strings"
+)
+
+var ErrCompact = errors.New("compact sdp malformed")
+
+// CompactPrefix starts every compact description
+const CompactPrefix = "SMC1."
+
+var setupRoles = []string{"actpass", "active", "passive"}
+var candidateTypes = []string{"host", "srflx", "prflx", "relay"}
+var tcpTypes = []string{"", "active", "passive", "so"}
+
+// type preference of RFC 8445, indexed like candidateTypes
+var typePreferences = []uint32{126, 100, 110, 0}
+
+const (
+	addrIPv4 byte = iota
+	addrIPv6
+	addrHost
+)
+
+// compactCandidate is the part of a candidate the peer needs, foundation and priority are rebuilt
+type compactCandidate struct {
+	Type    byte
+	TCP     bool
+	TCPType byte
+	Address string
+	Port    uint16
+}
+
+// EncodeCompact keep only what a data-only peer needs and pack it in URL-safe base64:
+// type, setup role, mid, ICE credentials, fingerprint and candidates
+func EncodeCompact(desc *webrtc.SessionDescription) (string, error) {
+	parsed, err := desc.Unmarshal()
+	if err != nil {
+		return "", err
+	}
+	var mid, ufrag, pwd, fingerprint string
+	setup := "actpass"
+	candidates := make([]compactCandidate, 0)
+	for _, media := range parsed.MediaDescriptions {
+		if media.MediaName.Media != "application" {
+			continue
+		}
+		for _, attr := range append(append([]sdp.Attribute{}, parsed.Attributes...), media.Attributes...) {
+			switch attr.Key {
+			case "mid":
+				mid = attr.Value
+			case "ice-ufrag":
+				ufrag = attr.Value
+			case "ice-pwd":
+				pwd = attr.Value
+			case "fingerprint":
+				fingerprint = attr.Value
+			case "setup":
+				setup = attr.Value
+			case "candidate":
+				candidate, err := parseCandidate(attr.Value)
+				if err != nil {
+					return "", err
+				}
+				if candidate != nil {
+					candidates = append(candidates, *candidate)
+				}
+			}
+		}
+		break
+	}
+	hash, digest, err := parseFingerprint(fingerprint)
+	if err != nil {
+		return "", err
+	}
+	role := indexOf(setupRoles, setup)
+	if role < 0 || len(candidates) > 255 {
+		return "", ErrCompact
+	}
+	for _, field := range []string{mid, ufrag, pwd, hash, string(digest)} {
+		if len(field) > 255 {
+			return "", ErrCompact
+		}
+	}
+
+	buf := []byte{1, byte(desc.Type), byte(role)}
+	buf = appendString(buf, mid)
+	buf = appendString(buf, ufrag)
+	buf = appendString(buf, pwd)
+	buf = appendString(buf, hash)
+	buf = appendString(buf, string(digest))
+	buf = append(buf, byte(len(candidates)))
+	for _, c := range candidates {
+		kind := c.Type | c.TCPType<<2
+		if c.TCP {
+			kind |= 1 << 4
+		}
+		buf = append(buf, kind)
+		if ip := net.ParseIP(c.Address); ip != nil && ip.To4() != nil {
+			buf = append(append(buf, addrIPv4), ip.To4()...)
+		} else if ip != nil {
+			buf = append(append(buf, addrIPv6), ip.To16()...)
+		} else if len(c.Address) <= 255 {
+			buf = appendString(append(buf, addrHost), c.Address)
+		} else {
+			return "", ErrCompact
+		}
+		buf = binary.BigEndian.AppendUint16(buf, c.Port)
+	}
+	return CompactPrefix + base64.RawURLEncoding.EncodeToString(buf), nil
+}
+
+// DecodeCompact rebuild a full data-only SDP from EncodeCompact output
+func DecodeCompact(in string) (*webrtc.SessionDescription, error) {
+	in = strings.TrimSpace(in)
+	if !strings.HasPrefix(in, CompactPrefix) {
+		return nil, ErrCompact
+	}
+	buf, err := base64.RawURLEncoding.DecodeString(in[len(CompactPrefix):])
+	if err != nil {
+		return nil, err
+	}
+	r := &compactReader{buf: buf}
+	version, sdpType, role := r.byte(), webrtc.SDPType(r.byte()), int(r.byte())
+	mid, ufrag, pwd, hash, digest := r.string(), r.string(), r.string(), r.string(), r.string()
+	count := int(r.byte())
+	if r.err != nil || version != 1 || role >= len(setupRoles) {
+		return nil, ErrCompact
+	}
+
+	var sdp strings.Builder
+	fmt.Fprintf(&sdp, "v=0\r\no=- %d 2 IN IP4 127.0.0.1\r\ns=-\r\nt=0 0\r\n", rand.Int63())
+	fmt.Fprintf(&sdp, "a=fingerprint:%s %s\r\n", hash, formatDigest([]byte(digest)))
+	fmt.Fprintf(&sdp, "a=group:BUNDLE %s\r\n", mid)
+	sdp.WriteString("m=application 9 UDP/DTLS/SCTP webrtc-datachannel\r\nc=IN IP4 0.0.0.0\r\n")
+	fmt.Fprintf(&sdp, "a=setup:%s\r\na=mid:%s\r\na=sendrecv\r\na=sctp-port:5000\r\n", setupRoles[role], mid)
+	fmt.Fprintf(&sdp, "a=ice-ufrag:%s\r\na=ice-pwd:%s\r\n", ufrag, pwd)
+	for i := 0; i < count; i++ {
+		kind := r.byte()
+		c := compactCandidate{Type: kind & 3, TCPType: kind >> 2 & 3, TCP: kind&(1<<4) != 0}
+		switch r.byte() {
+		case addrIPv4:
+			c.Address = net.IP(r.bytes(net.IPv4len)).String()
+		case addrIPv6:
+			c.Address = net.IP(r.bytes(net.IPv6len)).String()
+		case addrHost:
+			c.Address = r.string()
+		default:
+			return nil, ErrCompact
+		}
+		c.Port = r.uint16()
+		if r.err != nil {
+			return nil, ErrCompact
+		}
+		sdp.WriteString("a=candidate:" + formatCandidate(i, &c) + "\r\n")
+	}
+	sdp.WriteString("a=end-of-candidates\r\n")
+	return &webrtc.SessionDescription{Type: sdpType, SDP: sdp.String()}, nil
+}
+
+// parseCandidate read "foundation component protocol priority address port typ type ...",
+// nil is returned for candidates of other components
+func parseCandidate(value string) (*compactCandidate, error) {
+	fields := strings.Fields(value)
+	if len(fields) < 8 || fields[6] != "typ" {
+		return nil, ErrCompact
+	}
+	if fields[1] != "1" {
+		return nil, nil
+	}
+	port, err := strconv.ParseUint(fields[5], 10, 16)
+	if err != nil {
+		return nil, err
+	}
+	typ := indexOf(candidateTypes, fields[7])
+	if typ < 0 {
+		return nil, ErrCompact
+	}
+	c := &compactCandidate{
+		Type:    byte(typ),
+		TCP:     strings.EqualFold(fields[2], "tcp"),
+		Address: fields[4],
+		Port:    uint16(port),
+	}
+	for i := 8; i+1 < len(fields); i += 2 {
+		if fields[i] == "tcptype" {
+			if tcpType := indexOf(tcpTypes, fields[i+1]); tcpType > 0 {
+				c.TCPType = byte(tcpType)
+			}
+		}
+	}
+	return c, nil
+}
+
+func formatCandidate(foundation int, c *compactCandidate) string {
+	protocol := "udp"
+	if c.TCP {
+		protocol = "tcp"
+	}
+	// RFC 8445 priority for component 1, earlier candidates rank higher
+	priority := typePreferences[c.Type]<<24 | uint32(65535-foundation)<<8 | 255
+	line := fmt.Sprintf("%d 1 %s %d %s %d typ %s", foundation, protocol, priority, c.Address, c.Port, candidateTypes[c.Type])
+	if c.Type != 0 {
+		line += " raddr 0.0.0.0 rport 0"
+	}
+	if c.TCP && c.TCPType != 0 {
+		line += " tcptype " + tcpTypes[c.TCPType]
+	}
+	return line
+}
+
+// parseFingerprint split "sha-256 AB:CD:..." into the hash name and the raw digest
+func parseFingerprint(value string) (string, []byte, error) {
+	fields := strings.Fields(value)
+	if len(fields) != 2 {
+		return "", nil, ErrCompact
+	}
+	digest := make([]byte, 0, 64)
+	for _, part := range strings.Split(fields[1], ":") {
+		b, err := strconv.ParseUint(part, 16, 8)
+		if err != nil {
+			return "", nil, ErrCompact
+		}
+		digest = append(digest, byte(b))
+	}
+	return fields[0], digest, nil
+}
+
+func formatDigest(digest []byte) string {
+	parts := make([]string, len(digest))
+	for i, b := range digest {
+		parts[i] = fmt.Sprintf("%02X", b)
+	}
+	return strings.Join(parts, ":")
+}
+
+func indexOf(list []string, value string) int {
+	for i, item := range list {
+		if item == value {
+			return i
+		}
+	}
+	return -1
+}
+
+func appendString(buf []byte, s string) []byte {
+	return append(append(buf, byte(len(s))), s...)
+}
+
+// compactReader read the packed fields, the first failure sticks in err
+type compactReader struct {
+	buf []byte
+	err error
+}
+
+func (r *compactReader) bytes(n int) []byte {
+	if r.err != nil || len(r.buf) < n {
+		r.err = ErrCompact
+		return make([]byte, n)
+	}
+	b := r.buf[:n]
+	r.buf = r.buf[n:]
+	return b
+}
+
+func (r *compactReader) byte() byte {
+	return r.bytes(1)[0]
+}
+
+func (r *compactReader) string() string {
+	return string(r.bytes(int(r.byte())))
+}
+
+func (r *compactReader) uint16() uint16 {
+	return binary.BigEndian.Uint16(r.bytes(2))
+}
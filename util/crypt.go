@@ -0,0 +1,210 @@
+package util
+
+import (
+	"crypto/aes"
+	"crypto/cipher"
+	"crypto/rand"
+	"encoding/base64"
+	"errors"
+	"golang.org/x/crypto/scrypt"
+	"strings"
+	"sync"
+)
+
+var ErrDecrypt = errors.New("sdp decryption failed")
+
+// EncryptedPrefix starts every encrypted description, the version is bound into the ciphertext
+const EncryptedPrefix = "SMX1."
+
+// key derivation of an encrypted description, the first byte after the prefix
+const (
+	kdfRawKey byte = iota
+	kdfScrypt
+)
+
+const (
+	saltSize = 16
+	keySize  = 32
+)
+
+// scrypt cost, about 32MB and tens of milliseconds per derived key
+const (
+	scryptN = 1 << 15
+	scryptR = 8
+	scryptP = 1
+)
+
+// maxDerived bound the keys a Secret keeps for the salts of its peers
+const maxDerived = 16
+
+// openings bound the scrypt runs for the salts of peers across all Secrets,
+// each salt is chosen by whoever sent the description
+var openings = make(chan struct{}, 2)
+
+// Secret is shared by both sides, Key is used as is and takes precedence over Passphrase
+//
+// The keys derived from Passphrase are kept, EncryptSDP uses one salt for the life of the Secret
+// and DecryptSDP runs scrypt once per salt it has not seen.
+type Secret struct {
+	Passphrase string
+	Key        []byte
+
+	mu      sync.Mutex
+	salt    []byte
+	sealKey []byte
+	derived map[string][]byte
+}
+
+// Prepare derive the key of EncryptSDP now instead of on the first description
+func (s *Secret) Prepare() error {
+	if s.Key != nil {
+		return nil
+	}
+	_, _, err := s.sealingKey()
+	return err
+}
+
+// sealingKey return the salt and key of EncryptSDP, derived on first use
+func (s *Secret) sealingKey() ([]byte, []byte, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	if s.sealKey == nil {
+		salt := make([]byte, saltSize)
+		if _, err := rand.Read(salt); err != nil {
+			return nil, nil, err
+		}
+		key, err := deriveKey(s.Passphrase, salt)
+		if err != nil {
+			return nil, nil, err
+		}
+		s.salt, s.sealKey = salt, key
+	}
+	return s.salt, s.sealKey, nil
+}
+
+// openingKey return the key of salt, the derivation runs without holding the lock
+// and waits for a slot of openings
+func (s *Secret) openingKey(salt []byte) ([]byte, error) {
+	if key, ok := s.knownKey(salt); ok {
+		return key, nil
+	}
+	openings <- struct{}{}
+	defer func() { <-openings }()
+	// the same salt may have been derived while waiting
+	if key, ok := s.knownKey(salt); ok {
+		return key, nil
+	}
+	key, err := deriveKey(s.Passphrase, salt)
+	if err != nil {
+		return nil, err
+	}
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	if s.derived == nil || len(s.derived) >= maxDerived {
+		s.derived = make(map[string][]byte)
+	}
+	s.derived[string(salt)] = key
+	return key, nil
+}
+
+// knownKey return the key already derived for salt
+func (s *Secret) knownKey(salt []byte) ([]byte, bool) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	if key, ok := s.derived[string(salt)]; ok {
+		return key, true
+	}
+	if s.sealKey != nil && string(salt) == string(s.salt) {
+		return s.sealKey, true
+	}
+	return nil, false
+}
+
+// IsEncrypted tell whether in is an encrypted description
+func IsEncrypted(in string) bool {
+	return strings.HasPrefix(strings.TrimSpace(in), EncryptedPrefix)
+}
+
+// EncryptSDP seal sdp with AES-256-GCM as SMX1.<kdf|salt|nonce|ciphertext> in URL-safe base64
+func EncryptSDP(sdp string, secret *Secret) (string, error) {
+	header := []byte{kdfRawKey}
+	key := secret.Key
+	if key == nil {
+		salt, derived, err := secret.sealingKey()
+		if err != nil {
+			return "", err
+		}
+		key, header = derived, append([]byte{kdfScrypt}, salt...)
+	}
+	aead, err := newAEAD(key)
+	if err != nil {
+		return "", err
+	}
+	nonce := make([]byte, aead.NonceSize())
+	if _, err = rand.Read(nonce); err != nil {
+		return "", err
+	}
+	blob := append(header, nonce...)
+	blob = aead.Seal(blob, nonce, []byte(sdp), []byte(EncryptedPrefix))
+	return EncryptedPrefix + base64.RawURLEncoding.EncodeToString(blob), nil
+}
+
+// DecryptSDP open a description sealed by EncryptSDP
+func DecryptSDP(in string, secret *Secret) (string, error) {
+	in = strings.TrimSpace(in)
+	if !strings.HasPrefix(in, EncryptedPrefix) {
+		return "", cryptError(ErrDecrypt, "description is not encrypted")
+	}
+	blob, err := base64.RawURLEncoding.DecodeString(in[len(EncryptedPrefix):])
+	if err != nil {
+		return "", cryptError(err, "malformed ciphertext")
+	}
+	if len(blob) < 1 {
+		return "", cryptError(ErrDecrypt, "malformed ciphertext")
+	}
+
+	var key []byte
+	switch kdf := blob[0]; {
+	case kdf == kdfRawKey && secret.Key != nil:
+		key, blob = secret.Key, blob[1:]
+	case kdf == kdfScrypt && secret.Passphrase != "":
+		if len(blob) < 1+saltSize {
+			return "", cryptError(ErrDecrypt, "malformed ciphertext")
+		}
+		if key, err = secret.openingKey(blob[1 : 1+saltSize]); err != nil {
+			return "", cryptError(err, "cannot derive key")
+		}
+		blob = blob[1+saltSize:]
+	default:
+		return "", cryptError(ErrDecrypt, "no matching key or passphrase")
+	}
+
+	aead, err := newAEAD(key)
+	if err != nil {
+		return "", cryptError(err, "invalid key")
+	}
+	if len(blob) < aead.NonceSize() {
+		return "", cryptError(ErrDecrypt, "malformed ciphertext")
+	}
+	plain, err := aead.Open(nil, blob[:aead.NonceSize()], blob[aead.NonceSize():], []byte(EncryptedPrefix))
+	if err != nil {
+		return "", cryptError(ErrDecrypt, "wrong key or tampered ciphertext")
+	}
+	return string(plain), nil
+}
+
+func deriveKey(passphrase string, salt []byte) ([]byte, error) {
+	return scrypt.Key([]byte(passphrase), salt, scryptN, scryptR, scryptP, keySize)
+}
+
+func newAEAD(key []byte) (cipher.AEAD, error) {
+	block, err := aes.NewCipher(key)
+	if err != nil {
+		return nil, err
+	}
+	return cipher.NewGCM(block)
+}
+
+func cryptError(err error, detail string) *SDPError {
+	return &SDPError{Check: CheckEncryption, Detail: detail, Err: err}
+}
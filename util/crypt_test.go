@@ -0,0 +1,117 @@
+package util
+
+import (
+	"bytes"
+	"errors"
+	"strings"
+	"testing"
+	"time"
+)
+
+func TestEncryptSDP(t *testing.T) {
+	secrets := []*Secret{
+		{Passphrase: "correct horse battery staple"},
+		{Key: bytes.Repeat([]byte{7}, 32)},
+	}
+	for _, secret := range secrets {
+		sealed, err := EncryptSDP(testSDP, secret)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if !IsEncrypted(sealed) || strings.Contains(sealed, "127.0.0.1") {
+			t.Fatalf("description not encrypted: %v", sealed)
+		}
+		if strings.ContainsAny(sealed[len(EncryptedPrefix):], "+/=") {
+			t.Errorf("ciphertext is not URL-safe: %v", sealed)
+		}
+		plain, err := DecryptSDP(sealed, secret)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if plain != testSDP {
+			t.Errorf("unexpected plain text %q", plain)
+		}
+
+		flipped := []byte(sealed)
+		last := len(flipped) - 2
+		if flipped[last] == 'A' {
+			flipped[last] = 'B'
+		} else {
+			flipped[last] = 'A'
+		}
+		if _, err = DecryptSDP(string(flipped), secret); !errors.Is(err, ErrDecrypt) || !errors.Is(err, ErrSdp) {
+			t.Errorf("expected tampered ciphertext to be rejected, got %v", err)
+		}
+	}
+
+	sealed, err := EncryptSDP(testSDP, secrets[0])
+	if err != nil {
+		t.Fatal(err)
+	}
+	if _, err = DecryptSDP(sealed, &Secret{Passphrase: "wrong"}); !errors.Is(err, ErrDecrypt) {
+		t.Errorf("expected wrong passphrase to be rejected, got %v", err)
+	}
+	if _, err = DecryptSDP(sealed, secrets[1]); !errors.Is(err, ErrDecrypt) {
+		t.Errorf("expected key mismatch to be rejected, got %v", err)
+	}
+	if _, err = DecryptSDP(testSDP, secrets[0]); !errors.Is(err, ErrDecrypt) {
+		t.Errorf("expected plain description to be rejected, got %v", err)
+	}
+}
+
+func TestSecretKeepsKeys(t *testing.T) {
+	sender := &Secret{Passphrase: "correct horse battery staple"}
+	receiver := &Secret{Passphrase: sender.Passphrase}
+	if err := sender.Prepare(); err != nil {
+		t.Fatal(err)
+	}
+	for i := 0; i < 3; i++ {
+		sealed, err := EncryptSDP(testSDP, sender)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if _, err = DecryptSDP(sealed, receiver); err != nil {
+			t.Fatal(err)
+		}
+	}
+	// one salt per sender, derived once by the receiver
+	if len(receiver.derived) != 1 {
+		t.Errorf("expected one derived key, got %d", len(receiver.derived))
+	}
+	for i := len(receiver.derived); i < maxDerived; i++ {
+		receiver.derived[string(rune(i))] = nil
+	}
+	other, _ := EncryptSDP(testSDP, &Secret{Passphrase: sender.Passphrase})
+	if _, err := DecryptSDP(other, receiver); err != nil {
+		t.Fatal(err)
+	}
+	if len(receiver.derived) > maxDerived {
+		t.Errorf("derived keys not bounded: %d", len(receiver.derived))
+	}
+}
+
+func TestOpeningsBounded(t *testing.T) {
+	sealed, err := EncryptSDP(testSDP, &Secret{Passphrase: "pass"})
+	if err != nil {
+		t.Fatal(err)
+	}
+	for i := 0; i < cap(openings); i++ {
+		openings <- struct{}{}
+	}
+	done := make(chan error, 1)
+	go func() {
+		_, err := DecryptSDP(sealed, &Secret{Passphrase: "pass"})
+		done <- err
+	}()
+	select {
+	case err := <-done:
+		t.Fatalf("expected the derivation to wait for a slot, got %v", err)
+	case <-time.After(100 * time.Millisecond):
+	}
+	for i := 0; i < cap(openings); i++ {
+		<-openings
+	}
+	if err := <-done; err != nil {
+		t.Fatal(err)
+	}
+}
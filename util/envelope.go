@@ -0,0 +1,182 @@
+package util
+
+import (
+	"crypto/ed25519"
+	"crypto/hmac"
+	"crypto/sha256"
+	"crypto/subtle"
+	"encoding/base64"
+	"encoding/json"
+	"errors"
+	"strings"
+	"time"
+)
+
+var ErrEnvelope = errors.New("sdp envelope invalid")
+var ErrExpired = errors.New("sdp envelope expired")
+
+// EnvelopePrefix starts every envelope, so it can be told apart from a bare description
+const EnvelopePrefix = "SME1."
+
+const (
+	algHMAC    = "HS256"
+	algEd25519 = "EdDSA"
+)
+
+// clockSkew is how far in the future an envelope may have been created
+const clockSkew = 30 * time.Second
+
+// EnvelopeKeys sign and verify envelopes, an Ed25519 private key takes precedence over HMAC when signing
+type EnvelopeKeys struct {
+	HMAC           []byte
+	Ed25519Private ed25519.PrivateKey
+	// Ed25519Public are the keys of the peers, the public half of Ed25519Private is always trusted
+	Ed25519Public []ed25519.PublicKey
+}
+
+// EnvelopeOptions describe how envelopes are sealed and opened
+type EnvelopeOptions struct {
+	Keys EnvelopeKeys
+	TTL  time.Duration
+	// Token is shared by both sides, an envelope carrying another token is rejected
+	Token string
+}
+
+// envelopeBody is the signed part of an envelope
+type envelopeBody struct {
+	Alg     string `json:"alg"`
+	Created int64  `json:"iat"`
+	Expires int64  `json:"exp"`
+	Token   string `json:"tok,omitempty"`
+	SDP     string `json:"sdp"`
+}
+
+// IsEnvelope tell whether in is a sealed envelope
+func IsEnvelope(in string) bool {
+	return strings.HasPrefix(strings.TrimSpace(in), EnvelopePrefix)
+}
+
+// SealEnvelope wrap an encoded description as SME1.<body>.<signature>, both parts URL-safe base64
+func SealEnvelope(sdp string, opts *EnvelopeOptions, now time.Time) (string, error) {
+	body := envelopeBody{
+		Created: now.Unix(),
+		Expires: now.Add(opts.TTL).Unix(),
+		Token:   opts.Token,
+		SDP:     sdp,
+	}
+	switch {
+	case opts.Keys.Ed25519Private != nil:
+		body.Alg = algEd25519
+	case opts.Keys.HMAC != nil:
+		body.Alg = algHMAC
+	default:
+		return "", envelopeError(ErrEnvelope, "no signing key")
+	}
+	bodyJSON, err := json.Marshal(body)
+	if err != nil {
+		return "", err
+	}
+	signed := EnvelopePrefix + base64.RawURLEncoding.EncodeToString(bodyJSON)
+	var signature []byte
+	if body.Alg == algEd25519 {
+		signature = ed25519.Sign(opts.Keys.Ed25519Private, []byte(signed))
+	} else {
+		signature = hmacSum(opts.Keys.HMAC, signed)
+	}
+	return signed + "." + base64.RawURLEncoding.EncodeToString(signature), nil
+}
+
+// OpenEnvelope verify an envelope and return the description inside
+func OpenEnvelope(in string, opts *EnvelopeOptions, now time.Time) (string, error) {
+	in = strings.TrimSpace(in)
+	if !strings.HasPrefix(in, EnvelopePrefix) {
+		return "", envelopeError(ErrEnvelope, "description is not signed")
+	}
+	dot := strings.LastIndexByte(in, '.')
+	if dot < len(EnvelopePrefix) {
+		return "", envelopeError(ErrEnvelope, "no signature")
+	}
+	signed := in[:dot]
+	signature, err := base64.RawURLEncoding.DecodeString(in[dot+1:])
+	if err != nil {
+		return "", envelopeError(err, "malformed signature")
+	}
+	bodyJSON, err := base64.RawURLEncoding.DecodeString(signed[len(EnvelopePrefix):])
+	if err != nil {
+		return "", envelopeError(err, "malformed body")
+	}
+	var body envelopeBody
+	if err = json.Unmarshal(bodyJSON, &body); err != nil {
+		return "", envelopeError(err, "malformed body")
+	}
+
+	if !verifyEnvelope(body.Alg, signed, signature, &opts.Keys) {
+		return "", envelopeError(ErrEnvelope, "signature mismatch")
+	}
+	if subtle.ConstantTimeCompare([]byte(body.Token), []byte(opts.Token)) != 1 {
+		return "", envelopeError(ErrEnvelope, "session token mismatch")
+	}
+	if now.Unix() > body.Expires {
+		return "", envelopeError(ErrExpired, "expired at "+time.Unix(body.Expires, 0).UTC().Format(time.RFC3339))
+	}
+	if time.Unix(body.Created, 0).After(now.Add(clockSkew)) {
+		return "", envelopeError(ErrEnvelope, "created in the future")
+	}
+	return body.SDP, nil
+}
+
+func verifyEnvelope(alg, signed string, signature []byte, keys *EnvelopeKeys) bool {
+	switch alg {
+	case algHMAC:
+		return keys.HMAC != nil && hmac.Equal(signature, hmacSum(keys.HMAC, signed))
+	case algEd25519:
+		trusted := append([]ed25519.PublicKey{}, keys.Ed25519Public...)
+		if keys.Ed25519Private != nil {
+			trusted = append(trusted, keys.Ed25519Private.Public().(ed25519.PublicKey))
+		}
+		for _, key := range trusted {
+			if ed25519.Verify(key, []byte(signed), signature) {
+				return true
+			}
+		}
+	}
+	return false
+}
+
+func hmacSum(key []byte, signed string) []byte {
+	mac := hmac.New(sha256.New, key)
+	mac.Write([]byte(signed))
+	return mac.Sum(nil)
+}
+
+func envelopeError(err error, detail string) *SDPError {
+	return &SDPError{Check: CheckEnvelope, Detail: detail, Err: err}
+}
+
+// ParseEd25519PrivateKey accept a base64 32 byte seed or 64 byte private key
+func ParseEd25519PrivateKey(in string) (ed25519.PrivateKey, error) {
+	key, err := base64.StdEncoding.DecodeString(in)
+	if err != nil {
+		return nil, err
+	}
+	switch len(key) {
+	case ed25519.SeedSize:
+		return ed25519.NewKeyFromSeed(key), nil
+	case ed25519.PrivateKeySize:
+		return key, nil
+	default:
+		return nil, ErrEnvelope
+	}
+}
+
+// ParseEd25519PublicKey accept a base64 32 byte public key
+func ParseEd25519PublicKey(in string) (ed25519.PublicKey, error) {
+	key, err := base64.StdEncoding.DecodeString(in)
+	if err != nil {
+		return nil, err
+	}
+	if len(key) != ed25519.PublicKeySize {
+		return nil, ErrEnvelope
+	}
+	return key, nil
+}
@@ -0,0 +1,79 @@
+package util
+
+import (
+	"crypto/ed25519"
+	"errors"
+	"strings"
+	"testing"
+	"time"
+)
+
+func TestEnvelopeHMAC(t *testing.T) {
+	opts := &EnvelopeOptions{Keys: EnvelopeKeys{HMAC: []byte("secret")}, TTL: time.Minute, Token: "room-1"}
+	now := time.Now()
+	sealed, err := SealEnvelope("payload", opts, now)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !IsEnvelope(sealed) {
+		t.Fatalf("envelope not recognised: %v", sealed)
+	}
+	payload, err := OpenEnvelope(sealed, opts, now)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if payload != "payload" {
+		t.Errorf("unexpected payload %q", payload)
+	}
+
+	if _, err = OpenEnvelope(sealed, opts, now.Add(2*time.Minute)); !errors.Is(err, ErrExpired) {
+		t.Errorf("expected ErrExpired, got %v", err)
+	}
+	other := *opts
+	other.Token = "room-2"
+	if _, err = OpenEnvelope(sealed, &other, now); !errors.Is(err, ErrEnvelope) {
+		t.Errorf("expected token mismatch, got %v", err)
+	}
+	other = *opts
+	other.Keys.HMAC = []byte("guess")
+	if _, err = OpenEnvelope(sealed, &other, now); !errors.Is(err, ErrEnvelope) {
+		t.Errorf("expected signature mismatch, got %v", err)
+	}
+
+	// swap the body of another envelope in
+	forged, err := SealEnvelope("evil", &EnvelopeOptions{Keys: EnvelopeKeys{HMAC: []byte("attacker")}, TTL: time.Minute, Token: "room-1"}, now)
+	if err != nil {
+		t.Fatal(err)
+	}
+	tampered := forged[:strings.LastIndexByte(forged, '.')] + sealed[strings.LastIndexByte(sealed, '.'):]
+	if _, err = OpenEnvelope(tampered, opts, now); !errors.Is(err, ErrEnvelope) || !errors.Is(err, ErrSdp) {
+		t.Errorf("expected tampered envelope to be rejected, got %v", err)
+	}
+	if _, err = OpenEnvelope("payload", opts, now); !errors.Is(err, ErrEnvelope) {
+		t.Errorf("expected unsigned description to be rejected, got %v", err)
+	}
+}
+
+func TestEnvelopeEd25519(t *testing.T) {
+	offerPub, offerPriv, _ := ed25519.GenerateKey(nil)
+	answerPub, answerPriv, _ := ed25519.GenerateKey(nil)
+	offerer := &EnvelopeOptions{Keys: EnvelopeKeys{Ed25519Private: offerPriv, Ed25519Public: []ed25519.PublicKey{answerPub}}, TTL: time.Minute}
+	answerer := &EnvelopeOptions{Keys: EnvelopeKeys{Ed25519Private: answerPriv, Ed25519Public: []ed25519.PublicKey{offerPub}}, TTL: time.Minute}
+	stranger := &EnvelopeOptions{Keys: EnvelopeKeys{Ed25519Private: answerPriv}, TTL: time.Minute}
+
+	now := time.Now()
+	sealed, err := SealEnvelope("offer", offerer, now)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if _, err = OpenEnvelope(sealed, answerer, now); err != nil {
+		t.Error(err)
+	}
+	if _, err = OpenEnvelope(sealed, stranger, now); !errors.Is(err, ErrEnvelope) {
+		t.Errorf("expected untrusted key to be rejected, got %v", err)
+	}
+	// an HMAC-only side cannot be fooled into accepting an EdDSA envelope
+	if _, err = OpenEnvelope(sealed, &EnvelopeOptions{Keys: EnvelopeKeys{HMAC: []byte("secret")}}, now); !errors.Is(err, ErrEnvelope) {
+		t.Errorf("expected algorithm mismatch, got %v", err)
+	}
+}
@@ -5,10 +5,68 @@ import (
 	"compress/gzip"
 	"encoding/base64"
 	"encoding/json"
+	"errors"
 	"github.com/pion/webrtc/v4"
 	"io"
+	"strings"
 )
 
+var ErrFormat = errors.New("sdp format invalid")
+var ErrTooLarge = errors.New("sdp too large")
+
+// SDPFormat is the textual form of a session description
+type SDPFormat int
+
+const (
+	// FormatCompressed is base64 of gzip of JSON, the default
+	FormatCompressed SDPFormat = iota
+	// FormatJSON is RTCSessionDescription JSON, as JSON.stringify(pc.localDescription) in a browser
+	FormatJSON
+	// FormatRaw is the plain SDP text
+	FormatRaw
+	// FormatCompact keeps only what a data-only peer needs, URL-safe and short enough for a QR code
+	FormatCompact
+)
+
+var SDPFormatToStr = map[SDPFormat]string{
+	FormatCompressed: "compressed",
+	FormatJSON:       "json",
+	FormatRaw:        "raw",
+	FormatCompact:    "compact",
+}
+
+func (f SDPFormat) String() string {
+	return SDPFormatToStr[f]
+}
+
+// ParseSDPFormat parse a format name, empty string means FormatCompressed
+func ParseSDPFormat(name string) (SDPFormat, error) {
+	if name == "" {
+		return FormatCompressed, nil
+	}
+	for format, str := range SDPFormatToStr {
+		if strings.EqualFold(str, name) {
+			return format, nil
+		}
+	}
+	return FormatCompressed, ErrFormat
+}
+
+// DetectSDPFormat guess the format of an encoded description
+func DetectSDPFormat(in string) SDPFormat {
+	in = strings.TrimSpace(in)
+	switch {
+	case strings.HasPrefix(in, "{"):
+		return FormatJSON
+	case strings.HasPrefix(in, "v="):
+		return FormatRaw
+	case strings.HasPrefix(in, CompactPrefix):
+		return FormatCompact
+	default:
+		return FormatCompressed
+	}
+}
+
 func EncodeSDP(sdp *webrtc.SessionDescription) (string, error) {
 	sdpJSON, err := json.Marshal(sdp)
 	if err != nil {
@@ -32,7 +90,68 @@ func EncodeSDP(sdp *webrtc.SessionDescription) (string, error) {
 	return base64.StdEncoding.EncodeToString(buf.Bytes()), nil
 }
 
+// EncodeSDPFormat encode sdp in the given format
+func EncodeSDPFormat(sdp *webrtc.SessionDescription, format SDPFormat) (string, error) {
+	switch format {
+	case FormatCompressed:
+		return EncodeSDP(sdp)
+	case FormatJSON:
+		sdpJSON, err := json.Marshal(sdp)
+		if err != nil {
+			return "", err
+		}
+		return string(sdpJSON), nil
+	case FormatRaw:
+		return sdp.SDP, nil
+	case FormatCompact:
+		return EncodeCompact(sdp)
+	default:
+		return "", ErrFormat
+	}
+}
+
+// Limits bound the size of an untrusted description
+type Limits struct {
+	// MaxEncodedSize is the longest accepted input
+	MaxEncodedSize int
+	// MaxDecodedSize is the longest accepted SDP after decompression
+	MaxDecodedSize int
+}
+
+// DefaultLimits is far above what a data-only description needs
+var DefaultLimits = Limits{
+	MaxEncodedSize: 64 << 10,
+	MaxDecodedSize: 256 << 10,
+}
+
+// DecodeSDP accept every SDPFormat within DefaultLimits, a raw SDP carries no type so it is left unset
 func DecodeSDP(in string) (*webrtc.SessionDescription, error) {
+	return DecodeSDPLimit(in, DefaultLimits)
+}
+
+// DecodeSDPLimit is DecodeSDP with explicit limits
+func DecodeSDPLimit(in string, limits Limits) (*webrtc.SessionDescription, error) {
+	if len(in) > limits.MaxEncodedSize {
+		return nil, ErrTooLarge
+	}
+	in = strings.TrimSpace(in)
+	switch DetectSDPFormat(in) {
+	case FormatJSON:
+		if len(in) > limits.MaxDecodedSize {
+			return nil, ErrTooLarge
+		}
+		return unmarshalSDP([]byte(in))
+	case FormatRaw:
+		if len(in) > limits.MaxDecodedSize {
+			return nil, ErrTooLarge
+		}
+		// browsers and shells may hand over LF only line endings
+		sdp := strings.ReplaceAll(strings.ReplaceAll(in, "\r\n", "\n"), "\n", "\r\n") + "\r\n"
+		return &webrtc.SessionDescription{SDP: sdp}, nil
+	case FormatCompact:
+		return DecodeCompact(in)
+	}
+
 	buf, err := base64.StdEncoding.DecodeString(in)
 	if err != nil {
 		return nil, err
@@ -43,41 +162,68 @@ func DecodeSDP(in string) (*webrtc.SessionDescription, error) {
 	}
 	defer r.Close()
 
-	sdpBytes, err := io.ReadAll(r)
+	// read one byte past the limit to tell a full buffer from an oversized one
+	sdpBytes, err := io.ReadAll(io.LimitReader(r, int64(limits.MaxDecodedSize)+1))
 	if err != nil {
 		return nil, err
 	}
+	if len(sdpBytes) > limits.MaxDecodedSize {
+		return nil, ErrTooLarge
+	}
 
-	var sdp webrtc.SessionDescription
-	err = json.Unmarshal(sdpBytes, &sdp)
+	return unmarshalSDP(sdpBytes)
+}
+
+// DecodeSDPAs decode in and fill the type when the input did not carry one
+func DecodeSDPAs(in string, sdpType webrtc.SDPType) (*webrtc.SessionDescription, error) {
+	return decodeSDPAs(in, sdpType, DefaultLimits)
+}
+
+func decodeSDPAs(in string, sdpType webrtc.SDPType, limits Limits) (*webrtc.SessionDescription, error) {
+	sdp, err := DecodeSDPLimit(in, limits)
 	if err != nil {
 		return nil, err
 	}
-
-	return &sdp, nil
+	if sdp.Type == webrtc.SDPTypeUnknown {
+		sdp.Type = sdpType
+	}
+	return sdp, nil
 }
 
-func ValidateSDP(input string) error {
-	buf, err := base64.StdEncoding.DecodeString(input)
+// ParseSDP decode input once and check it is a complete description of the expected type
+func ParseSDP(input string, expected webrtc.SDPType, limits Limits) (*webrtc.SessionDescription, error) {
+	sdp, err := decodeSDPAs(input, expected, limits)
 	if err != nil {
-		return err
+		return nil, sdpError(CheckDecode, err, "cannot decode %v", DetectSDPFormat(input))
 	}
-	r, err := gzip.NewReader(bytes.NewReader(buf))
-	if err != nil {
-		return err
+	if err = ValidateDescription(sdp, expected, false); err != nil {
+		return nil, err
 	}
-	defer r.Close()
+	return sdp, nil
+}
 
-	sdpBytes, err := io.ReadAll(r)
+// ValidateSDP check input decodes within DefaultLimits to a complete description of the type it carries,
+// a raw SDP carries no type
+func ValidateSDP(input string) error {
+	sdp, err := DecodeSDPLimit(input, DefaultLimits)
 	if err != nil {
-		return err
+		return sdpError(CheckDecode, err, "cannot decode %v", DetectSDPFormat(input))
 	}
+	return ValidateDescription(sdp, sdp.Type, false)
+}
+
+// ValidateSDPType is ParseSDP within DefaultLimits, without the result
+func ValidateSDPType(input string, expected webrtc.SDPType) error {
+	_, err := ParseSDP(input, expected, DefaultLimits)
+	return err
+}
 
+func unmarshalSDP(sdpBytes []byte) (*webrtc.SessionDescription, error) {
 	var sdp webrtc.SessionDescription
-	err = json.Unmarshal(sdpBytes, &sdp)
+	err := json.Unmarshal(sdpBytes, &sdp)
 	if err != nil {
-		return err
+		return nil, err
 	}
 
-	return nil
+	return &sdp, nil
 }
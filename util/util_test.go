@@ -0,0 +1,167 @@
+package util
+
+import (
+	"bytes"
+	"compress/gzip"
+	"encoding/base64"
+	"errors"
+	"github.com/pion/webrtc/v4"
+	"runtime"
+	"strings"
+	"testing"
+)
+
+const testSDP = "v=0\r\no=- 0 0 IN IP4 127.0.0.1\r\ns=-\r\nt=0 0\r\n"
+
+func TestSDPFormatRoundTrip(t *testing.T) {
+	offer := &webrtc.SessionDescription{Type: webrtc.SDPTypeOffer, SDP: testSDP}
+	for format := range SDPFormatToStr {
+		if format == FormatCompact {
+			// lossy, see TestCompactSDP
+			continue
+		}
+		encoded, err := EncodeSDPFormat(offer, format)
+		if err != nil {
+			t.Fatal(format, err)
+		}
+		if detected := DetectSDPFormat(encoded); detected != format {
+			t.Errorf("%v detected as %v", format, detected)
+		}
+		decoded, err := DecodeSDPAs(encoded, webrtc.SDPTypeOffer)
+		if err != nil {
+			t.Fatal(format, err)
+		}
+		if decoded.Type != offer.Type || decoded.SDP != offer.SDP {
+			t.Errorf("%v round trip mismatch: %v", format, decoded)
+		}
+	}
+}
+
+func TestDecodeBrowserSDP(t *testing.T) {
+	// JSON.stringify(pc.localDescription)
+	decoded, err := DecodeSDP(`{"type":"answer","sdp":"v=0\r\no=- 0 0 IN IP4 127.0.0.1\r\ns=-\r\nt=0 0\r\n"}`)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if decoded.Type != webrtc.SDPTypeAnswer || decoded.SDP != testSDP {
+		t.Errorf("unexpected description: %v", decoded)
+	}
+
+	// pasted from a terminal with LF line endings
+	decoded, err = DecodeSDPAs("v=0\no=- 0 0 IN IP4 127.0.0.1\ns=-\nt=0 0\n", webrtc.SDPTypeAnswer)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if decoded.Type != webrtc.SDPTypeAnswer || decoded.SDP != testSDP {
+		t.Errorf("unexpected description: %v", decoded)
+	}
+
+	if _, err = ParseSDPFormat("xml"); err != ErrFormat {
+		t.Errorf("expected ErrFormat, got %v", err)
+	}
+}
+
+func gzipBase64(t testing.TB, data []byte) string {
+	var buf bytes.Buffer
+	g, err := gzip.NewWriterLevel(&buf, gzip.BestCompression)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if _, err = g.Write(data); err != nil {
+		t.Fatal(err)
+	}
+	if err = g.Close(); err != nil {
+		t.Fatal(err)
+	}
+	return base64.StdEncoding.EncodeToString(buf.Bytes())
+}
+
+func TestDecompressionBomb(t *testing.T) {
+	// 64MB of zeros inflate from a ~90KB blob
+	bomb := gzipBase64(t, make([]byte, 64<<20))
+	limits := Limits{MaxEncodedSize: len(bomb), MaxDecodedSize: 256 << 10}
+
+	var before, after runtime.MemStats
+	runtime.GC()
+	runtime.ReadMemStats(&before)
+	_, err := DecodeSDPLimit(bomb, limits)
+	runtime.ReadMemStats(&after)
+	if !errors.Is(err, ErrTooLarge) {
+		t.Fatalf("expected ErrTooLarge, got %v", err)
+	}
+	if allocated := after.TotalAlloc - before.TotalAlloc; allocated > 4<<20 {
+		t.Errorf("decoding allocated %d bytes", allocated)
+	}
+
+	if _, err = DecodeSDP(bomb); !errors.Is(err, ErrTooLarge) {
+		t.Errorf("expected encoded size to be rejected, got %v", err)
+	}
+	var sdpErr *SDPError
+	if err = ValidateSDP(bomb); !errors.As(err, &sdpErr) || !errors.Is(err, ErrTooLarge) {
+		t.Errorf("expected SDPError wrapping ErrTooLarge, got %v", err)
+	}
+}
+
+func FuzzDecodeSDP(f *testing.F) {
+	encoded, err := EncodeSDP(&webrtc.SessionDescription{Type: webrtc.SDPTypeOffer, SDP: testSDP})
+	if err != nil {
+		f.Fatal(err)
+	}
+	f.Add(encoded)
+	f.Add(testSDP)
+	f.Add(`{"type":"offer","sdp":"v=0\r\n"}`)
+	f.Add(gzipBase64(f, make([]byte, 1<<20)))
+	limits := Limits{MaxEncodedSize: 4 << 10, MaxDecodedSize: 16 << 10}
+	f.Fuzz(func(t *testing.T, in string) {
+		var before, after runtime.MemStats
+		runtime.ReadMemStats(&before)
+		sdp, err := DecodeSDPLimit(in, limits)
+		runtime.ReadMemStats(&after)
+		if len(in) > limits.MaxEncodedSize && !errors.Is(err, ErrTooLarge) {
+			t.Fatalf("oversized input accepted: %v", err)
+		}
+		if err == nil && len(sdp.SDP) > 2*limits.MaxDecodedSize {
+			t.Fatalf("decoded %d bytes", len(sdp.SDP))
+		}
+		// input, base64, inflated buffer and JSON copies all stay within a few limits
+		if allocated := after.TotalAlloc - before.TotalAlloc; allocated > 1<<20 {
+			t.Fatalf("decoding allocated %d bytes", allocated)
+		}
+	})
+}
+
+func TestCompactSDP(t *testing.T) {
+	offer := &webrtc.SessionDescription{Type: webrtc.SDPTypeOffer, SDP: validOffer}
+	compact, err := EncodeSDPFormat(offer, FormatCompact)
+	if err != nil {
+		t.Fatal(err)
+	}
+	compressed, err := EncodeSDP(offer)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(compact) >= len(compressed) {
+		t.Errorf("compact form is %d long, compressed %d", len(compact), len(compressed))
+	}
+	if strings.ContainsAny(compact, "+/= ") {
+		t.Errorf("compact form is not URL-safe: %v", compact)
+	}
+	decoded, err := ParseSDP(compact, webrtc.SDPTypeOffer, DefaultLimits)
+	if err != nil {
+		t.Fatal(err)
+	}
+	for _, line := range []string{
+		"a=ice-ufrag:abcd",
+		"a=ice-pwd:abcdefghijklmnopqrstuvwx",
+		"a=setup:actpass",
+		"a=fingerprint:sha-256 3E:4A:9C:2B:57:6C:66:3A:91:C2:8D:58:7E:A0:9B:31:6F:3D:1A:A6:5E:7C:44:2E:8B:55:0D:34:A1:1C:9E:70",
+		" 127.0.0.1 50000 typ host",
+	} {
+		if !strings.Contains(decoded.SDP, line) {
+			t.Errorf("rebuilt sdp lacks %q:\n%v", line, decoded.SDP)
+		}
+	}
+	if _, err = DecodeSDP(compact[:len(compact)-6]); err == nil {
+		t.Error("expected truncated compact sdp to be rejected")
+	}
+}
@@ -0,0 +1,100 @@
+package util
+
+import (
+	"errors"
+	"fmt"
+	"github.com/pion/sdp/v3"
+	"github.com/pion/webrtc/v4"
+)
+
+var ErrSdp = errors.New("sdp invalid")
+
+// SDPCheck names the validation step that rejected a description
+type SDPCheck string
+
+const (
+	CheckDecode      SDPCheck = "decode"
+	CheckType        SDPCheck = "type"
+	CheckMedia       SDPCheck = "media"
+	CheckFingerprint SDPCheck = "fingerprint"
+	CheckICE         SDPCheck = "ice"
+	CheckCandidate   SDPCheck = "candidate"
+	CheckEnvelope    SDPCheck = "envelope"
+	CheckEncryption  SDPCheck = "encryption"
+	CheckChunk       SDPCheck = "chunk"
+)
+
+// SDPError describe why a description was rejected, it matches ErrSdp with errors.Is
+type SDPError struct {
+	Check  SDPCheck
+	Detail string
+	// Err is the underlying cause, may be nil
+	Err error
+}
+
+func (e *SDPError) Error() string {
+	msg := fmt.Sprintf("%v: %v: %v", ErrSdp, e.Check, e.Detail)
+	if e.Err != nil {
+		msg += ": " + e.Err.Error()
+	}
+	return msg
+}
+
+func (e *SDPError) Unwrap() []error {
+	if e.Err == nil {
+		return []error{ErrSdp}
+	}
+	return []error{ErrSdp, e.Err}
+}
+
+func sdpError(check SDPCheck, err error, format string, a ...interface{}) *SDPError {
+	return &SDPError{Check: check, Detail: fmt.Sprintf(format, a...), Err: err}
+}
+
+// ValidateDescription check that desc is a usable data-only description of the expected type,
+// trickle allows a description without candidates
+func ValidateDescription(desc *webrtc.SessionDescription, expected webrtc.SDPType, trickle bool) error {
+	if desc.Type != expected {
+		return sdpError(CheckType, nil, "expected %v, got %v", expected, desc.Type)
+	}
+	parsed, err := desc.Unmarshal()
+	if err != nil {
+		return sdpError(CheckDecode, err, "malformed sdp")
+	}
+
+	var application *sdp.MediaDescription
+	for _, media := range parsed.MediaDescriptions {
+		if media.MediaName.Media == "application" {
+			application = media
+			break
+		}
+	}
+	if application == nil {
+		return sdpError(CheckMedia, nil, "no application m-line")
+	}
+
+	if _, ok := attribute(parsed, application, "fingerprint"); !ok {
+		return sdpError(CheckFingerprint, nil, "no dtls fingerprint")
+	}
+	if ufrag, ok := attribute(parsed, application, "ice-ufrag"); !ok || ufrag == "" {
+		return sdpError(CheckICE, nil, "no ice-ufrag")
+	}
+	if pwd, ok := attribute(parsed, application, "ice-pwd"); !ok || pwd == "" {
+		return sdpError(CheckICE, nil, "no ice-pwd")
+	}
+
+	if !trickle {
+		if _, ok := application.Attribute("candidate"); !ok {
+			return sdpError(CheckCandidate, nil, "no candidate and trickle is off")
+		}
+	}
+	return nil
+}
+
+// attribute look up key on the media first, then on the session
+func attribute(session *sdp.SessionDescription, media *sdp.MediaDescription, key string) (string, bool) {
+	if value, ok := media.Attribute(key); ok {
+		return value, true
+	}
+	return session.Attribute(key)
+}
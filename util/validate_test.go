@@ -0,0 +1,93 @@
+package util
+
+import (
+	"errors"
+	"github.com/pion/webrtc/v4"
+	"strings"
+	"testing"
+)
+
+const validOffer = "v=0\r\n" +
+	"o=- 1 2 IN IP4 127.0.0.1\r\n" +
+	"s=-\r\n" +
+	"t=0 0\r\n" +
+	"a=fingerprint:sha-256 3E:4A:9C:2B:57:6C:66:3A:91:C2:8D:58:7E:A0:9B:31:6F:3D:1A:A6:5E:7C:44:2E:8B:55:0D:34:A1:1C:9E:70\r\n" +
+	"m=application 9 UDP/DTLS/SCTP webrtc-datachannel\r\n" +
+	"c=IN IP4 0.0.0.0\r\n" +
+	"a=setup:actpass\r\n" +
+	"a=mid:0\r\n" +
+	"a=sendrecv\r\n" +
+	"a=sctp-port:5000\r\n" +
+	"a=ice-ufrag:abcd\r\n" +
+	"a=ice-pwd:abcdefghijklmnopqrstuvwx\r\n" +
+	"a=candidate:1 1 udp 2130706431 127.0.0.1 50000 typ host\r\n"
+
+func TestValidateDescription(t *testing.T) {
+	offer := &webrtc.SessionDescription{Type: webrtc.SDPTypeOffer, SDP: validOffer}
+	if err := ValidateDescription(offer, webrtc.SDPTypeOffer, false); err != nil {
+		t.Fatal(err)
+	}
+
+	cases := []struct {
+		name     string
+		sdpType  webrtc.SDPType
+		sdp      string
+		expected SDPCheck
+	}{
+		{"answer passed as offer", webrtc.SDPTypeAnswer, validOffer, CheckType},
+		{"no application", webrtc.SDPTypeOffer, strings.Replace(validOffer, "m=application", "m=audio", 1), CheckMedia},
+		{"no fingerprint", webrtc.SDPTypeOffer, strings.Replace(validOffer, "a=fingerprint", "a=x-fingerprint", 1), CheckFingerprint},
+		{"no ufrag", webrtc.SDPTypeOffer, strings.Replace(validOffer, "a=ice-ufrag:abcd\r\n", "", 1), CheckICE},
+		{"no candidate", webrtc.SDPTypeOffer, validOffer[:strings.Index(validOffer, "a=candidate")], CheckCandidate},
+	}
+	for _, c := range cases {
+		err := ValidateDescription(&webrtc.SessionDescription{Type: c.sdpType, SDP: c.sdp}, webrtc.SDPTypeOffer, false)
+		if !errors.Is(err, ErrSdp) {
+			t.Errorf("%v: expected ErrSdp, got %v", c.name, err)
+			continue
+		}
+		var sdpErr *SDPError
+		if !errors.As(err, &sdpErr) || sdpErr.Check != c.expected {
+			t.Errorf("%v: expected check %v, got %v", c.name, c.expected, err)
+		}
+	}
+
+	withoutCandidate := &webrtc.SessionDescription{Type: webrtc.SDPTypeOffer, SDP: validOffer[:strings.Index(validOffer, "a=candidate")]}
+	if err := ValidateDescription(withoutCandidate, webrtc.SDPTypeOffer, true); err != nil {
+		t.Errorf("trickle description rejected: %v", err)
+	}
+}
+
+func TestValidateSDP(t *testing.T) {
+	encoded, err := EncodeSDP(&webrtc.SessionDescription{Type: webrtc.SDPTypeOffer, SDP: validOffer})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if err = ValidateSDP(encoded); err != nil {
+		t.Error(err)
+	}
+	if err = ValidateSDP(validOffer); err != nil {
+		t.Error(err)
+	}
+	var sdpErr *SDPError
+	if err = ValidateSDP("not base64!"); !errors.As(err, &sdpErr) || sdpErr.Check != CheckDecode {
+		t.Errorf("expected decode error, got %v", err)
+	}
+}
+
+func TestValidateSDPType(t *testing.T) {
+	encoded, err := EncodeSDP(&webrtc.SessionDescription{Type: webrtc.SDPTypeOffer, SDP: validOffer})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if err = ValidateSDPType(encoded, webrtc.SDPTypeOffer); err != nil {
+		t.Error(err)
+	}
+	if err = ValidateSDPType(validOffer, webrtc.SDPTypeAnswer); err != nil {
+		t.Errorf("raw SDP takes the expected type, got %v", err)
+	}
+	var sdpErr *SDPError
+	if err = ValidateSDPType(encoded, webrtc.SDPTypeAnswer); !errors.As(err, &sdpErr) || sdpErr.Check != CheckType {
+		t.Errorf("expected type error, got %v", err)
+	}
+}